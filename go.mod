@@ -4,6 +4,5 @@ go 1.24.0
 
 require (
 	github.com/ngicks/go-iterator-helper v0.0.18
-	github.com/ngicks/und v1.0.0-alpha8
 	golang.org/x/sync v0.11.0
 )
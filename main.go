@@ -1,541 +1,225 @@
 package main
 
 import (
-	"bytes"
-	"cmp"
 	"context"
-	"encoding/json"
-	"errors"
 	"flag"
 	"fmt"
-	"io"
-	"io/fs"
-	"iter"
 	"os"
-	"os/exec"
 	"os/signal"
 	"path/filepath"
-	"reflect"
-	"runtime"
 	"slices"
-	"strings"
-	"sync"
+	"strconv"
 	"syscall"
 
-	"github.com/ngicks/go-iterator-helper/hiter"
-	"github.com/ngicks/go-iterator-helper/hiter/ioiter"
-	"github.com/ngicks/go-iterator-helper/x/exp/xiter"
-	"golang.org/x/sync/errgroup"
+	"github.com/ngicks/ngpkgmgr/manager"
 )
 
 var (
-	dir   = flag.String("dir", "", "")
-	v     = flag.Bool("v", false, "")
+	dirs  []string
+	v     int
+	q     = flag.Bool("q", false, "quiet: prints only errors and the summary")
 	f     = flag.Bool("f", false, "force option: ignores errors")
 	n     = flag.String("new", "", "creates command sets for given name")
+	tmpl  = flag.String("template", "", "template for -new: script, github-release or go-install")
 	debug = flag.Bool("debug", false, "debug")
+	o     = flag.String("o", "text", "output format: text, json, table or plain")
+	dry   = flag.Bool("dry-run", false, "runs ver and checklatest only, prints what install / update would do")
+	tag   = flag.String("tag", "", "selects only sets having any of the comma separated tags")
+	prof  = flag.String("profile", "", "selects only sets in the profile of this name in config.json. Defaults to $PKGMGR_PROFILE")
+
+	parallel = flag.Int("j", 0, "number of sets processed concurrently. 0 means 5 for checking versions and 1 otherwise")
+	timeout  = flag.Duration("timeout", 0, "default timeout for each command. 0 means no timeout")
+	retries  = flag.Int("retries", 0, "default number of retries for failed checklatest, install and update")
+	cacheTTL = flag.Duration("cache-ttl", 0, "reuses checklatest results cached within this duration. 0 disables the cache")
+	refresh  = flag.Bool("refresh", false, "ignores cached checklatest results, still refreshing the cache")
+
+	allowDowngrade = flag.Bool("allow-downgrade", false, "update whenever current and target versions differ, even if target is older")
+	verifyAfter    = flag.Bool("verify-after", false, "verifies every set's ver matches its target after install / update")
+	noRollback     = flag.Bool("no-rollback", false, "does not re-install the previous version when update fails")
+	syncFirst      = flag.Bool("sync", false, "syncs the config dir from its remote before running")
+	pin            = flag.Bool("pin", false, "pins sets given as <name>@<version> to the version once installed or updated, or the set switched to by use")
+	reinstall      = flag.Bool("reinstall", false, "installs even sets which seem already installed")
+	yes            = flag.Bool("yes", false, "updates without asking for confirmation")
+	nonInteractive = flag.Bool("non-interactive", false, "gives commands no stdin and sets env like CI=1 and DEBIAN_FRONTEND=noninteractive")
+	wait           = flag.Bool("wait", false, "waits for another run using the config dir to finish instead of failing")
+	noProject      = flag.Bool("no-project", false, "does not look for a project-local .pkgmgr directory")
+
+	logLevel  = flag.String("log-level", "", "log level: debug, info, warn or error (default info)")
+	logFormat = flag.String("log-format", "", "log format: text or json (default text)")
+	logToFile = flag.Bool("log-to-file", false, "also appends logs to .log/ngpkgmgr.log under the config dir")
+	color     = flag.String("color", "", "colors status lines: auto, always or never (default auto, which honors NO_COLOR)")
 )
 
-type namedCommandSet struct {
-	Name string
-	Set  commandSet
+const usage = `Usage:
+  %[1]s [flags] [<tgt>] <ver|checklatest|install|update|uninstall>
+  %[1]s [flags] <ver|checklatest|install|update|uninstall> [<tgt>...]
+  %[1]s [flags] <tgt> run <command> [<arg>...]
+  %[1]s [flags] list [<tgt>]
+  %[1]s [flags] show [--ver <version>] <name>
+  %[1]s [flags] edit [--dir] <name>
+  %[1]s [flags] remove <name>
+  %[1]s [flags] rename <old> <new>
+  %[1]s [flags] validate
+  %[1]s [flags] doctor
+  %[1]s [flags] outdated [--json] [<tgt>]
+  %[1]s [flags] check [<tgt>]
+  %[1]s [flags] history [--limit <n>] [<name>]
+  %[1]s [flags] daemon [--interval <duration>] [--once] [--metrics-addr <addr>] [--metrics-file <path>] [--push <url>] [<tgt>]
+  %[1]s [flags] schedule [--interval <duration>] <systemd|launchd|windows>
+  %[1]s [flags] env [--shell <sh|fish|powershell>]
+  %[1]s [flags] use <name> [<version>]
+  %[1]s [flags] gc [--keep <n>]
+  %[1]s [flags] fetch [-o <path>] [--retries <n>] [--chunks <n>] [--header '<key>: <value>']... <url>
+  %[1]s [flags] extract [-C <dir>] [--strip-components <n>] [--format <format>] <archive>
+  %[1]s [flags] pin [<name> <version>]
+  %[1]s [flags] unpin <name>
+  %[1]s [flags] disable <name>...
+  %[1]s [flags] enable <name>...
+  %[1]s [flags] sync [<url>]
+  %[1]s [flags] freeze [--file <path>] [<tgt>]
+  %[1]s [flags] restore [--file <path>] [<tgt>]
+  %[1]s [flags] export sbom [--format <cyclonedx|spdx>] [-o <path>] [<tgt>]
+  %[1]s [flags] export tool-versions [--installed] [-o <path>] [<tgt>]
+  %[1]s [flags] export --format <brewfile|shell|ansible> [-o <path>] [<tgt>]
+  %[1]s [flags] import [--format <tool-versions|brewfile>] <file>
+  %[1]s [flags] tui [<tgt>]
+  %[1]s [flags] audit [--json] [--fail-on <low|medium|high|critical|none>] [<tgt>]
+  %[1]s [flags] self-update [--check]
+  %[1]s [flags] completion <bash|zsh|fish|powershell>
+  %[1]s [flags] -new <name> [-template <template>]
+  %[1]s [flags] new
+
+<tgt> is a command set name, a path.Match pattern or a comma separated list of them.
+-tag further narrows sets to ones tagged with any of given tags, and -profile to ones in the profile.
+
+A .pkgmgr directory in the working directory or its parents is a project-local
+config dir: its sets take precedence over the config dir's and an empty <tgt>
+selects only them.
+
+Flags:
+`
+
+func init() {
+	flag.StringVar(o, "output", "text", "same as -o")
+	flag.BoolVar(q, "quiet", false, "same as -q")
+	flag.BoolVar(f, "keep-going", false, "same as -f")
+	flag.Var(verbosity{&v, 1}, "v", "prints output of every command. Given twice, same as -vv")
+	flag.Var(verbosity{&v, 2}, "vv", "also logs commands run with their args and env, as -log-level debug")
+	flag.Func("dir", "config dir. May be repeated: later dirs override earlier ones per set name. Defaults to $PKGMGR_PATH", func(s string) error {
+		dirs = append(dirs, s)
+		return nil
+	})
 }
 
-type commandSet struct {
-	Ver         []string `json:"ver,omitzero"`
-	CheckLatest []string `json:"checklatest,omitzero"`
-	Install     []string `json:"install,omitzero"`
-	Update      []string `json:"update,omitzero"`
-	After       []string `json:"after,omitzero"`
+// verbosity is a boolean flag adding step to n each time it is given, so that -v can be repeated.
+type verbosity struct {
+	n    *int
+	step int
 }
 
-type command string
-
-const (
-	commandVer         command = "ver"
-	commandChecklatest command = "checklatest"
-	commandInstall     command = "install"
-	commandUpdate      command = "update"
-)
-
-var cmds = []command{commandVer, commandChecklatest, commandInstall, commandUpdate}
-
-func (c commandSet) Select(kind command) []string {
-	switch kind {
-	default:
-		panic(fmt.Errorf("unknown command: %q", kind))
-	case commandVer:
-		return c.Ver
-	case commandChecklatest:
-		return c.CheckLatest
-	case commandInstall:
-		return c.Install
-	case commandUpdate:
-		return c.Update
+func (v verbosity) String() string {
+	if v.n == nil {
+		return "0"
 	}
+	return strconv.Itoa(*v.n)
 }
 
-type commandExecutor struct {
-	dir        string
-	commandSet namedCommandSet
-	stdin      io.Reader
-	stdout     io.Writer
-	stderr     io.Writer
-}
-
-func newCommandExecutor(
-	dir string,
-	commandSet namedCommandSet,
-	stdin io.Reader,
-	stdout io.Writer,
-	stderr io.Writer,
-) *commandExecutor {
-	return &commandExecutor{
-		dir:        dir,
-		commandSet: commandSet,
-		stdin:      stdin,
-		stdout:     stdout,
-		stderr:     stderr,
-	}
-}
-
-func (e commandExecutor) Exec(
-	ctx context.Context,
-	kind command,
-	ver string,
-	verbose bool,
-) (string, error) {
-	args := e.commandSet.Set.Select(kind)
-	if len(args) > 0 {
-		dict := dictReplacer{
-			"${VER}":  ver,
-			"${OS}":   runtime.GOOS,
-			"${ARCH}": runtime.GOARCH,
-		}
-		args = slices.Collect(dict.Map(slices.Values(args)))
-	} else {
-		for _, suf := range []string{"", ".sh", ".exe", ".bat", ".ps1"} {
-			name := filepath.Join(e.dir, e.commandSet.Name, string(kind)+suf)
-			_, err := os.Stat(name)
-			if err == nil {
-				args = append(slices.Clip(args), name)
-				break
-			}
-		}
-		if len(args) == 0 {
-			return "", fmt.Errorf("command not found")
-		}
-	}
-
-	cmd := exec.CommandContext(ctx, args[0])
-	if len(args) > 1 {
-		cmd.Args = args
-	}
-
-	cmd.Stdin = e.stdin
-
-	buf := new(bytes.Buffer)
-	if kind == commandInstall {
-		cmd.Stdout = e.stdout
-	} else if !verbose {
-		cmd.Stdout = buf
-	} else {
-		cmd.Stdout = io.MultiWriter(buf, e.stdout)
+func (v verbosity) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
 	}
-	cmd.Stderr = e.stderr
-
-	cmd.Env = append(os.Environ(), "OS="+runtime.GOOS, "ARCH="+runtime.GOARCH)
-	if ver != "" {
-		cmd.Env = append(cmd.Env, "VER="+ver)
+	if b {
+		*v.n += v.step
 	}
-
-	err := cmd.Run()
-	return buf.String(), err
+	return nil
 }
 
-const (
-	pinnedVersionsFileName = ".pin.json"
-)
+func (v verbosity) IsBoolFlag() bool { return true }
 
 func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), usage, filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-	defer stop()
-
-	cfgDir := *dir
-
-	if cfgDir == "" {
-		userCfgDir, err := os.UserConfigDir()
-		if err != nil {
-			panic(fmt.Errorf("getting os.UserConfigDir: %w", err))
-		}
-		cfgDir = filepath.Join(userCfgDir, "ngpkgmgr")
-	}
-
-	if *n != "" {
-		f, err := os.OpenFile(filepath.Join(cfgDir, *n+".json"), os.O_RDWR|os.O_CREATE|os.O_EXCL, fs.ModePerm)
-		switch {
-		default:
-			panic(err)
-		case errors.Is(err, fs.ErrExist):
-		case err == nil:
-			enc := json.NewEncoder(f)
-			enc.SetIndent("", "    ")
-			err := enc.Encode(commandSet{
-				Ver:         []string{},
-				Install:     []string{},
-				CheckLatest: []string{},
-				Update:      []string{},
-				After:       []string{},
-			})
-			_ = f.Close()
-			if err != nil {
-				panic(err)
-			}
-		}
-		err = os.Mkdir(filepath.Join(cfgDir, *n), fs.ModePerm)
-		if err != nil && !errors.Is(err, fs.ErrExist) {
-			panic(err)
-		}
-		for _, c := range cmds {
-			scriptName := filepath.Join(cfgDir, *n, string(c))
-			switch runtime.GOOS {
-			case "windows":
-				scriptName += ".ps1"
-			default:
-				scriptName += ".sh"
-			}
-			f, err := os.OpenFile(scriptName, os.O_RDWR|os.O_CREATE|os.O_EXCL, fs.ModePerm)
-			switch {
-			default:
-				panic(err)
-			case errors.Is(err, fs.ErrExist):
-			case err == nil:
-				_, err := fmt.Fprintf(f, "#!%s\n", cmp.Or(os.Getenv("SHELL"), "/bin/bash"))
-				_ = f.Close()
-				if err != nil {
-					panic(err)
-				}
-			}
-		}
-		return
-	}
-
-	var tgt, cmd string
-	args := flag.Args()
-	switch len(args) {
-	case 2:
-		tgt, cmd = args[0], args[1]
-	case 1:
-		cmd = args[0]
-	default:
-		panic(fmt.Errorf("wrong args length: want 2 or 1, got %d", len(args)))
-	}
-
-	if !slices.Contains(cmds, command(cmd)) {
-		panic(fmt.Errorf("unknown command: must be one of %v", cmds))
-	}
-
-	pinnedVersions := map[string]string{}
-	pinFile, err := os.Open(filepath.Join(cfgDir, pinnedVersionsFileName))
+	err := run(ctx, flag.Args())
+	stop()
 	if err != nil {
-		if !errors.Is(err, fs.ErrNotExist) {
-			panic(err)
-		}
-	} else {
-		err = json.NewDecoder(pinFile).Decode(&pinnedVersions)
-		_ = pinFile.Close()
-		if err != nil {
-			panic(err)
-		}
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 	}
+	os.Exit(manager.ExitCode(err))
+}
 
-	for k, v := range pinnedVersions {
-		if k != strings.TrimSpace(k) || v != strings.TrimSpace(v) {
-			panic(fmt.Errorf("pinned version %q has space prefix and/or suffix in name or version", k))
-		}
+func run(ctx context.Context, args []string) error {
+	if len(dirs) == 0 {
+		dirs = slices.DeleteFunc(filepath.SplitList(os.Getenv("PKGMGR_PATH")), func(s string) bool { return s == "" })
 	}
-
-	var sets []namedCommandSet
-	if tgt != "" {
-		f, err := os.Open(filepath.Join(cfgDir, tgt+".json"))
-		if err == nil {
-			var set commandSet
-			err = json.NewDecoder(f).Decode(&set)
-			_ = f.Close()
-			if err != nil {
-				panic(err)
-			}
-			sets = append(sets, namedCommandSet{Name: tgt, Set: set})
-		} else if !errors.Is(err, fs.ErrNotExist) {
-			panic(err)
-		} else {
-			s, err := os.Stat(filepath.Join(cfgDir, tgt))
-			if err != nil {
-				panic(err)
-			}
-			if !s.IsDir() {
-				panic(fmt.Errorf("file %[1]q.json or directory %[1]q must exist", tgt))
-			}
-			sets = append(sets, namedCommandSet{Name: tgt})
-		}
-	} else {
-		dir, err := os.Open(cfgDir)
-		if err != nil {
-			panic(err)
-		}
-
-		sets, err = hiter.TryAppendSeq(
-			sets[:0],
-			xiter.Map2(
-				func(fi fs.FileInfo, err error) (namedCommandSet, error) {
-					switch {
-					default:
-						return namedCommandSet{}, err
-					case fi.Mode().IsRegular() && strings.HasSuffix(fi.Name(), ".json"):
-						f, err := os.Open(filepath.Join(cfgDir, fi.Name()))
-						if err != nil {
-							return namedCommandSet{}, err
-						}
-						var set commandSet
-						err = json.NewDecoder(f).Decode(&set)
-						_ = f.Close()
-						if err != nil {
-							return namedCommandSet{}, err
-						}
-						return namedCommandSet{Name: strings.TrimSuffix(fi.Name(), ".json"), Set: set}, nil
-					case fi.IsDir():
-						// directory should contain scripts.
-						return namedCommandSet{Name: fi.Name()}, nil
-					}
-				},
-				xiter.Filter2(
-					func(fi fs.FileInfo, err error) bool {
-						switch {
-						default:
-							return false
-						case err != nil,
-							fi.Mode().IsRegular() && strings.HasSuffix(fi.Name(), ".json") && fi.Name() != pinnedVersionsFileName,
-							fi.IsDir():
-							return true
-						}
-					},
-					ioiter.Readdir(dir),
-				),
-			),
-		)
-		_ = dir.Close()
+	if len(dirs) == 0 {
+		cfgDir, err := manager.DefaultConfigDir()
 		if err != nil {
-			panic(err)
+			return err
 		}
-		slices.SortFunc(
-			sets,
-			func(i, j namedCommandSet) int {
-				if c := cmp.Compare(i.Name, j.Name); c != 0 {
-					return c
-				}
-				switch {
-				case reflect.ValueOf(i.Set).IsZero():
-					// x > y
-					return +1
-				case reflect.ValueOf(j.Set).IsZero():
-					return -1
-				default:
-					return 0
-				}
-			},
-		)
-		// may contain both .json and directory
-		sets = slices.CompactFunc(sets, func(i, j namedCommandSet) bool { return i.Name == j.Name })
-		sets = topologicalSort(sets)
+		dirs = []string{cfgDir}
 	}
-
-	if *debug {
-		for _, s := range sets {
-			fmt.Printf("name = %s, after = %v\n", s.Name, s.Set.After)
-		}
-		return
+	if *prof == "" {
+		*prof = os.Getenv("PKGMGR_PROFILE")
 	}
+	// The last dir is the config dir, holding settings and state.
+	cfgDir, baseDirs := dirs[len(dirs)-1], dirs[:len(dirs)-1]
 
-	currentVersions := map[string]string{}
-	latestVersions := map[string]string{}
-
-	iter := func() iter.Seq[*commandExecutor] {
-		return func(yield func(*commandExecutor) bool) {
-			for _, set := range sets {
-				executor := newCommandExecutor(cfgDir, set, os.Stdin, os.Stdout, os.Stderr)
-				if !yield(executor) {
-					return
-				}
-			}
-		}
-	}
-
-	type targetedExecutor struct {
-		tgt      string
-		executor *commandExecutor
-	}
-	var updates []targetedExecutor
-	checkVersions := func() {
-		gr, gCtx := errgroup.WithContext(ctx)
-		gr.SetLimit(5)
-		var mu1, mu2 sync.Mutex
-		for executor := range iter() {
-			gr.Go(func() error {
-				out, err := executor.Exec(gCtx, commandVer, "", *v)
-				if err != nil || len(out) == 0 {
-					if err == nil {
-						err = fmt.Errorf("empty output")
-					}
-					err := fmt.Errorf("ver %q: %w", executor.commandSet.Name, err)
-					return err
-				}
-				mu1.Lock()
-				currentVersions[executor.commandSet.Name] = strings.TrimSpace(out)
-				mu1.Unlock()
-				return nil
-			})
-			gr.Go(func() error {
-				out, err := executor.Exec(gCtx, commandChecklatest, "", *v)
-				if err != nil || len(out) == 0 {
-					if err == nil {
-						err = fmt.Errorf("empty output")
-					}
-					err = fmt.Errorf("checklatest %q: %w", executor.commandSet.Name, err)
-					return err
-				}
-				mu2.Lock()
-				latestVersions[executor.commandSet.Name] = strings.TrimSpace(out)
-				mu2.Unlock()
-				return nil
-			})
-		}
-		err := gr.Wait()
+	var projectDir string
+	if !*noProject {
+		wd, err := os.Getwd()
 		if err != nil {
-			panic(err)
-		}
-
-		for executor := range iter() {
-			name := executor.commandSet.Name
-			tgt := cmp.Or(pinnedVersions[name], latestVersions[name])
-			fmt.Printf("%q: %s -> %s", name, currentVersions[name], tgt)
-			if pinnedVersions[name] != "" {
-				fmt.Printf("(pinned)")
-			}
-			if currentVersions[name] == tgt {
-				fmt.Printf(": no update\n")
-				continue
-			}
-			updates = append(updates, targetedExecutor{tgt: tgt, executor: executor})
-			fmt.Printf("\n")
-		}
-	}
-
-	switch command(cmd) {
-	case commandInstall:
-		for executor := range iter() {
-			fmt.Printf("installing %q...\n", executor.commandSet.Name)
-			out, err := executor.Exec(ctx, commandVer, "", false)
-			if err == nil && len(out) > 0 {
-				fmt.Printf("Skipping %q: seems already installed at version %s\n", executor.commandSet.Name, strings.TrimSpace(out))
-				continue
-			}
-
-			out, err = executor.Exec(ctx, commandChecklatest, "", false)
-			ver := strings.TrimSpace(out)
-			if err != nil {
-				ver = ""
-				fmt.Printf("fetching latest version failed with err %v\nNow trying with no version specified\n", err)
-			}
-
-			_, err = executor.Exec(ctx, commandInstall, cmp.Or(pinnedVersions[executor.commandSet.Name], ver), *v)
-			if err != nil {
-				err := fmt.Errorf("install %q: %w", executor.commandSet.Name, err)
-				if !*f {
-					panic(err)
-				}
-				fmt.Printf("warn: failed: %v\n", err)
-			} else {
-				fmt.Printf("installing %q done!\n", executor.commandSet.Name)
-			}
+			return err
 		}
-	case commandVer:
-		for executor := range iter() {
-			out, err := executor.Exec(ctx, commandVer, "", false)
-			if err != nil || len(out) == 0 {
-				if err == nil {
-					err = fmt.Errorf("empty output")
-				}
-				err := fmt.Errorf("ver %q: %w", executor.commandSet.Name, err)
-				if !*f {
-					panic(err)
-				}
-				fmt.Printf("warn: failed: %v\n", err)
-			}
-			currentVersions[executor.commandSet.Name] = strings.TrimSpace(out)
-		}
-		fmt.Printf("%s\n", must(json.MarshalIndent(currentVersions, "", "    ")))
-	case commandChecklatest:
-		checkVersions()
-	case commandUpdate:
-		checkVersions()
-		for _, t := range updates {
-			fmt.Printf("updating %q...\n", t.executor.commandSet.Name)
-			_, err := t.executor.Exec(ctx, commandUpdate, t.tgt, *v)
-			if err != nil {
-				panic(fmt.Errorf("updating %q: %w", t.executor.commandSet.Name, err))
-			}
-			fmt.Printf("updated %q!\n", t.executor.commandSet.Name)
-		}
-	}
-}
-
-func must[V any](v V, err error) V {
-	if err != nil {
-		panic(err)
-	}
-	return v
-}
-
-func topologicalSort(s []namedCommandSet) []namedCommandSet {
-	type node struct {
-		after []*node
-		val   namedCommandSet
-	}
-
-	nodes := make([]*node, len(s))
-	for i, e := range s {
-		nodes[i] = &node{val: e}
-	}
-	for i, n := range nodes {
-		for j, nn := range nodes {
-			if i == j {
-				continue
-			}
-			if slices.Contains(n.val.Set.After, nn.val.Name) {
-				n.after = append(n.after, nn)
-			}
-		}
-	}
-
-	sorted := make([]namedCommandSet, 0, len(s))
+		projectDir, err = manager.FindProjectDir(wd)
+		if err != nil {
+			return err
+		}
+		if abs, err := filepath.Abs(cfgDir); err == nil && abs == projectDir {
+			projectDir = ""
+		}
+	}
+
+	m := manager.New(cfgDir, manager.Options{
+		Verbose:        v >= 1,
+		VeryVerbose:    v >= 2,
+		Quiet:          *q,
+		Force:          *f,
+		Debug:          *debug,
+		Output:         *o,
+		DryRun:         *dry,
+		Tag:            *tag,
+		Profile:        *prof,
+		Parallel:       *parallel,
+		Timeout:        *timeout,
+		Retries:        *retries,
+		CacheTTL:       *cacheTTL,
+		Refresh:        *refresh,
+		AllowDowngrade: *allowDowngrade,
+		VerifyAfter:    *verifyAfter,
+		NoRollback:     *noRollback,
+		Sync:           *syncFirst,
+		Pin:            *pin,
+		Reinstall:      *reinstall,
+		Yes:            *yes,
+		NonInteractive: *nonInteractive,
+		Wait:           *wait,
+		LogLevel:       *logLevel,
+		LogFormat:      *logFormat,
+		LogToFile:      *logToFile,
+		Color:          *color,
+		BaseDirs:       baseDirs,
+		ProjectDir:     projectDir,
+		Flags:          flag.CommandLine,
+	})
 
-	visited := make(map[*node]bool, len(s))
-	var visit func(n *node, visited map[*node]bool)
-	visit = func(n *node, visited map[*node]bool) {
-		if visited[n] {
-			return
-		}
-		visited[n] = true
-		for _, nn := range n.after {
-			visit(nn, visited)
-		}
-		sorted = append(sorted, n.val)
-	}
-	for _, n := range nodes {
-		visit(n, visited)
+	if *n != "" {
+		return m.CreateCommandSetFromTemplate(*n, *tmpl)
 	}
-
-	return sorted
+	return m.Run(ctx, args)
 }
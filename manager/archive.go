@@ -0,0 +1,288 @@
+package manager
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// archiveFormats are in the order of the suffixes checked.
+var archiveFormats = []struct{ format, suffix string }{
+	{"tar.gz", ".tar.gz"},
+	{"tar.gz", ".tgz"},
+	{"tar.xz", ".tar.xz"},
+	{"tar.xz", ".txz"},
+	{"tar", ".tar"},
+	{"zip", ".zip"},
+}
+
+// archiveFormat returns the format of name by its suffix, or "raw" for a binary itself.
+func archiveFormat(name string) string {
+	for _, f := range archiveFormats {
+		if strings.HasSuffix(strings.ToLower(name), f.suffix) {
+			return f.format
+		}
+	}
+	return "raw"
+}
+
+type archiveEntry struct {
+	// Name is slash separated.
+	Name     string
+	Mode     fs.FileMode
+	Linkname string
+}
+
+// walkArchive calls fn for each file, dir and symlink of the archive in archive order. Other entry types are skipped.
+// tar.xz is decompressed by the xz command, since the standard library lacks xz.
+func walkArchive(format string, r io.ReaderAt, size int64, fn func(e archiveEntry, r io.Reader) error) error {
+	sr := io.NewSectionReader(r, 0, size)
+	switch format {
+	case "tar.gz":
+		gr, err := gzip.NewReader(sr)
+		if err != nil {
+			return err
+		}
+		return walkTar(gr, fn)
+	case "tar.xz":
+		cmd := exec.Command("xz", "--decompress", "--stdout")
+		cmd.Stdin = sr
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.StdoutPipe()
+		if err != nil {
+			return err
+		}
+		if err := cmd.Start(); err != nil {
+			return fmt.Errorf("tar.xz needs the xz command: %w", err)
+		}
+		err = walkTar(out, fn)
+		if err != nil {
+			// Let xz exit on the broken pipe rather than block writing.
+			_, _ = io.Copy(io.Discard, out)
+		}
+		if werr := cmd.Wait(); err == nil && werr != nil {
+			err = fmt.Errorf("xz: %w: %s", werr, bytes.TrimSpace(stderr.Bytes()))
+		}
+		return err
+	case "tar":
+		return walkTar(sr, fn)
+	case "zip":
+		zr, err := zip.NewReader(r, size)
+		if err != nil {
+			return err
+		}
+		for _, f := range zr.File {
+			e := archiveEntry{Name: f.Name, Mode: f.Mode()}
+			if e.Mode.Type() == fs.ModeSymlink {
+				// The target of a symlink is its content.
+				target, err := readZipFile(f)
+				if err != nil {
+					return err
+				}
+				e.Linkname = string(target)
+			}
+			if e.Mode.Type()&^(fs.ModeDir|fs.ModeSymlink) != 0 {
+				continue
+			}
+			var content io.Reader = strings.NewReader("")
+			var rc io.ReadCloser
+			if e.Mode.IsRegular() {
+				if rc, err = f.Open(); err != nil {
+					return err
+				}
+				content = rc
+			}
+			err := fn(e, content)
+			if rc != nil {
+				_ = rc.Close()
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown archive format %q", format)
+	}
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+func walkTar(r io.Reader, fn func(e archiveEntry, r io.Reader) error) error {
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		e := archiveEntry{Name: h.Name, Mode: fs.FileMode(h.Mode).Perm(), Linkname: h.Linkname}
+		switch h.Typeflag {
+		case tar.TypeReg:
+		case tar.TypeDir:
+			e.Mode |= fs.ModeDir
+		case tar.TypeSymlink:
+			e.Mode |= fs.ModeSymlink
+		default:
+			continue
+		}
+		if err := fn(e, tr); err != nil {
+			return err
+		}
+	}
+}
+
+func stripComponents(name string, n int) (string, bool) {
+	name = strings.Trim(path.Clean(strings.TrimPrefix(name, "./")), "/")
+	for range n {
+		_, after, found := strings.Cut(name, "/")
+		if !found {
+			return "", false
+		}
+		name = after
+	}
+	return name, name != "" && name != "."
+}
+
+// extractArchive extracts the archive into dir, keeping permission bits regardless of umask and rejecting entries
+// escaping dir. A raw archive is placed as dir/rawName. It returns paths created, dirs before their contents.
+func extractArchive(format string, r io.ReaderAt, size int64, dir string, strip int, rawName string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if format == "raw" {
+		dst := filepath.Join(dir, rawName)
+		if err := copyFileAtomic(dst, io.NewSectionReader(r, 0, size), 0o755); err != nil {
+			return nil, err
+		}
+		return []string{dst}, nil
+	}
+	var created []string
+	err := walkArchive(format, r, size, func(e archiveEntry, content io.Reader) error {
+		name, ok := stripComponents(e.Name, strip)
+		if !ok {
+			return nil
+		}
+		if !filepath.IsLocal(filepath.FromSlash(name)) {
+			return fmt.Errorf("%q escapes the destination", e.Name)
+		}
+		if err := checkNoSymlinkParent(dir, name); err != nil {
+			return fmt.Errorf("%q escapes the destination: %w", e.Name, err)
+		}
+		dst := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return err
+		}
+		switch {
+		case e.Mode.IsDir():
+			if err := os.MkdirAll(dst, e.Mode.Perm()|0o700); err != nil {
+				return err
+			}
+		case e.Mode.Type() == fs.ModeSymlink:
+			target := filepath.FromSlash(e.Linkname)
+			if filepath.IsAbs(target) || !filepath.IsLocal(filepath.Join(filepath.Dir(filepath.FromSlash(name)), target)) {
+				return fmt.Errorf("%q links outside the destination: %q", e.Name, e.Linkname)
+			}
+			if err := os.RemoveAll(dst); err != nil {
+				return err
+			}
+			if err := os.Symlink(target, dst); err != nil {
+				return err
+			}
+		default:
+			if err := copyFileAtomic(dst, content, e.Mode.Perm()); err != nil {
+				return err
+			}
+		}
+		created = append(created, dst)
+		return nil
+	})
+	return created, err
+}
+
+// checkNoSymlinkParent rejects a name under a symlink an earlier entry created, which might resolve outside dir.
+func checkNoSymlinkParent(dir, name string) error {
+	parent := dir
+	elems := strings.Split(name, "/")
+	for _, elem := range elems[:len(elems)-1] {
+		parent = filepath.Join(parent, elem)
+		info, err := os.Lstat(parent)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode().Type() == fs.ModeSymlink {
+			return fmt.Errorf("%s is a symlink", parent)
+		}
+	}
+	return nil
+}
+
+// extract extracts an archive the same on every OS, for install scripts.
+//
+//	extract [-C <dir>] [--strip-components <n>] [--format <tar.gz|tar.xz|tar|zip|raw>] <archive>
+func (m *Manager) extract(_ context.Context, args []string) error {
+	fset := flag.NewFlagSet("extract", flag.ContinueOnError)
+	dir := fset.String("C", ".", "dir to extract into")
+	strip := fset.Int("strip-components", 0, "leading path elements removed from names")
+	format := fset.String("format", "", "archive format: tar.gz, tar.xz, tar, zip or raw. defaults to the one of the archive name")
+	if err := fset.Parse(args); err != nil {
+		return configError(err)
+	}
+	if fset.NArg() != 1 {
+		return configError(fmt.Errorf("extract: wrong args length: want 1, got %d", fset.NArg()))
+	}
+	if *strip < 0 {
+		return configError(fmt.Errorf("extract: negative --strip-components: %d", *strip))
+	}
+	archive := fset.Arg(0)
+	if *format == "" {
+		*format = archiveFormat(archive)
+	} else if *format != "raw" && !slices.ContainsFunc(archiveFormats, func(f struct{ format, suffix string }) bool { return f.format == *format }) {
+		return configError(fmt.Errorf("extract: unknown --format %q", *format))
+	}
+	if m.opts.DryRun {
+		fmt.Printf("[dry-run] would extract %s (%s) into %s\n", archive, *format, *dir)
+		return nil
+	}
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	created, err := extractArchive(*format, f, info.Size(), *dir, *strip, filepath.Base(archive))
+	if err != nil {
+		return fmt.Errorf("extract: %s: %w", archive, err)
+	}
+	m.logger().Debug("extracted", "archive", archive, "dir", *dir, "entries", len(created))
+	return nil
+}
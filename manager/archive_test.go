@@ -0,0 +1,47 @@
+package manager
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestExtractArchiveSymlinkEscape(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges on windows")
+	}
+	var b bytes.Buffer
+	tw := tar.NewWriter(&b)
+	for _, h := range []*tar.Header{
+		{Name: "d/l", Typeflag: tar.TypeSymlink, Linkname: "..", Mode: 0o777},
+		{Name: "d/l/l2", Typeflag: tar.TypeSymlink, Linkname: "..", Mode: 0o777},
+		{Name: "d/l/l2/evil", Typeflag: tar.TypeReg, Mode: 0o644, Size: 4},
+	} {
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		if h.Typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte("evil")); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "out")
+	_, err := extractArchive("tar", bytes.NewReader(b.Bytes()), int64(b.Len()), dir, 0, "")
+	if err == nil {
+		t.Fatal("extractArchive succeeded, want an error")
+	}
+	for _, name := range []string{filepath.Join(parent, "evil"), filepath.Join(dir, "evil")} {
+		if _, err := os.Lstat(name); err == nil {
+			t.Errorf("%s is written", name)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package manager
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// writeFileAtomic writes through a synced temporary file renamed onto name, so readers never see a truncated one.
+func writeFileAtomic(name string, data []byte, perm os.FileMode) error {
+	return copyFileAtomic(name, bytes.NewReader(data), perm)
+}
+
+func copyFileAtomic(name string, r io.Reader, perm os.FileMode) error {
+	tmp, err := writeTemp(name, r, perm)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	syncDir(filepath.Dir(name))
+	return nil
+}
+
+// createFileAtomic hard links the temporary file, which, unlike renaming, fails with fs.ErrExist if name exists.
+func createFileAtomic(name string, data []byte, perm os.FileMode) error {
+	tmp, err := writeTemp(name, bytes.NewReader(data), perm)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	if err := os.Link(tmp, name); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(name))
+	return nil
+}
+
+func writeTemp(name string, r io.Reader, perm os.FileMode) (_ string, err error) {
+	f, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+			_ = os.Remove(f.Name())
+		}
+	}()
+
+	if _, err = io.Copy(f, r); err != nil {
+		return "", err
+	}
+	if err = f.Chmod(perm); err != nil {
+		return "", err
+	}
+	if err = f.Sync(); err != nil {
+		return "", err
+	}
+	if err = f.Close(); err != nil {
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// syncDir is best-effort: Windows, among others, can not sync directories.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
@@ -0,0 +1,327 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"golang.org/x/sync/errgroup"
+)
+
+var osvAPI = "https://api.osv.dev/v1"
+
+var osvPURLTypes = []string{"cargo", "composer", "gem", "golang", "hex", "maven", "npm", "nuget", "pub", "pypi"}
+
+// severities are lowest first.
+var severities = []string{"low", "medium", "high", "critical"}
+
+// severityRank ranks unknown severities highest, so that --fail-on does not let unrated vulnerabilities through.
+func severityRank(s string) int {
+	if i := slices.Index(severities, s); i >= 0 {
+		return i
+	}
+	return len(severities)
+}
+
+type auditVuln struct {
+	ID      string   `json:"id"`
+	Aliases []string `json:"aliases,omitzero"`
+	Summary string   `json:"summary,omitzero"`
+	// Severity is one of severities, or "unknown".
+	Severity string   `json:"severity"`
+	Fixed    []string `json:"fixed,omitzero"`
+}
+
+type auditEntry struct {
+	Name    string      `json:"name"`
+	Version string      `json:"version"`
+	PURL    string      `json:"purl"`
+	Vulns   []auditVuln `json:"vulns"`
+}
+
+// audit queries OSV.dev for vulnerabilities of the current versions of sets having package URLs.
+//
+//	audit [--json] [--fail-on <low|medium|high|critical|none>] [<tgt>]
+func (m *Manager) audit(ctx context.Context, args []string) error {
+	fset := flag.NewFlagSet("audit", flag.ContinueOnError)
+	asJSON := fset.Bool("json", false, "prints json instead of a table. Same as -o json")
+	failOn := fset.String("fail-on", "none", "exits with 6 if any vulnerability is at or above this severity: low, medium, high, critical or none")
+	if err := fset.Parse(args); err != nil {
+		return configError(err)
+	}
+	if fset.NArg() > 1 {
+		return configError(fmt.Errorf("audit: wrong args length: want 0 or 1, got %d", fset.NArg()))
+	}
+	if *failOn != "none" && !slices.Contains(severities, *failOn) {
+		return configError(fmt.Errorf("audit: unknown --fail-on %q: want one of %s or none", *failOn, strings.Join(severities, ", ")))
+	}
+	format := outputFormat(m.opts.Output)
+	if err := format.Validate(); err != nil {
+		return configError(err)
+	}
+	if *asJSON {
+		format = outputJSON
+	}
+
+	sets, err := m.resolveTargets(fset.Arg(0))
+	if err != nil {
+		return configError(err)
+	}
+	versions, err := m.installedVersions(ctx, sets)
+	if err != nil {
+		return err
+	}
+	var entries []auditEntry
+	for _, set := range sets {
+		ver, ok := versions[set.Name]
+		if !ok {
+			continue
+		}
+		purl := packageURL(set.Set)
+		typ, _, _ := strings.Cut(strings.TrimPrefix(purl, "pkg:"), "/")
+		if !slices.Contains(osvPURLTypes, typ) {
+			m.logger().Warn("audit: skipping: no package URL of an ecosystem OSV knows", "set", set.Name, "purl", purl)
+			continue
+		}
+		entries = append(entries, auditEntry{Name: set.Name, Version: ver, PURL: purl})
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(8)
+	for i := range entries {
+		g.Go(func() error {
+			vulns, err := queryOSV(gctx, entries[i].PURL, entries[i].Version)
+			if err != nil {
+				return fmt.Errorf("audit: %s: %w", entries[i].Name, err)
+			}
+			entries[i].Vulns = vulns
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	var total, failing int
+	for _, e := range entries {
+		total += len(e.Vulns)
+		for _, v := range e.Vulns {
+			if *failOn != "none" && severityRank(v.Severity) >= severityRank(*failOn) {
+				failing++
+			}
+		}
+	}
+	if format == outputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "    ")
+		if err := enc.Encode(entries); err != nil {
+			return err
+		}
+	} else if total > 0 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tVERSION\tID\tSEVERITY\tFIXED\tSUMMARY")
+		for _, e := range entries {
+			for _, v := range e.Vulns {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", e.Name, e.Version, v.ID, v.Severity, strings.Join(v.Fixed, ","), v.Summary)
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	m.logger().Info("audit: done", "sets", len(entries), "vulnerabilities", total)
+
+	if failing > 0 {
+		return &exitError{code: exitVulnerable, err: fmt.Errorf("%d vulnerabilities at or above %s", failing, *failOn)}
+	}
+	return nil
+}
+
+type osvVuln struct {
+	ID       string   `json:"id"`
+	Aliases  []string `json:"aliases"`
+	Summary  string   `json:"summary"`
+	Severity []struct {
+		Type  string `json:"type"`
+		Score string `json:"score"`
+	} `json:"severity"`
+	DatabaseSpecific struct {
+		Severity string `json:"severity"`
+	} `json:"database_specific"`
+	Affected []struct {
+		Ranges []struct {
+			Events []struct {
+				Fixed string `json:"fixed"`
+			} `json:"events"`
+		} `json:"ranges"`
+		EcosystemSpecific struct {
+			Severity string `json:"severity"`
+		} `json:"ecosystem_specific"`
+	} `json:"affected"`
+}
+
+func queryOSV(ctx context.Context, purl, ver string) ([]auditVuln, error) {
+	if strings.HasPrefix(purl, "pkg:golang/") {
+		// OSV records Go versions without "v".
+		ver = strings.TrimPrefix(ver, "v")
+	}
+	vulns := []auditVuln{}
+	var pageToken string
+	for {
+		body, err := json.Marshal(struct {
+			Package   map[string]string `json:"package"`
+			Version   string            `json:"version"`
+			PageToken string            `json:"page_token,omitzero"`
+		}{map[string]string{"purl": purl}, ver, pageToken})
+		if err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, osvAPI+"/query", bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "ngpkgmgr/"+version)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		var res struct {
+			Vulns         []osvVuln `json:"vulns"`
+			NextPageToken string    `json:"next_page_token"`
+		}
+		if resp.StatusCode != http.StatusOK {
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("POST %s: %s", req.URL, resp.Status)
+		}
+		err = json.NewDecoder(resp.Body).Decode(&res)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("POST %s: %w", req.URL, err)
+		}
+		for _, v := range res.Vulns {
+			vulns = append(vulns, v.audit())
+		}
+		if res.NextPageToken == "" {
+			return vulns, nil
+		}
+		pageToken = res.NextPageToken
+	}
+}
+
+func (v osvVuln) audit() auditVuln {
+	out := auditVuln{ID: v.ID, Aliases: v.Aliases, Summary: v.Summary, Severity: "unknown"}
+	rated := []string{v.DatabaseSpecific.Severity}
+	for _, a := range v.Affected {
+		rated = append(rated, a.EcosystemSpecific.Severity)
+		for _, r := range a.Ranges {
+			for _, e := range r.Events {
+				if e.Fixed != "" && !slices.Contains(out.Fixed, e.Fixed) {
+					out.Fixed = append(out.Fixed, e.Fixed)
+				}
+			}
+		}
+	}
+	for _, s := range rated {
+		s = strings.ToLower(s)
+		if s == "moderate" {
+			s = "medium"
+		}
+		if slices.Contains(severities, s) {
+			out.Severity = s
+			return out
+		}
+	}
+	for _, s := range v.Severity {
+		if strings.HasPrefix(s.Type, "CVSS_V3") {
+			if score, ok := cvss3Score(s.Score); ok {
+				out.Severity = cvssSeverity(score)
+				return out
+			}
+		}
+	}
+	return out
+}
+
+func cvss3Score(vector string) (float64, bool) {
+	metrics := map[string]string{}
+	for _, part := range strings.Split(vector, "/") {
+		k, v, ok := strings.Cut(part, ":")
+		if ok {
+			metrics[k] = v
+		}
+	}
+	weights := map[string]map[string]float64{
+		"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+		"AC": {"L": 0.77, "H": 0.44},
+		"UI": {"N": 0.85, "R": 0.62},
+		"C":  {"H": 0.56, "L": 0.22, "N": 0},
+		"I":  {"H": 0.56, "L": 0.22, "N": 0},
+		"A":  {"H": 0.56, "L": 0.22, "N": 0},
+	}
+	w := map[string]float64{}
+	for k, values := range weights {
+		v, ok := values[metrics[k]]
+		if !ok {
+			return 0, false
+		}
+		w[k] = v
+	}
+	changed := metrics["S"] == "C"
+	if !changed && metrics["S"] != "U" {
+		return 0, false
+	}
+	pr := map[string]float64{"N": 0.85, "L": 0.62, "H": 0.27}
+	if changed {
+		pr["L"], pr["H"] = 0.68, 0.5
+	}
+	prWeight, ok := pr[metrics["PR"]]
+	if !ok {
+		return 0, false
+	}
+
+	iss := 1 - (1-w["C"])*(1-w["I"])*(1-w["A"])
+	impact := 6.42 * iss
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	}
+	if impact <= 0 {
+		return 0, true
+	}
+	exploitability := 8.22 * w["AV"] * w["AC"] * prWeight * w["UI"]
+	score := impact + exploitability
+	if changed {
+		score *= 1.08
+	}
+	return cvssRoundUp(math.Min(score, 10)), true
+}
+
+// cvssRoundUp rounds x up to 1 decimal place as the CVSS v3.1 specification defines.
+func cvssRoundUp(x float64) float64 {
+	i := int64(math.Round(x * 100000))
+	if i%10000 == 0 {
+		return float64(i) / 100000
+	}
+	return float64(i/10000+1) / 10
+}
+
+func cvssSeverity(score float64) string {
+	switch {
+	case score >= 9:
+		return "critical"
+	case score >= 7:
+		return "high"
+	case score >= 4:
+		return "medium"
+	default:
+		return "low"
+	}
+}
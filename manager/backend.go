@@ -0,0 +1,92 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// backend implements commands declaratively. Args and scripts still take precedence for each command.
+type backend interface {
+	Commands() []command
+	// Validate has no side effect.
+	Validate() error
+	// Exec returns output as if it were stdout of a command.
+	Exec(ctx context.Context, e commandExecutor, kind command, ver string) (string, error)
+}
+
+func (c commandSet) backends() []backend {
+	var b []backend
+	if c.GitHub != nil {
+		b = append(b, c.GitHub)
+	}
+	if c.GoInstall != nil {
+		b = append(b, c.GoInstall)
+	}
+	if c.Cargo != nil {
+		b = append(b, c.Cargo)
+	}
+	if c.Npm != nil {
+		b = append(b, c.Npm)
+	}
+	if c.Pipx != nil {
+		b = append(b, c.Pipx)
+	}
+	if c.Brew != nil {
+		b = append(b, c.Brew)
+	}
+	if c.Apt != nil {
+		b = append(b, c.Apt)
+	}
+	if c.Scoop != nil {
+		b = append(b, c.Scoop)
+	}
+	if c.Winget != nil {
+		b = append(b, c.Winget)
+	}
+	return b
+}
+
+func backendName(b backend) string {
+	switch b.(type) {
+	case *githubBackend:
+		return "github"
+	case *goInstallBackend:
+		return "goinstall"
+	case *cargoBackend:
+		return "cargo"
+	case *npmBackend:
+		return "npm"
+	case *pipxBackend:
+		return "pipx"
+	case *brewBackend:
+		return "brew"
+	case *aptBackend:
+		return "apt"
+	case *scoopBackend:
+		return "scoop"
+	case *wingetBackend:
+		return "winget"
+	}
+	return ""
+}
+
+func (c commandSet) backend() backend {
+	if b := c.backends(); len(b) > 0 {
+		return b[0]
+	}
+	return nil
+}
+
+func expandHome(p string) (string, error) {
+	if p != "~" && !strings.HasPrefix(p, "~/") {
+		return p, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("expanding %q: %w", p, err)
+	}
+	return filepath.Join(home, p[1:]), nil
+}
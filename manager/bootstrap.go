@@ -0,0 +1,261 @@
+package manager
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"path"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// Bootstrap formats of "export --format". Sets a format can not express are written as comments,
+// so that nothing is dropped silently.
+
+// bootstrapSet has the version to install, "" for the latest.
+type bootstrapSet struct {
+	set  namedCommandSet
+	ver  string
+	dict dictReplacer
+}
+
+func newBootstrapSet(set namedCommandSet, ver string) bootstrapSet {
+	e := newCommandExecutor(set, executorDefaults{}, nil, nil, nil)
+	return bootstrapSet{set: set, ver: ver, dict: e.dict(ver)}
+}
+
+// installArgs returns args installing s, or why a plain command can not. The github backend is handled by githubInstallScript.
+func (s bootstrapSet) installArgs() ([]string, string) {
+	ver := s.ver
+	switch b := s.set.Set.backend().(type) {
+	case nil:
+		args := s.set.Set.Select(commandInstall)
+		if len(args) == 0 {
+			return nil, "install is a script, not args"
+		}
+		if ver == "" && slices.ContainsFunc(args, func(a string) bool { return strings.Contains(a, "${VER") }) {
+			return nil, "install needs a version but none is pinned or installed"
+		}
+		return slices.Collect(s.dict.Map(slices.Values(args))), ""
+	case *goInstallBackend:
+		dict := maps.Clone(s.dict)
+		if ver == "" {
+			dict["${VER}"] = "latest"
+		}
+		return []string{"go", "install", dict.Expand(string(*b))}, ""
+	case *cargoBackend:
+		args := []string{"cargo", "install", "--locked", string(*b)}
+		if ver != "" {
+			args = append(args, "--version", ver)
+		}
+		return args, ""
+	case *npmBackend:
+		return []string{"npm", "install", "--global", string(*b) + "@" + cmp.Or(ver, "latest")}, ""
+	case *pipxBackend:
+		if ver != "" {
+			return []string{"pipx", "install", string(*b) + "==" + ver}, ""
+		}
+		return []string{"pipx", "install", string(*b)}, ""
+	case *brewBackend:
+		return []string{"brew", "install", string(*b)}, ""
+	case *aptBackend:
+		pkg := string(*b)
+		if ver != "" {
+			pkg += "=" + ver
+		}
+		return []string{"sudo", "apt-get", "--yes", "install", pkg}, ""
+	default:
+		return nil, "the " + backendName(b) + " backend is not supported by this format"
+	}
+}
+
+func (s bootstrapSet) githubInstallScript(g *githubBackend) (string, string) {
+	if s.ver == "" {
+		return "", "the github backend needs a version but none is pinned or installed"
+	}
+	asset := s.dict.Expand(g.Asset)
+	url := fmt.Sprintf("https://github.com/%s/releases/download/%s%s/%s", g.Repo, g.tagPrefix(), s.ver, asset)
+	var b strings.Builder
+	fmt.Fprintf(&b, "tmp=$(mktemp -d)\n")
+	fmt.Fprintf(&b, "curl -fsSL -o \"$tmp\"/%s %s\n", shellQuote(path.Base(asset)), shellQuote(url))
+	switch archiveFormat(asset) {
+	case "raw":
+		fmt.Fprintf(&b, "install -m 755 \"$tmp\"/%s \"$BIN_DIR\"/%s\n", shellQuote(path.Base(asset)), shellQuote(g.binaries()[0]))
+	default:
+		if archiveFormat(asset) == "zip" {
+			fmt.Fprintf(&b, "unzip -q \"$tmp\"/%s -d \"$tmp\"/x\n", shellQuote(path.Base(asset)))
+		} else {
+			fmt.Fprintf(&b, "mkdir \"$tmp\"/x && tar -xf \"$tmp\"/%s -C \"$tmp\"/x\n", shellQuote(path.Base(asset)))
+		}
+		for _, bin := range g.binaries() {
+			fmt.Fprintf(&b, "find \"$tmp\"/x -type f -name %s -exec install -m 755 {} \"$BIN_DIR\"/ \\;\n", shellQuote(bin))
+		}
+	}
+	fmt.Fprintf(&b, "rm -rf \"$tmp\"\n")
+	return b.String(), ""
+}
+
+func (s bootstrapSet) env() []string {
+	if s.set.Set.backend() != nil {
+		return nil
+	}
+	env := []string{"OS=" + runtime.GOOS, "ARCH=" + runtime.GOARCH}
+	if s.ver != "" {
+		env = append(env, "VER="+s.ver)
+	}
+	for _, k := range slices.Sorted(maps.Keys(s.set.Set.Env)) {
+		env = append(env, k+"="+s.dict["${"+k+"}"])
+	}
+	return env
+}
+
+func (s bootstrapSet) comment() string {
+	if s.ver == "" {
+		return s.set.Name + " (latest)"
+	}
+	return s.set.Name + " " + s.ver
+}
+
+var shellSafeRe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+func shellQuote(s string) string {
+	if shellSafeRe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = shellQuote(a)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// writeBrewfile writes versions as comments, since Brewfiles can not pin them.
+func writeBrewfile(w io.Writer, sets []bootstrapSet) error {
+	fmt.Fprintln(w, "# Generated by ngpkgmgr export --format brewfile.")
+	for _, s := range sets {
+		b, ok := s.set.Set.backend().(*brewBackend)
+		if !ok {
+			fmt.Fprintf(w, "# %s: not a brew formula\n", s.set.Name)
+			continue
+		}
+		line := fmt.Sprintf("brew %q", string(*b))
+		if s.ver != "" {
+			line += " # " + s.ver
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeShell(w io.Writer, sets []bootstrapSet) error {
+	fmt.Fprintf(w, "#!/bin/sh\n# Generated by ngpkgmgr export --format shell on %s/%s.\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Fprint(w, "set -eu\nBIN_DIR=${BIN_DIR:-$HOME/.local/bin}\nmkdir -p \"$BIN_DIR\"\n")
+	for _, s := range sets {
+		fmt.Fprintf(w, "\n# %s\n", s.comment())
+		if g, ok := s.set.Set.backend().(*githubBackend); ok {
+			script, reason := s.githubInstallScript(g)
+			if reason != "" {
+				fmt.Fprintf(w, "# skipped: %s\n", reason)
+				continue
+			}
+			fmt.Fprint(w, script)
+			continue
+		}
+		args, reason := s.installArgs()
+		if reason != "" {
+			fmt.Fprintf(w, "# skipped: %s\n", reason)
+			continue
+		}
+		if env := s.env(); len(env) > 0 {
+			fmt.Fprintf(w, "(export %s; %s)\n", shellJoin(env), shellJoin(args))
+		} else {
+			fmt.Fprintln(w, shellJoin(args))
+		}
+	}
+	return nil
+}
+
+func writeAnsible(w io.Writer, sets []bootstrapSet) error {
+	obj := func(kv ...any) *orderedObject {
+		o := &orderedObject{}
+		for i := 0; i < len(kv); i += 2 {
+			o.keys = append(o.keys, kv[i].(string))
+			o.values = append(o.values, kv[i+1])
+		}
+		return o
+	}
+	fmt.Fprintln(w, "# Generated by ngpkgmgr export --format ansible.")
+	fmt.Fprintln(w, "---")
+	var tasks int
+	for _, s := range sets {
+		name := "Install " + s.comment()
+		var task *orderedObject
+		switch b := s.set.Set.backend().(type) {
+		case *brewBackend:
+			task = obj("name", name, "community.general.homebrew", obj("name", string(*b), "state", "present"))
+		case *aptBackend:
+			pkg := string(*b)
+			if s.ver != "" {
+				pkg += "=" + s.ver
+			}
+			task = obj("name", name, "ansible.builtin.apt", obj("name", pkg, "state", "present"), "become", true)
+		case *npmBackend:
+			task = obj("name", name, "community.general.npm", obj("name", string(*b), "version", cmp.Or(s.ver, "latest"), "global", true))
+		case *cargoBackend:
+			mod := obj("name", string(*b), "locked", true)
+			if s.ver != "" {
+				mod.keys, mod.values = append(mod.keys, "version"), append(mod.values, s.ver)
+			}
+			task = obj("name", name, "community.general.cargo", mod)
+		case *githubBackend:
+			script, reason := s.githubInstallScript(b)
+			if reason != "" {
+				fmt.Fprintf(w, "# %s: skipped: %s\n", s.set.Name, reason)
+				continue
+			}
+			script = "set -eu\nBIN_DIR=${BIN_DIR:-$HOME/.local/bin}\nmkdir -p \"$BIN_DIR\"\n" + script
+			task = obj("name", name, "ansible.builtin.shell", script)
+		default:
+			args, reason := s.installArgs()
+			if reason != "" {
+				fmt.Fprintf(w, "# %s: skipped: %s\n", s.set.Name, reason)
+				continue
+			}
+			argv := make([]any, len(args))
+			for i, a := range args {
+				argv[i] = a
+			}
+			task = obj("name", name, "ansible.builtin.command", obj("argv", argv))
+			if env := s.env(); len(env) > 0 {
+				e := &orderedObject{}
+				for _, kv := range env {
+					k, v, _ := strings.Cut(kv, "=")
+					e.keys, e.values = append(e.keys, k), append(e.values, v)
+				}
+				task.keys, task.values = append(task.keys, "environment"), append(task.values, e)
+			}
+		}
+		var b strings.Builder
+		if err := writeYAML(&b, task, 2); err != nil {
+			return err
+		}
+		// "- " replaces the indentation of the first key.
+		if _, err := fmt.Fprintf(w, "- %s", b.String()[2:]); err != nil {
+			return err
+		}
+		tasks++
+	}
+	if tasks == 0 {
+		fmt.Fprintln(w, "[]")
+	}
+	return nil
+}
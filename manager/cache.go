@@ -0,0 +1,69 @@
+package manager
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type latestCacheEntry struct {
+	Version   string    `json:"version"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+func latestCacheDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "ngpkgmgr", "checklatest"), nil
+}
+
+func latestCachePath(name string) (string, error) {
+	dir, err := latestCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name+".json"), nil
+}
+
+func loadLatestCache(name string, ttl time.Duration) (string, bool) {
+	p, err := latestCachePath(name)
+	if err != nil {
+		return "", false
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return "", false
+	}
+	var entry latestCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Version == "" {
+		return "", false
+	}
+	if time.Since(entry.CheckedAt) > ttl {
+		return "", false
+	}
+	return entry.Version, true
+}
+
+func storeLatestCache(name, ver string) error {
+	p, err := latestCachePath(name)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(latestCacheEntry{Version: ver, CheckedAt: time.Now()}, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	return writeFileAtomic(p, append(data, '\n'), 0o644)
+}
+
+func removeLatestCache(name string) {
+	if p, err := latestCachePath(name); err == nil {
+		_ = os.Remove(p)
+	}
+}
@@ -0,0 +1,146 @@
+package manager
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+)
+
+type checkEntry struct {
+	Name    string `json:"name"`
+	Current string `json:"current,omitzero"`
+	Target  string `json:"target,omitzero"`
+	Pinned  bool   `json:"pinned"`
+	// Problem is "outdated", "newer than pin", "not installed" or "checklatest failed".
+	Problem string `json:"problem"`
+	Error   string `json:"error,omitzero"`
+}
+
+// check prints only sets not at their target versions, for CI and shell prompts.
+//
+//	check [<tgt>]
+func (m *Manager) check(ctx context.Context, args []string) error {
+	if len(args) > 1 {
+		return configError(fmt.Errorf("check: wrong args length: want 0 or 1, got %d", len(args)))
+	}
+	var tgt string
+	if len(args) == 1 {
+		tgt = args[0]
+	}
+	format := outputFormat(m.opts.Output)
+	if err := format.Validate(); err != nil {
+		return configError(err)
+	}
+
+	pinnedVersions, err := loadPinnedVersions(m.cfgDir)
+	if err != nil {
+		return configError(err)
+	}
+	sets, err := m.resolveTargets(tgt)
+	if err != nil {
+		return configError(err)
+	}
+	r, err := m.newRunner(commandChecklatest, sets, pinnedVersions, format)
+	if err != nil {
+		return err
+	}
+	entries, err := r.checkAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	diffs := []*checkEntry{}
+	var numFailed int
+	for _, e := range entries {
+		if e.Problem == "" {
+			continue
+		}
+		diffs = append(diffs, e)
+		if e.Error != "" {
+			numFailed++
+		}
+	}
+
+	if format == outputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "    ")
+		if err := enc.Encode(diffs); err != nil {
+			return err
+		}
+	} else {
+		for _, e := range diffs {
+			var pinned string
+			if e.Pinned {
+				pinned = " (pinned)"
+			}
+			switch e.Problem {
+			case "checklatest failed":
+				fmt.Printf("%s: checklatest failed: %s\n", e.Name, e.Error)
+			case "not installed":
+				fmt.Printf("%s: not installed -> %s%s\n", e.Name, e.Target, pinned)
+			default:
+				fmt.Printf("%s: %s -> %s%s\n", e.Name, e.Current, e.Target, pinned)
+			}
+		}
+	}
+
+	switch {
+	case len(diffs) > numFailed:
+		return &exitError{code: exitOutdated, err: fmt.Errorf("%d of %d set(s) not at their target versions", len(diffs)-numFailed, len(sets))}
+	case numFailed > 0:
+		return fmt.Errorf("checklatest failed for %d set(s)", numFailed)
+	}
+	return nil
+}
+
+// checkAll records failures of a set in its entry instead of aborting, unlike resolveVersions.
+func (r *runner) checkAll(ctx context.Context) ([]*checkEntry, error) {
+	entries := make([]*checkEntry, len(r.sets))
+	gr, gCtx := errgroup.WithContext(ctx)
+	gr.SetLimit(cmp.Or(r.opts.Parallel, 5))
+	for i, set := range r.sets {
+		executor := r.executor(set)
+		pinned := r.pinnedVersions[set.Name]
+		gr.Go(func() error {
+			e := &checkEntry{Name: set.Name, Target: pinned, Pinned: pinned != ""}
+			entries[i] = e
+			out, err := executor.Exec(gCtx, commandVer, "", false)
+			e.Current = strings.TrimSpace(out)
+			if err != nil || e.Current == "" {
+				e.Current, e.Problem = "", "not installed"
+			}
+			if !e.Pinned || isConstraint(pinned) {
+				out, err := executor.Exec(gCtx, commandChecklatest, "", false)
+				e.Target = strings.TrimSpace(out)
+				if err != nil || e.Target == "" {
+					e.Problem = "checklatest failed"
+					e.Error = cmp.Or(err, errors.New("empty output")).Error()
+					return nil
+				}
+			}
+			if e.Pinned {
+				target, err := executor.resolvePin(gCtx, pinned, e.Current, e.Target)
+				if err != nil {
+					e.Problem, e.Error = "checklatest failed", err.Error()
+					return nil
+				}
+				e.Target = target
+			}
+			switch {
+			case e.Problem != "":
+			case needsUpdate(set.Set.Versioning, e.Current, e.Target, r.opts.AllowDowngrade):
+				e.Problem = "outdated"
+			case e.Pinned && isDowngrade(set.Set.Versioning, e.Current, e.Target):
+				e.Problem = "newer than pin"
+			}
+			return nil
+		})
+	}
+	return entries, gr.Wait()
+}
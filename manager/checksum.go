@@ -0,0 +1,152 @@
+package manager
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// checksumConfig verifies downloaded artifacts before install and update.
+//
+//	{"checksum": {"artifact": "https://example.com/tool_${VER}.tar.gz", "sums": "SHA256SUMS"}}
+type checksumConfig struct {
+	// Artifact is downloaded before install and update of sets defined by args or scripts, and passed to them
+	// as ${ARTIFACT}. Backends verify their own downloads.
+	Artifact string `json:"artifact,omitzero"`
+	SHA256   string `json:"sha256,omitzero"`
+	// Sums is a SHA256SUMS style file, relative to the artifact URL.
+	Sums string `json:"sums,omitzero"`
+	// Name defaults to the base name of the artifact URL.
+	Name string `json:"name,omitzero"`
+}
+
+func (c checksumConfig) Validate(hasBackend bool) error {
+	if (c.SHA256 == "") == (c.Sums == "") {
+		return fmt.Errorf("checksum: exactly one of sha256 or sums must be specified")
+	}
+	if c.Artifact == "" && !hasBackend {
+		return fmt.Errorf("checksum: artifact must be specified unless a backend is set")
+	}
+	return nil
+}
+
+func (c checksumConfig) Verify(ctx context.Context, dict dictReplacer, artifactURL string, data []byte) error {
+	want := dict.Expand(c.SHA256)
+	if c.Sums != "" {
+		name := dict.Expand(c.Name)
+		if name == "" {
+			u, err := url.Parse(artifactURL)
+			if err != nil {
+				return fmt.Errorf("checksum: %w", err)
+			}
+			name = path.Base(u.Path)
+		}
+		var err error
+		want, err = c.lookup(ctx, dict, artifactURL, name)
+		if err != nil {
+			return err
+		}
+	}
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, want) {
+		return fmt.Errorf("checksum: mismatch for %s: want %s, got %s", artifactURL, want, got)
+	}
+	return nil
+}
+
+func (c checksumConfig) lookup(ctx context.Context, dict dictReplacer, artifactURL, name string) (string, error) {
+	base, err := url.Parse(artifactURL)
+	if err != nil {
+		return "", fmt.Errorf("checksum: %w", err)
+	}
+	ref, err := url.Parse(dict.Expand(c.Sums))
+	if err != nil {
+		return "", fmt.Errorf("checksum: %w", err)
+	}
+	sumsURL := base.ResolveReference(ref).String()
+	sums, err := fetch(ctx, sumsURL)
+	if err != nil {
+		return "", fmt.Errorf("checksum: %w", err)
+	}
+	sc := bufio.NewScanner(bytes.NewReader(sums))
+	for sc.Scan() {
+		// "<hex>  <name>", or "<hex> *<name>" for binary mode.
+		digest, file, ok := strings.Cut(strings.TrimSpace(sc.Text()), " ")
+		if !ok {
+			continue
+		}
+		file = strings.TrimPrefix(strings.TrimSpace(file), "*")
+		if file == name || path.Base(file) == name {
+			return digest, nil
+		}
+	}
+	return "", fmt.Errorf("checksum: %q not listed in %s", name, sumsURL)
+}
+
+func fetch(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Some registries, e.g. crates.io, reject requests without a User-Agent.
+	req.Header.Set("User-Agent", "ngpkgmgr/"+version)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("GET %s: %w", url, err)
+	}
+	return data, nil
+}
+
+func (e commandExecutor) fetchArtifact(ctx context.Context, dict dictReplacer) (string, func(), error) {
+	c := e.commandSet.Set.Checksum
+	artifactURL := dict.Expand(c.Artifact)
+	u, err := url.Parse(artifactURL)
+	if err != nil {
+		return "", nil, err
+	}
+	base := path.Base(u.Path)
+	if base == "." || base == "/" {
+		base = "artifact"
+	}
+	dir, err := os.MkdirTemp("", tempPrefix(e.cfgDir)+e.commandSet.Name+"-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { _ = os.RemoveAll(dir) }
+	name := filepath.Join(dir, base)
+	fmt.Fprintf(e.stderr, "downloading %s\n", artifactURL)
+	if err := newDownloader(e.stderr).Download(ctx, artifactURL, name); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	data, err := os.ReadFile(name)
+	if err == nil {
+		err = c.Verify(ctx, dict, artifactURL, data)
+	}
+	if sig := e.commandSet.Set.Signature; err == nil && sig != nil {
+		err = sig.Verify(ctx, dict, artifactURL, name)
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return name, cleanup, nil
+}
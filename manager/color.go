@@ -0,0 +1,90 @@
+package manager
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Colors are of the same length so that tabwriter aligns colored cells.
+const (
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorRed    = "\x1b[31m"
+	// colorNone is written where a colored column has an uncolored cell.
+	colorNone  = "\x1b[39m"
+	colorReset = "\x1b[0m"
+)
+
+func validColor(s string) bool {
+	switch s {
+	case "", "auto", "always", "never":
+		return true
+	}
+	return false
+}
+
+// useColor colors terminals under auto unless NO_COLOR is set to non-empty or TERM is dumb.
+func useColor(mode string, w io.Writer) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	return ok && isTerminal(f)
+}
+
+func paint(color, s string) string {
+	if color == "" {
+		color = colorNone
+	}
+	return color + s + colorReset
+}
+
+// messageColors are keyed by messages with any "[dry-run] " prefix removed.
+var messageColors = map[string]string{
+	"no update":                               colorGreen,
+	"skipping: seems already installed":       colorGreen,
+	"skipping: already at the locked version": colorGreen,
+	"installed":                               colorGreen,
+	"updated":                                 colorGreen,
+	"uninstalled":                             colorGreen,
+	"verified":                                colorGreen,
+	"update available":                        colorYellow,
+	"failed":                                  colorRed,
+	"update failed, rolling back":             colorRed,
+	"verification failed":                     colorRed,
+}
+
+func recordColor(r slog.Record) string {
+	if c, ok := messageColors[strings.TrimPrefix(r.Message, "[dry-run] ")]; ok {
+		return c
+	}
+	switch {
+	case r.Level >= slog.LevelError:
+		return colorRed
+	case r.Level >= slog.LevelWarn:
+		return colorYellow
+	}
+	return ""
+}
+
+func actionColor(res *packageResult) string {
+	switch res.Action {
+	case actionInstalled, actionUpdated, actionUninstalled, actionRan:
+		return colorGreen
+	case actionFailed:
+		return colorRed
+	case actionSkipped:
+		if res.Current != "" && res.Current == res.Target {
+			return colorGreen
+		}
+	}
+	return ""
+}
@@ -0,0 +1,393 @@
+package manager
+
+import (
+	"fmt"
+	"maps"
+	"runtime"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+type namedCommandSet struct {
+	Name string
+	Set  commandSet
+	// Dir is the config dir the set was read from, where its scripts are.
+	Dir string
+}
+
+type commandSet struct {
+	Ver         []string `json:"ver,omitzero"`
+	CheckLatest []string `json:"checklatest,omitzero"`
+	Install     []string `json:"install,omitzero"`
+	Update      []string `json:"update,omitzero"`
+	Uninstall   []string `json:"uninstall,omitzero"`
+	// Versions lists available versions for constraint pins the latest version does not satisfy.
+	Versions  []string                    `json:"versions,omitzero"`
+	Commands  map[string][]string         `json:"commands,omitzero"`
+	Platforms map[string]platformCommands `json:"platforms,omitzero"`
+	After     []string                    `json:"after,omitzero"`
+	Deps      []string                    `json:"deps,omitzero"`
+	// Requires is an alias of Deps. Both are merged.
+	Requires []string `json:"requires,omitzero"`
+	Tags     []string `json:"tags,omitzero"`
+	Disabled bool     `json:"disabled,omitzero"`
+	// OS and Arch limit the set to these GOOS and GOARCH.
+	OS     []string      `json:"os,omitzero"`
+	Arch   []string      `json:"arch,omitzero"`
+	Source *sourceConfig `json:"source,omitzero"`
+	// PURL defaults to one derived from the backend. See packageURL.
+	PURL string `json:"purl,omitzero"`
+	// "0" or "" disables timeout while absent falls back to -timeout.
+	Timeout  *duration            `json:"timeout,omitzero"`
+	Timeouts map[command]duration `json:"timeouts,omitzero"`
+	// Env values are also exported as environment variables.
+	Env map[string]string `json:"env,omitzero"`
+	// Vars, unlike Env, are not exported. Env values may refer to them.
+	Vars map[string]string `json:"vars,omitzero"`
+	// Environment variables OS and ARCH stay GOOS and GOARCH regardless of OSMap and ArchMap.
+	OSMap   map[string]string `json:"os_map,omitzero"`
+	ArchMap map[string]string `json:"arch_map,omitzero"`
+	Retries *int              `json:"retries,omitzero"`
+	// Retry's count takes precedence over Retries.
+	Retry      *retryPolicy          `json:"retry,omitzero"`
+	Extract    map[command]extractor `json:"extract,omitzero"`
+	Versioning versioning            `json:"versioning,omitzero"`
+	// Hooks run inside global hooks.
+	Hooks     *hooksConfig     `json:"hooks,omitzero"`
+	Checksum  *checksumConfig  `json:"checksum,omitzero"`
+	Signature *signatureConfig `json:"signature,omitzero"`
+	// Workdir absent means pkgmgr's working directory.
+	Workdir string `json:"workdir,omitzero"`
+	// Pty merges stdout and stderr. Supported on Linux and macOS.
+	Pty          bool                `json:"pty,omitzero"`
+	Interpreters map[string][]string `json:"interpreters,omitzero"`
+
+	// Backends. At most one can be set.
+
+	GitHub    *githubBackend    `json:"github,omitzero"`
+	GoInstall *goInstallBackend `json:"goinstall,omitzero"`
+	Cargo     *cargoBackend     `json:"cargo,omitzero"`
+	Npm       *npmBackend       `json:"npm,omitzero"`
+	Pipx      *pipxBackend      `json:"pipx,omitzero"`
+	Brew      *brewBackend      `json:"brew,omitzero"`
+	Apt       *aptBackend       `json:"apt,omitzero"`
+	Scoop     *scoopBackend     `json:"scoop,omitzero"`
+	Winget    *wingetBackend    `json:"winget,omitzero"`
+}
+
+// reservedEnvKeys are keys of built-in placeholders.
+var reservedEnvKeys = []string{
+	"VER", "VER_NO_V", "VER_MAJOR", "VER_MINOR", "VER_PATCH",
+	"OS", "ARCH", "ARTIFACT", "HOME", "CONFIG_DIR", "NAME",
+}
+
+func (c commandSet) placeholders() []string {
+	p := make([]string, 0, len(reservedEnvKeys)+len(c.Vars)+len(c.Env))
+	for _, k := range reservedEnvKeys {
+		p = append(p, "${"+k+"}")
+	}
+	for k := range c.Vars {
+		p = append(p, "${"+k+"}")
+	}
+	for k := range c.Env {
+		p = append(p, "${"+k+"}")
+	}
+	return p
+}
+
+func (c commandSet) Validate() error {
+	switch b := c.backends(); len(b) {
+	case 0:
+	case 1:
+		if err := b[0].Validate(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("at most one backend can be set")
+	}
+	if err := c.Source.Validate(); err != nil {
+		return err
+	}
+	if c.PURL != "" && (!strings.HasPrefix(c.PURL, "pkg:") || strings.ContainsAny(c.PURL, "@?#")) {
+		return fmt.Errorf("purl: want pkg:<type>/<name> without version, qualifiers or subpath, got %q", c.PURL)
+	}
+	for _, t := range c.Tags {
+		if t == "" || strings.ContainsFunc(t, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+			return fmt.Errorf("tags: invalid tag %q", t)
+		}
+	}
+	for _, f := range []struct {
+		key    string
+		values []string
+	}{{"os", c.OS}, {"arch", c.Arch}} {
+		for _, v := range f.values {
+			if v == "" || strings.ContainsFunc(v, func(r rune) bool { return r == '/' || unicode.IsSpace(r) }) {
+				return fmt.Errorf("%s: invalid value %q", f.key, v)
+			}
+		}
+	}
+	for p := range c.Platforms {
+		goos, goarch, _ := strings.Cut(p, "/")
+		if goos == "" || strings.Contains(goarch, "/") || strings.ContainsFunc(p, unicode.IsSpace) {
+			return fmt.Errorf("platforms: invalid platform %q: must be \"<os>\" or \"<os>/<arch>\"", p)
+		}
+	}
+	for k, x := range c.Extract {
+		if k != commandVer && k != commandChecklatest {
+			return fmt.Errorf("extract: only ver and checklatest can be extracted, got %q", k)
+		}
+		if err := x.Validate(); err != nil {
+			return fmt.Errorf("extract.%s: %w", k, err)
+		}
+	}
+	for k := range c.Commands {
+		if err := validateUserCommand(k); err != nil {
+			return err
+		}
+	}
+	for p, v := range c.Platforms {
+		for k := range v.Commands {
+			if err := validateUserCommand(k); err != nil {
+				return fmt.Errorf("platforms.%s: %w", p, err)
+			}
+		}
+	}
+	for k := range c.Timeouts {
+		if !slices.Contains(cmds, k) {
+			return fmt.Errorf("timeouts: unknown command %q", k)
+		}
+	}
+	if err := c.Versioning.Validate(); err != nil {
+		return err
+	}
+	if c.Checksum != nil {
+		if err := c.Checksum.Validate(c.backend() != nil); err != nil {
+			return err
+		}
+	}
+	if c.Signature != nil {
+		if err := c.Signature.Validate(); err != nil {
+			return err
+		}
+		if c.GitHub == nil && (c.Checksum == nil || c.Checksum.Artifact == "") {
+			return fmt.Errorf("signature: needs the github backend or artifact of checksum to verify")
+		}
+	}
+	if c.Retries != nil && *c.Retries < 0 {
+		return fmt.Errorf("retries: must not be negative")
+	}
+	if c.Retry != nil {
+		if err := c.Retry.Validate(); err != nil {
+			return err
+		}
+	}
+	for k := range c.Env {
+		if k == "" || strings.ContainsAny(k, "=${}") {
+			return fmt.Errorf("env: invalid key %q", k)
+		}
+		if slices.Contains(reservedEnvKeys, k) {
+			return fmt.Errorf("env: key %q is reserved", k)
+		}
+	}
+	for k := range c.Vars {
+		if k == "" || strings.ContainsAny(k, "=${}") {
+			return fmt.Errorf("vars: invalid key %q", k)
+		}
+		if slices.Contains(reservedEnvKeys, k) {
+			return fmt.Errorf("vars: key %q is reserved", k)
+		}
+		if _, ok := c.Env[k]; ok {
+			return fmt.Errorf("vars: key %q is also defined in env", k)
+		}
+	}
+	return nil
+}
+
+func validateUserCommand(name string) error {
+	if slices.Contains(cmds, command(name)) {
+		return fmt.Errorf("commands: %q is a built-in command", name)
+	}
+	if name == "" || strings.ContainsFunc(name, func(r rune) bool { return r == ',' || r == '/' || unicode.IsSpace(r) }) {
+		return fmt.Errorf("commands: invalid name %q", name)
+	}
+	return nil
+}
+
+// userCommands returns names of user-defined commands of c, including those only defined for some platform.
+func (c commandSet) userCommands() []string {
+	names := slices.Collect(maps.Keys(c.Commands))
+	for _, v := range c.Platforms {
+		names = slices.AppendSeq(names, maps.Keys(v.Commands))
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+func (c commandSet) dependencies() []string {
+	if len(c.Requires) == 0 {
+		return c.Deps
+	}
+	deps := slices.Concat(c.Deps, c.Requires)
+	slices.Sort(deps)
+	return slices.Compact(deps)
+}
+
+type retryPolicy struct {
+	// Count defaults to retries of the set or -retries.
+	Count *int `json:"count,omitzero"`
+	// Backoff doubles on each retry.
+	Backoff  *duration `json:"backoff,omitzero"`
+	Commands []command `json:"commands,omitzero"`
+}
+
+var defaultRetryableCmds = []command{commandChecklatest, commandInstall, commandUpdate}
+
+func (p retryPolicy) Validate() error {
+	if p.Count != nil && *p.Count < 0 {
+		return fmt.Errorf("retry: count must not be negative")
+	}
+	if p.Backoff != nil && *p.Backoff < 0 {
+		return fmt.Errorf("retry: backoff must not be negative")
+	}
+	for _, c := range p.Commands {
+		switch {
+		case c == commandVer:
+			return fmt.Errorf("retry: ver can not be retried since its failure means the set is not installed")
+		case !slices.Contains(cmds, c):
+			return fmt.Errorf("retry: unknown command %q", c)
+		}
+	}
+	return nil
+}
+
+type command string
+
+const (
+	commandVer         command = "ver"
+	commandChecklatest command = "checklatest"
+	commandInstall     command = "install"
+	commandUpdate      command = "update"
+	commandUninstall   command = "uninstall"
+)
+
+var cmds = []command{commandVer, commandChecklatest, commandInstall, commandUpdate, commandUninstall}
+
+var optionalCmds = []command{commandUninstall}
+
+// Select returns args of kind for the running platform: "<os>/<arch>" wins over "<os>", which wins over the base.
+func (c commandSet) Select(kind command) []string {
+	for _, p := range []string{runtime.GOOS + "/" + runtime.GOARCH, runtime.GOOS} {
+		if v, ok := c.Platforms[p]; ok {
+			if args := v.selectBase(kind); len(args) > 0 {
+				return args
+			}
+		}
+	}
+	return c.selectBase(kind)
+}
+
+func (c commandSet) supported() bool {
+	return (len(c.OS) == 0 || slices.Contains(c.OS, runtime.GOOS)) &&
+		(len(c.Arch) == 0 || slices.Contains(c.Arch, runtime.GOARCH))
+}
+
+type platformCommands struct {
+	Ver         []string            `json:"ver,omitzero"`
+	CheckLatest []string            `json:"checklatest,omitzero"`
+	Install     []string            `json:"install,omitzero"`
+	Update      []string            `json:"update,omitzero"`
+	Uninstall   []string            `json:"uninstall,omitzero"`
+	Commands    map[string][]string `json:"commands,omitzero"`
+}
+
+func (c platformCommands) selectBase(kind command) []string {
+	return commandSet{
+		Ver:         c.Ver,
+		CheckLatest: c.CheckLatest,
+		Install:     c.Install,
+		Update:      c.Update,
+		Uninstall:   c.Uninstall,
+		Commands:    c.Commands,
+	}.selectBase(kind)
+}
+
+func (c commandSet) selectBase(kind command) []string {
+	switch kind {
+	default:
+		return c.Commands[string(kind)]
+	case commandVer:
+		return c.Ver
+	case commandChecklatest:
+		return c.CheckLatest
+	case commandInstall:
+		return c.Install
+	case commandUpdate:
+		return c.Update
+	case commandUninstall:
+		return c.Uninstall
+	}
+}
+
+// topologicalSort orders sets after sets named in After, Deps and Requires. Deps and Requires must exist.
+func topologicalSort(s []namedCommandSet) ([]namedCommandSet, error) {
+	type node struct {
+		after []*node
+		val   namedCommandSet
+	}
+
+	nodes := make([]*node, len(s))
+	for i, e := range s {
+		nodes[i] = &node{val: e}
+	}
+	for _, n := range nodes {
+		deps := n.val.Set.dependencies()
+		for _, dep := range deps {
+			if !slices.ContainsFunc(nodes, func(nn *node) bool { return nn.val.Name == dep }) {
+				return nil, fmt.Errorf("%q depends on unknown command set %q", n.val.Name, dep)
+			}
+		}
+		for _, nn := range nodes {
+			// a set naming itself is a cycle of one.
+			if slices.Contains(n.val.Set.After, nn.val.Name) || slices.Contains(deps, nn.val.Name) {
+				n.after = append(n.after, nn)
+			}
+		}
+	}
+
+	sorted := make([]namedCommandSet, 0, len(s))
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[*node]int, len(s))
+	var path []string
+	var visit func(n *node) error
+	visit = func(n *node) error {
+		switch state[n] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependency cycle: %s", strings.Join(append(path, n.val.Name), " -> "))
+		}
+		state[n] = visiting
+		path = append(path, n.val.Name)
+		for _, nn := range n.after {
+			if err := visit(nn); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[n] = visited
+		sorted = append(sorted, n.val)
+		return nil
+	}
+	for _, n := range nodes {
+		if err := visit(n); err != nil {
+			return nil, err
+		}
+	}
+
+	return sorted, nil
+}
@@ -0,0 +1,226 @@
+package manager
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// completionScripts ask "<prog> __complete <word>..." for candidates, falling back to file names when there is none.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for %[1]s. Generated by %[1]s completion bash.
+_%[2]s() {
+    local IFS=$'\n'
+    COMPREPLY=($(%[1]s __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _%[2]s %[1]s
+`,
+	"zsh": `# zsh completion for %[1]s. Generated by %[1]s completion zsh.
+_%[2]s() {
+    local -a candidates
+    candidates=("${(@f)$(%[1]s __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    if [[ -z "${candidates[1]}" ]]; then
+        _files
+        return
+    fi
+    compadd -a candidates
+}
+compdef _%[2]s %[1]s
+`,
+	"fish": `# fish completion for %[1]s. Generated by %[1]s completion fish.
+function __%[2]s_complete
+    %[1]s __complete (commandline -opc)[2..-1] (commandline -ct) 2>/dev/null
+end
+complete -c %[1]s -f -a '(__%[2]s_complete)'
+complete -c %[1]s -n 'not __%[2]s_complete | string length -q' -F
+`,
+	"powershell": `# PowerShell completion for %[1]s. Generated by %[1]s completion powershell.
+Register-ArgumentCompleter -Native -CommandName %[1]s -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $words = @($commandAst.CommandElements | Select-Object -Skip 1 |
+        Where-Object { $_.Extent.EndOffset -le $cursorPosition } |
+        ForEach-Object { $_.Extent.Text })
+    if ($wordToComplete -eq '') {
+        # An empty arg is dropped by Windows PowerShell; __complete takes '""' as one.
+        $words += '""'
+    }
+    & %[1]s __complete @words 2>$null | ForEach-Object {
+        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+    }
+}
+`,
+}
+
+var completionFuncInvalid = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// completion prints a completion script for shell.
+//
+//	completion <bash|zsh|fish|powershell>
+func (m *Manager) completion(_ context.Context, args []string) error {
+	shells := strings.Join(slices.Sorted(maps.Keys(completionScripts)), ", ")
+	if len(args) != 1 {
+		return configError(fmt.Errorf("completion: wrong args length: want 1, got %d: shells are %s", len(args), shells))
+	}
+	script, ok := completionScripts[args[0]]
+	if !ok {
+		return configError(fmt.Errorf("completion: unknown shell %q: want one of %s", args[0], shells))
+	}
+	prog := strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+	fmt.Printf(script, prog, completionFuncInvalid.ReplaceAllString(prog, "_"))
+	return nil
+}
+
+func init() {
+	// Registered here since complete refers to subcommands.
+	subcommands["__complete"] = (*Manager).complete
+}
+
+var completeHidden = []string{"shim-exec", "__complete"}
+
+var completeSetArgs = []string{
+	"pin", "unpin", "enable", "disable", "list", "outdated", "audit", "check", "daemon", "use",
+	"freeze", "restore", "edit", "show", "history", "remove", "rename", "tui",
+}
+
+// completeArgs are completed at the first arg of subcommands.
+var completeArgs = map[string][]string{
+	"schedule":   {"systemd", "launchd", "windows"},
+	"completion": {"bash", "zsh", "fish", "powershell"},
+}
+
+var completeFlagValues = map[string][]string{
+	"o":          {"text", "json", "table", "plain"},
+	"output":     {"text", "json", "table", "plain"},
+	"template":   {"script", "github-release", "go-install"},
+	"log-level":  {"debug", "info", "warn", "error"},
+	"log-format": {"text", "json"},
+	"color":      {"auto", "always", "never"},
+}
+
+// complete prints candidates for the last of args, the word under the cursor, one per line.
+//
+//	__complete [<word>...] <current>
+func (m *Manager) complete(_ context.Context, args []string) error {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	cur := args[len(args)-1]
+	if cur == `""` {
+		cur = ""
+	}
+	for _, c := range m.completions(args[:len(args)-1], cur) {
+		if strings.HasPrefix(c, cur) {
+			fmt.Println(c)
+		}
+	}
+	return nil
+}
+
+func (m *Manager) completions(words []string, cur string) []string {
+	var positional []string
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		if len(positional) > 0 || !strings.HasPrefix(w, "-") || w == "-" {
+			positional = append(positional, w)
+			continue
+		}
+		if w == "--" {
+			positional = append(positional, words[i+1:]...)
+			break
+		}
+		if name := strings.TrimLeft(w, "-"); !strings.Contains(name, "=") && !m.isBoolFlag(name) {
+			if i == len(words)-1 {
+				// cur is the value of the flag.
+				return completeFlagValues[name]
+			}
+			i++
+		}
+	}
+
+	if len(positional) == 0 {
+		if strings.HasPrefix(cur, "-") {
+			return m.flagNames()
+		}
+		var out []string
+		for name := range subcommands {
+			if !slices.Contains(completeHidden, name) {
+				out = append(out, name)
+			}
+		}
+		for _, c := range cmds {
+			out = append(out, string(c))
+		}
+		out = append(out, m.setNames()...)
+		slices.Sort(out)
+		return slices.Compact(out)
+	}
+
+	first, rest := positional[0], positional[1:]
+	switch {
+	case slices.Contains(cmds, command(first)):
+		return m.setNames()
+	case slices.Contains(completeSetArgs, first):
+		if strings.HasPrefix(cur, "-") {
+			return nil
+		}
+		return m.setNames()
+	case first == "export" && len(rest) == 0:
+		return append(slices.Sorted(maps.Keys(exporters)), "--format")
+	case first == "export" && rest[len(rest)-1] == "--format":
+		return slices.Sorted(maps.Keys(bootstrapFormats))
+	case len(rest) == 0 && completeArgs[first] != nil:
+		return completeArgs[first]
+	case subcommands[first] != nil:
+		return nil
+	}
+	// "<tgt> <cmd>" or "<tgt> run <command>".
+	switch {
+	case len(rest) == 0:
+		out := []string{"run"}
+		for _, c := range cmds {
+			out = append(out, string(c))
+		}
+		return out
+	case len(rest) == 1 && rest[0] == "run":
+		sets, _ := resolveNames(m.setDirs(), first)
+		var out []string
+		for _, set := range sets {
+			out = append(out, slices.Collect(maps.Keys(set.Set.Commands))...)
+		}
+		slices.Sort(out)
+		return slices.Compact(out)
+	}
+	return nil
+}
+
+func (m *Manager) setNames() []string {
+	sets, _, _ := readLayeredCommandSets(m.setDirs())
+	return setNames(sets)
+}
+
+func (m *Manager) flagNames() []string {
+	var names []string
+	if m.opts.Flags != nil {
+		m.opts.Flags.VisitAll(func(f *flag.Flag) { names = append(names, "-"+f.Name) })
+	}
+	return names
+}
+
+// isBoolFlag takes unknown flags as boolean.
+func (m *Manager) isBoolFlag(name string) bool {
+	if m.opts.Flags == nil {
+		return true
+	}
+	f := m.opts.Flags.Lookup(name)
+	if f == nil {
+		return true
+	}
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
@@ -0,0 +1,330 @@
+package manager
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/ngicks/go-iterator-helper/hiter/ioiter"
+)
+
+func decodeCommandSetFile(name string) (commandSet, error) {
+	data, err := readAsJSON(name)
+	if err != nil {
+		return commandSet{}, err
+	}
+	var set commandSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return commandSet{}, err
+	}
+	return set, set.Validate()
+}
+
+// loadCommandSet loads name.json (or .toml, .yaml) or directory name from cfgDir.
+func loadCommandSet(cfgDir, name string) (namedCommandSet, error) {
+	if name == globalConfigName {
+		return namedCommandSet{}, fmt.Errorf("%q is reserved for %s", name, globalConfigFileName)
+	}
+	file, err := findSetFile(cfgDir, name)
+	if err == nil {
+		set, err := decodeCommandSetFile(file)
+		if err != nil {
+			return namedCommandSet{}, fmt.Errorf("%s: %w", filepath.Base(file), err)
+		}
+		return namedCommandSet{Name: name, Set: set, Dir: cfgDir}, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return namedCommandSet{}, err
+	}
+
+	s, err := os.Stat(filepath.Join(cfgDir, name))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return namedCommandSet{}, fmt.Errorf("file %[1]q.json or directory %[1]q must exist", name)
+		}
+		return namedCommandSet{}, err
+	}
+	if !s.IsDir() {
+		return namedCommandSet{}, fmt.Errorf("file %[1]q.json or directory %[1]q must exist", name)
+	}
+	return namedCommandSet{Name: name, Dir: cfgDir}, nil
+}
+
+// readCommandSets reads all command sets under cfgDir, sorted by name.
+// Sets failing to be decoded or validated are reported in errs, while err is for cfgDir itself.
+func readCommandSets(cfgDir string) (sets []namedCommandSet, errs []error, err error) {
+	dir, err := os.Open(cfgDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer dir.Close()
+
+	files := map[string]string{}
+	for fi, err := range ioiter.Readdir(dir) {
+		if err != nil {
+			return nil, nil, err
+		}
+		ext, isSetFile := setFileExt(fi.Name())
+		name := strings.TrimSuffix(fi.Name(), ext)
+		switch {
+		case strings.HasPrefix(fi.Name(), "."), isSetFile && name == globalConfigName:
+			// .pin.json, .lock.json and such are not command sets.
+		case fi.Mode().IsRegular() && isSetFile:
+			if other, ok := files[name]; ok {
+				errs = append(errs, fmt.Errorf("%q is defined by both %s and %s", name, other, fi.Name()))
+				continue
+			}
+			files[name] = fi.Name()
+			set, err := decodeCommandSetFile(filepath.Join(cfgDir, fi.Name()))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", fi.Name(), err))
+				continue
+			}
+			sets = append(sets, namedCommandSet{Name: name, Set: set, Dir: cfgDir})
+		case fi.IsDir():
+			// directory should contain scripts.
+			sets = append(sets, namedCommandSet{Name: fi.Name(), Dir: cfgDir})
+		}
+	}
+
+	slices.SortFunc(
+		sets,
+		func(i, j namedCommandSet) int {
+			if c := cmp.Compare(i.Name, j.Name); c != 0 {
+				return c
+			}
+			switch {
+			case reflect.ValueOf(i.Set).IsZero():
+				// x > y
+				return +1
+			case reflect.ValueOf(j.Set).IsZero():
+				return -1
+			default:
+				return 0
+			}
+		},
+	)
+	// may contain both .json and directory
+	sets = slices.CompactFunc(sets, func(i, j namedCommandSet) bool { return i.Name == j.Name })
+	return sets, errs, nil
+}
+
+// findCommandSet loads name from the last of dirs defining it.
+func findCommandSet(dirs []string, name string) (namedCommandSet, error) {
+	for _, dir := range slices.Backward(dirs[1:]) {
+		ok, err := commandSetExists(dir, name)
+		if err != nil {
+			return namedCommandSet{}, err
+		}
+		if ok {
+			return loadCommandSet(dir, name)
+		}
+	}
+	return loadCommandSet(dirs[0], name)
+}
+
+// readLayeredCommandSets is readCommandSets over dirs, a set in a later dir replacing the one in earlier dirs.
+func readLayeredCommandSets(dirs []string) (sets []namedCommandSet, errs []error, err error) {
+	byName := map[string]namedCommandSet{}
+	for _, dir := range dirs {
+		dirSets, dirErrs, err := readCommandSets(dir)
+		if err != nil {
+			return nil, nil, err
+		}
+		errs = append(errs, dirErrs...)
+		for _, set := range dirSets {
+			byName[set.Name] = set
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(byName)) {
+		sets = append(sets, byName[name])
+	}
+	return sets, errs, nil
+}
+
+func loadCommandSets(dirs []string) ([]namedCommandSet, error) {
+	sets, errs, err := readLayeredCommandSets(dirs)
+	if err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return topologicalSort(sets)
+}
+
+// setDirs returns base dirs, the config dir, then the project dir if any.
+func (m *Manager) setDirs() []string {
+	dirs := append(slices.Clone(m.opts.BaseDirs), m.cfgDir)
+	if m.opts.ProjectDir != "" {
+		dirs = append(dirs, m.opts.ProjectDir)
+	}
+	return dirs
+}
+
+func (m *Manager) setDir(name string) string {
+	for _, dir := range slices.Backward(m.setDirs()) {
+		if ok, _ := commandSetExists(dir, name); ok {
+			return dir
+		}
+	}
+	return m.cfgDir
+}
+
+func (m *Manager) resolveTargets(tgt string) ([]namedCommandSet, error) {
+	tgt = m.expandTargets(tgt)
+	sets, err := m.resolveAllTargets(tgt)
+	if err != nil {
+		return nil, err
+	}
+	disabled, err := loadDisabled(m.cfgDir)
+	if err != nil {
+		return nil, err
+	}
+	sets, err = dropDisabled(sets, disabled, tgt)
+	if err != nil {
+		return nil, err
+	}
+	return m.dropUnsupported(sets, tgt)
+}
+
+// dropUnsupported removes sets not for the running platform, erroring if tgt names one.
+func (m *Manager) dropUnsupported(sets []namedCommandSet, tgt string) ([]namedCommandSet, error) {
+	explicit := explicitNames(tgt)
+	var dropped []string
+	for _, set := range sets {
+		if set.Set.supported() {
+			continue
+		}
+		if slices.Contains(explicit, set.Name) {
+			return nil, fmt.Errorf("%q is not for %s/%s: os %s, arch %s", set.Name, runtime.GOOS, runtime.GOARCH,
+				cmp.Or(strings.Join(set.Set.OS, ","), "any"), cmp.Or(strings.Join(set.Set.Arch, ","), "any"))
+		}
+		m.logger().Debug("skipping: not for this platform", "set", set.Name)
+		dropped = append(dropped, set.Name)
+	}
+	sets = slices.DeleteFunc(sets, func(set namedCommandSet) bool { return slices.Contains(dropped, set.Name) })
+	if len(sets) == 0 && len(dropped) > 0 {
+		return nil, fmt.Errorf("no selected command set is for %s/%s: %s", runtime.GOOS, runtime.GOARCH, strings.Join(dropped, ", "))
+	}
+	return sets, nil
+}
+
+// resolveAllTargets returns sets selected by tgt narrowed by -profile and -tag. In a project, empty tgt selects the project's sets.
+func (m *Manager) resolveAllTargets(tgt string) ([]namedCommandSet, error) {
+	tgt = m.expandTargets(tgt)
+	sets, err := resolveNames(m.setDirs(), tgt)
+	if err == nil && tgt == "" && m.opts.ProjectDir != "" {
+		sets = slices.DeleteFunc(sets, func(set namedCommandSet) bool { return set.Dir != m.opts.ProjectDir })
+		if len(sets) == 0 {
+			return nil, fmt.Errorf("project dir %s has no command set", m.opts.ProjectDir)
+		}
+	}
+	if err == nil {
+		sets, err = m.applyProfile(sets, tgt)
+	}
+	if err != nil || m.opts.Tag == "" {
+		return sets, err
+	}
+	tags := strings.Split(m.opts.Tag, ",")
+	sets = slices.DeleteFunc(sets, func(set namedCommandSet) bool {
+		return !slices.ContainsFunc(tags, func(t string) bool { return slices.Contains(set.Set.Tags, t) })
+	})
+	if len(sets) == 0 {
+		return nil, fmt.Errorf("no command set is tagged with any of %q", m.opts.Tag)
+	}
+	return sets, nil
+}
+
+// resolveNames returns sets selected by tgt, a comma separated list of names or path.Match patterns each matching a set.
+func resolveNames(dirs []string, tgt string) ([]namedCommandSet, error) {
+	if tgt == "" {
+		return loadCommandSets(dirs)
+	}
+
+	patterns := strings.Split(tgt, ",")
+	if len(patterns) == 1 && !hasMeta(tgt) {
+		set, err := findCommandSet(dirs, tgt)
+		if err != nil {
+			if !slices.ContainsFunc(dirs, func(dir string) bool { ok, _ := commandSetExists(dir, tgt); return ok }) {
+				sets, _, _ := readLayeredCommandSets(dirs)
+				err = fmt.Errorf("%w%s", err, didYouMean(tgt, setNames(sets)))
+			}
+			return nil, err
+		}
+		return []namedCommandSet{set}, nil
+	}
+
+	for _, pat := range patterns {
+		if pat == "" {
+			return nil, fmt.Errorf("empty target in %q", tgt)
+		}
+		if _, err := path.Match(pat, ""); err != nil {
+			return nil, fmt.Errorf("target %q: %w", pat, err)
+		}
+	}
+
+	all, err := loadCommandSets(dirs)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]bool, len(patterns))
+	var sets []namedCommandSet
+	for _, set := range all {
+		var selected bool
+		for i, pat := range patterns {
+			if ok, _ := path.Match(pat, set.Name); ok {
+				matched[i] = true
+				selected = true
+			}
+		}
+		if selected {
+			sets = append(sets, set)
+		}
+	}
+	if i := slices.Index(matched, false); i >= 0 {
+		var suggestion string
+		if !hasMeta(patterns[i]) {
+			suggestion = didYouMean(patterns[i], setNames(all))
+		}
+		return nil, fmt.Errorf("target %q matches no command set%s", patterns[i], suggestion)
+	}
+	return sets, nil
+}
+
+func explicitNames(tgt string) []string {
+	return slices.DeleteFunc(strings.Split(tgt, ","), func(s string) bool { return s == "" || hasMeta(s) })
+}
+
+func hasMeta(pat string) bool {
+	return strings.ContainsAny(pat, `*?[\`)
+}
+
+func definedCommands(set namedCommandSet) []command {
+	var defined []command
+	b := set.Set.backend()
+	for _, c := range cmds {
+		_, found := findScript(set.Dir, set.Name, c)
+		switch {
+		case len(set.Set.Select(c)) > 0,
+			c == commandChecklatest && set.Set.Source != nil,
+			b != nil && slices.Contains(b.Commands(), c),
+			found:
+			defined = append(defined, c)
+		}
+	}
+	for _, c := range set.Set.userCommands() {
+		defined = append(defined, command(c))
+	}
+	return defined
+}
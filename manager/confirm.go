@@ -0,0 +1,93 @@
+package manager
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var errAborted = errors.New("aborted by user")
+
+// isTerminal reports whether f is a character device other than the null device.
+func isTerminal(f *os.File) bool {
+	s, err := f.Stat()
+	if err != nil || s.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	null, err := os.Stat(os.DevNull)
+	return err != nil || !os.SameFile(s, null)
+}
+
+// confirm asks which updates to run when stdin is a terminal, unless -yes, -dry-run or -non-interactive.
+func (r *runner) confirm(updates []targetedExecutor) ([]targetedExecutor, error) {
+	if r.opts.Yes || r.opts.DryRun || r.opts.NonInteractive || len(updates) == 0 || !isTerminal(os.Stdin) {
+		return updates, nil
+	}
+	return r.ask(os.Stdin, updates)
+}
+
+func (r *runner) ask(in io.Reader, updates []targetedExecutor) ([]targetedExecutor, error) {
+	fmt.Fprintf(r.logw, "\nplan:\n")
+	for i, t := range updates {
+		res := r.report.Get(t.executor.commandSet.Name)
+		var pinned string
+		if res.Pinned {
+			pinned = " (pinned)"
+		}
+		fmt.Fprintf(r.logw, "  %d) %s: %s -> %s%s\n", i+1, res.Name, res.Current, res.Target, pinned)
+	}
+
+	sc := bufio.NewScanner(in)
+	for {
+		fmt.Fprintf(r.logw, "proceed? [Y]es / [n]o / numbers to skip, e.g. \"1 3\": ")
+		if !sc.Scan() {
+			if err := sc.Err(); err != nil {
+				return nil, err
+			}
+			return nil, errAborted
+		}
+		answer := strings.ToLower(strings.TrimSpace(sc.Text()))
+		switch answer {
+		case "", "y", "yes":
+			return updates, nil
+		case "n", "no":
+			for _, t := range updates {
+				r.report.Get(t.executor.commandSet.Name).Action = actionSkipped
+			}
+			return nil, errAborted
+		}
+
+		skip, err := parseSelection(answer, len(updates))
+		if err != nil {
+			fmt.Fprintf(r.logw, "%v\n", err)
+			continue
+		}
+		var selected []targetedExecutor
+		for i, t := range updates {
+			if slices.Contains(skip, i) {
+				r.report.Get(t.executor.commandSet.Name).Action = actionSkipped
+				fmt.Fprintf(r.logw, "skipping %q\n", t.executor.commandSet.Name)
+				continue
+			}
+			selected = append(selected, t)
+		}
+		return selected, nil
+	}
+}
+
+func parseSelection(s string, n int) ([]int, error) {
+	var indices []int
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == ',' }) {
+		i, err := strconv.Atoi(f)
+		if err != nil || i < 1 || i > n {
+			return nil, fmt.Errorf("invalid selection %q: must be a number from 1 to %d", f, n)
+		}
+		indices = append(indices, i-1)
+	}
+	return indices, nil
+}
@@ -0,0 +1,279 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// versionConstraint is a pin matching a range of versions rather than exactly one, e.g.
+//
+//	^1.2        >=1.2.0 <2.0.0
+//	~1.4.0      >=1.4.0 <1.5.0
+//	1.x         >=1.0.0 <2.0.0
+//	>=1.2 <2.0  every condition separated by spaces must hold
+//	1.x || 3.x  either side of || may hold
+//
+// Versions are compared by the versioning of the set. As in npm, a pre-release satisfies an alternative only if one
+// of its bounds is a pre-release of the same major, minor and patch.
+type versionConstraint [][]versionCond
+
+type versionCond struct {
+	op    string
+	bound string
+}
+
+func isConstraint(pin string) bool {
+	if strings.ContainsAny(pin[:min(len(pin), 1)], "^~<>=") || strings.ContainsAny(pin, " |") {
+		return true
+	}
+	return slices.ContainsFunc(strings.Split(pin, "."), isWildcard)
+}
+
+func isWildcard(s string) bool {
+	return s == "x" || s == "X" || s == "*"
+}
+
+func parseConstraint(s string) (versionConstraint, error) {
+	var vc versionConstraint
+	for alt := range strings.SplitSeq(s, "||") {
+		fields := strings.Fields(alt)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("constraint %q: empty alternative", s)
+		}
+		var conds []versionCond
+		for _, f := range fields {
+			c, err := parseVersionCond(f)
+			if err != nil {
+				return nil, fmt.Errorf("constraint %q: %w", s, err)
+			}
+			conds = append(conds, c...)
+		}
+		vc = append(vc, conds)
+	}
+	return vc, nil
+}
+
+func parseVersionCond(s string) ([]versionCond, error) {
+	op := s[:len(s)-len(strings.TrimLeft(s, "^~<>="))]
+	ver := s[len(op):]
+	if ver == "" {
+		return nil, fmt.Errorf("%q: no version", s)
+	}
+	switch op {
+	case "<", "<=", ">", ">=":
+		return []versionCond{{op, ver}}, nil
+	case "", "=", "^", "~":
+	default:
+		return nil, fmt.Errorf("%q: unknown operator %q", s, op)
+	}
+
+	// components given before a wildcard or the end, and any pre-release or build suffix.
+	prefix, rest := "", strings.TrimPrefix(ver, "v")
+	if len(rest) < len(ver) {
+		prefix = "v"
+	}
+	core, suffix := rest, ""
+	if i := strings.IndexAny(rest, "-+"); i >= 0 {
+		core, suffix = rest[:i], rest[i:]
+	}
+	var nums []uint64
+	for c := range strings.SplitSeq(core, ".") {
+		if isWildcard(c) {
+			break
+		}
+		n, err := strconv.ParseUint(c, 10, 64)
+		if err != nil {
+			if op == "" || op == "=" {
+				return []versionCond{{"=", ver}}, nil
+			}
+			return nil, fmt.Errorf("%q: %s needs a numeric version", s, op)
+		}
+		nums = append(nums, n)
+	}
+	wildcard := len(nums) < len(strings.Split(core, "."))
+	if (op == "" || op == "=") && !wildcard {
+		return []versionCond{{"=", ver}}, nil
+	}
+	if wildcard && suffix != "" {
+		return nil, fmt.Errorf("%q: a wildcard can not have a suffix", s)
+	}
+	if len(nums) == 0 {
+		// "*" matches anything.
+		return []versionCond{{">=", "0"}}, nil
+	}
+
+	// the upper bound increments the last component kept.
+	keep := len(nums)
+	switch {
+	case op == "^":
+		// the first non-zero component, or the last given one, must not change.
+		keep = len(nums)
+		for i, n := range nums {
+			if n != 0 {
+				keep = i + 1
+				break
+			}
+		}
+	case op == "~" && len(nums) > 1:
+		keep = 2
+	}
+	upper := slices.Clone(nums[:keep])
+	upper[keep-1]++
+
+	return []versionCond{
+		{">=", prefix + joinNums(nums) + suffix},
+		{"<", prefix + joinNums(upper)},
+	}, nil
+}
+
+func joinNums(nums []uint64) string {
+	s := make([]string, max(len(nums), 3))
+	for i := range s {
+		s[i] = "0"
+		if i < len(nums) {
+			s[i] = strconv.FormatUint(nums[i], 10)
+		}
+	}
+	return strings.Join(s, ".")
+}
+
+func (vc versionConstraint) Satisfied(v versioning, ver string) bool {
+	return slices.ContainsFunc(vc, func(conds []versionCond) bool {
+		for _, cond := range conds {
+			if cond.op == "=" {
+				if !sameVersion(v, ver, cond.bound) {
+					return false
+				}
+				continue
+			}
+			c, ok := v.compare(ver, cond.bound)
+			if !ok {
+				return false
+			}
+			var hold bool
+			switch cond.op {
+			case "<":
+				hold = c < 0
+			case "<=":
+				hold = c <= 0
+			case ">":
+				hold = c > 0
+			case ">=":
+				hold = c >= 0
+			}
+			if !hold {
+				return false
+			}
+		}
+		return !isPrerelease(v, ver) || slices.ContainsFunc(conds, func(cond versionCond) bool {
+			return samePrereleaseTuple(v, ver, cond.bound)
+		})
+	})
+}
+
+func isPrerelease(v versioning, ver string) bool {
+	if v != "" && v != versioningSemver {
+		return false
+	}
+	sv, ok := parseSemver(ver)
+	return ok && len(sv.pre) > 0
+}
+
+func samePrereleaseTuple(v versioning, ver, bound string) bool {
+	if !isPrerelease(v, bound) {
+		return false
+	}
+	a, _ := parseSemver(ver)
+	b, _ := parseSemver(bound)
+	return a.major == b.major && a.minor == b.minor && a.patch == b.patch
+}
+
+// atPin reports whether current satisfies pin if it is a constraint, or equals it otherwise.
+func atPin(v versioning, current, pin string) bool {
+	if !isConstraint(pin) {
+		return sameVersion(v, current, pin)
+	}
+	vc, err := parseConstraint(pin)
+	return err == nil && vc.Satisfied(v, current)
+}
+
+// resolvePin returns the newest of latest, or of versions listed by the set, satisfying pin if it is a constraint.
+// current is kept if it satisfies it and is newer.
+func (e commandExecutor) resolvePin(ctx context.Context, pin, current, latest string) (string, error) {
+	if !isConstraint(pin) {
+		return pin, nil
+	}
+	vc, err := parseConstraint(pin)
+	if err != nil {
+		return "", err
+	}
+	v := e.commandSet.Set.Versioning
+
+	candidates := []string{latest}
+	var listErr error
+	if latest == "" || !vc.Satisfied(v, latest) {
+		available, err := e.versions(ctx)
+		switch {
+		case errors.Is(err, errNoVersions):
+			listErr = err
+		case err != nil:
+			return "", fmt.Errorf("listing versions for %s: %w", pin, err)
+		}
+		candidates = append(candidates, available...)
+	}
+	if current != "" {
+		candidates = append(candidates, current)
+	}
+
+	var best string
+	for _, c := range candidates {
+		if c == "" || !vc.Satisfied(v, c) {
+			continue
+		}
+		if best == "" {
+			best = c
+		} else if cmp, ok := v.compare(c, best); ok && cmp > 0 {
+			best = c
+		}
+	}
+	if best == "" {
+		err := fmt.Errorf("no version satisfies %s", pin)
+		if latest != "" {
+			err = fmt.Errorf("%w: latest is %s", err, latest)
+		}
+		if listErr != nil {
+			err = fmt.Errorf("%w; %w", err, listErr)
+		}
+		return "", err
+	}
+	return best, nil
+}
+
+var errNoVersions = errors.New(`the set can not list versions: define "versions"`)
+
+func (e commandExecutor) versions(ctx context.Context) ([]string, error) {
+	if args := e.commandSet.Set.Versions; len(args) > 0 {
+		dict := e.dict("")
+		cmd := e.command(ctx, slices.Collect(dict.Map(slices.Values(args))), dict)
+		var out strings.Builder
+		cmd.Stdout = &out
+		if err := runCmd(cmd); err != nil {
+			return nil, err
+		}
+		var vers []string
+		for line := range strings.Lines(out.String()) {
+			if line = strings.TrimSpace(line); line != "" {
+				vers = append(vers, line)
+			}
+		}
+		return vers, nil
+	}
+	if g := e.commandSet.Set.GitHub; g != nil {
+		return g.versions(ctx, githubClient{token: e.githubToken, log: e.log})
+	}
+	return nil, errNoVersions
+}
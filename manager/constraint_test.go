@@ -0,0 +1,66 @@
+package manager
+
+import "testing"
+
+func TestConstraintSatisfied(t *testing.T) {
+	for _, tc := range []struct {
+		constraint string
+		satisfied  []string
+		not        []string
+	}{
+		{"^1.2", []string{"1.2.0", "1.9.3", "v1.2.1"}, []string{"1.1.9", "2.0.0", "2.0.0-rc.1", "1.3.0-rc.1", "1.2.0-rc.1"}},
+		{"^0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.3.0", "0.3.0-rc.1", "0.2.2"}},
+		{"^1.2.0-rc.1", []string{"1.2.0-rc.1", "1.2.0-rc.2", "1.2.0", "1.5.0"}, []string{"1.2.0-beta.1", "1.3.0-rc.1", "2.0.0-rc.1"}},
+		{"~1.4.0", []string{"1.4.0", "1.4.7"}, []string{"1.5.0", "1.5.0-rc.1", "1.4.1-rc.1", "1.3.9"}},
+		{"~1", []string{"1.0.0", "1.9.0"}, []string{"2.0.0", "2.0.0-alpha"}},
+		{"1.x", []string{"1.0.0", "1.99.0"}, []string{"0.9.0", "2.0.0", "2.0.0-rc.1", "1.5.0-rc.1"}},
+		{"1.2.x", []string{"1.2.0", "1.2.9"}, []string{"1.3.0", "1.3.0-rc.1"}},
+		{"*", []string{"0.0.1", "10.0.0"}, []string{"1.0.0-rc.1"}},
+		{"1.x || 3.x", []string{"1.0.0", "3.2.1"}, []string{"2.0.0", "4.0.0", "2.0.0-rc.1", "4.0.0-rc.1"}},
+		{"^1.2 || 2.0.0-rc.1", []string{"1.5.0", "2.0.0-rc.1"}, []string{"2.0.0-rc.2", "2.0.0"}},
+		{">=1.2 <2.0", []string{"1.2.0", "1.9.9"}, []string{"1.1.0", "2.0.0", "2.0.0-rc.1"}},
+		{"1.2.0", []string{"1.2.0", "v1.2.0"}, []string{"1.2.1"}},
+	} {
+		vc, err := parseConstraint(tc.constraint)
+		if err != nil {
+			t.Errorf("parseConstraint(%q): %v", tc.constraint, err)
+			continue
+		}
+		for _, ver := range tc.satisfied {
+			if !vc.Satisfied(versioningSemver, ver) {
+				t.Errorf("%q is not satisfied by %q", tc.constraint, ver)
+			}
+		}
+		for _, ver := range tc.not {
+			if vc.Satisfied(versioningSemver, ver) {
+				t.Errorf("%q is satisfied by %q", tc.constraint, ver)
+			}
+		}
+	}
+}
+
+func TestConstraintCalver(t *testing.T) {
+	vc, err := parseConstraint("^2024")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for ver, want := range map[string]bool{"2024.01.15": true, "2024-12-31": true, "2025.01.01": false} {
+		if got := vc.Satisfied(versioningCalver, ver); got != want {
+			t.Errorf("^2024 satisfied by %q = %t, want %t", ver, got, want)
+		}
+	}
+}
+
+func TestParseConstraintError(t *testing.T) {
+	for _, s := range []string{
+		"^",
+		"1.x ||",
+		"|| 1.x",
+		"^abc",
+		"1.x-rc.1",
+	} {
+		if _, err := parseConstraint(s); err == nil {
+			t.Errorf("parseConstraint(%q) succeeded, want an error", s)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	daemonStateFileName   = ".daemon.json"
+	defaultDaemonInterval = 6 * time.Hour
+	scheduleName          = "ngpkgmgr-check"
+)
+
+type daemonState struct {
+	// Notified maps sets to target versions already notified.
+	Notified map[string]string `json:"notified"`
+}
+
+func loadDaemonState(cfgDir string) (daemonState, error) {
+	var state daemonState
+	data, err := readAsJSON(filepath.Join(cfgDir, daemonStateFileName))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return state, nil
+		}
+		return state, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("%s: %w", daemonStateFileName, err)
+	}
+	return state, nil
+}
+
+func storeDaemonState(cfgDir string, state daemonState) error {
+	data, err := json.MarshalIndent(state, "", "    ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(cfgDir, daemonStateFileName), append(data, '\n'), 0o644)
+}
+
+// daemon runs ver and checklatest every interval, notifying updates not notified yet. Failed checks are retried
+// at the next interval.
+//
+//	daemon [--interval <duration>] [--once] [--metrics-addr <addr>] [--metrics-file <path>] [--push <url>] [<tgt>]
+func (m *Manager) daemon(ctx context.Context, args []string) error {
+	fset := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	interval := fset.Duration("interval", defaultDaemonInterval, "interval between checks")
+	once := fset.Bool("once", false, "checks once and exits")
+	metricsAddr := fset.String("metrics-addr", "", "serves Prometheus metrics at /metrics on this address, e.g. :9464")
+	metricsFile := fset.String("metrics-file", "", "writes Prometheus metrics to this file after each check, e.g. for node_exporter")
+	push := fset.String("push", "", "pushes Prometheus metrics to this Pushgateway URL after each check")
+	if err := fset.Parse(args); err != nil {
+		return configError(err)
+	}
+	if fset.NArg() > 1 {
+		return configError(fmt.Errorf("daemon: wrong args length: want 0 or 1, got %d", fset.NArg()))
+	}
+	if *interval <= 0 {
+		return configError(fmt.Errorf("daemon: interval must be positive"))
+	}
+
+	if *push != "" && !strings.HasPrefix(*push, "https://") && !strings.HasPrefix(*push, "http://") {
+		return configError(fmt.Errorf("daemon: --push must be http(s), got %q", *push))
+	}
+
+	log := m.logger()
+	metrics := &daemonMetrics{}
+	if *metricsAddr != "" {
+		ln, err := net.Listen("tcp", *metricsAddr)
+		if err != nil {
+			return fmt.Errorf("daemon: %w", err)
+		}
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics)
+		srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		go func() { _ = srv.Serve(ln) }()
+		defer srv.Close()
+		log.Info("daemon: serving metrics", "addr", ln.Addr().String())
+	}
+	for {
+		entries, err := m.daemonCheck(ctx, fset.Arg(0), *interval)
+		if ctx.Err() == nil {
+			metrics.record(entries, err, time.Now())
+			if *metricsFile != "" {
+				if err := metrics.writeFile(*metricsFile); err != nil {
+					log.Warn("daemon: writing metrics failed", "err", err)
+				}
+			}
+			if *push != "" {
+				if err := metrics.push(ctx, *push); err != nil {
+					log.Warn("daemon: pushing metrics failed", "err", err)
+				}
+			}
+		}
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err != nil && *once:
+			return err
+		case err != nil:
+			log.Warn("daemon: check failed", "err", err)
+		}
+		if *once {
+			return nil
+		}
+		log.Info("daemon: next check", "at", time.Now().Add(*interval).Format(time.DateTime))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(*interval):
+		}
+	}
+}
+
+func (m *Manager) daemonCheck(ctx context.Context, tgt string, interval time.Duration) ([]*checkEntry, error) {
+	pinnedVersions, err := loadPinnedVersions(m.cfgDir)
+	if err != nil {
+		return nil, configError(err)
+	}
+	sets, err := m.resolveTargets(tgt)
+	if err != nil {
+		return nil, configError(err)
+	}
+
+	// Always refresh, so that the cache serves later runs with -cache-ttl.
+	dm := *m
+	dm.opts.CacheTTL = max(dm.opts.CacheTTL, interval)
+	dm.opts.Refresh = true
+	r, err := dm.newRunner(commandChecklatest, sets, pinnedVersions, outputText)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := r.checkAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := loadDaemonState(m.cfgDir)
+	if err != nil {
+		return entries, err
+	}
+	available := map[string]string{}
+	var lines []string
+	var numNew int
+	for _, e := range entries {
+		switch e.Problem {
+		case "outdated":
+			available[e.Name] = e.Target
+			lines = append(lines, fmt.Sprintf("%s: %s -> %s", e.Name, e.Current, e.Target))
+			if state.Notified[e.Name] != e.Target {
+				numNew++
+			}
+			r.log.Info("update available", "set", e.Name, "current", e.Current, "target", e.Target)
+		case "checklatest failed":
+			r.log.Warn("checklatest failed", "set", e.Name, "err", e.Error)
+		}
+	}
+	r.log.Info("daemon: checked", "sets", len(entries), "updates", len(available), "new", numNew)
+
+	if numNew > 0 && r.notify != nil {
+		title := fmt.Sprintf("ngpkgmgr: %d update(s) available", len(available))
+		if err := r.notify.send(ctx, title, strings.Join(lines, "\n"), entries); err != nil {
+			// try again at the next check.
+			r.log.Warn("notifying failed", "err", err)
+			return entries, nil
+		}
+	}
+	state.Notified = available
+	return entries, storeDaemonState(m.cfgDir, state)
+}
+
+// schedule prints systemd user units, a launchd agent or a Windows scheduled task running "daemon --once".
+//
+//	schedule [--interval <duration>] <systemd|launchd|windows>
+func (m *Manager) schedule(_ context.Context, args []string) error {
+	fset := flag.NewFlagSet("schedule", flag.ContinueOnError)
+	interval := fset.Duration("interval", defaultDaemonInterval, "interval between checks")
+	if err := fset.Parse(args); err != nil {
+		return configError(err)
+	}
+	if fset.NArg() != 1 {
+		return configError(fmt.Errorf("schedule: wrong args length: want 1, got %d", fset.NArg()))
+	}
+	if *interval < time.Minute {
+		return configError(fmt.Errorf("schedule: interval must be a minute or longer"))
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmdline := []string{exe}
+	for _, dir := range append(m.opts.BaseDirs, m.cfgDir) {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		cmdline = append(cmdline, "-dir", abs)
+	}
+	if m.opts.Profile != "" {
+		cmdline = append(cmdline, "-profile", m.opts.Profile)
+	}
+	cmdline = append(cmdline, "-no-project", "-non-interactive", "-log-to-file", "daemon", "--once")
+
+	switch fset.Arg(0) {
+	case "systemd":
+		fmt.Print(systemdUnits(cmdline, *interval))
+	case "launchd":
+		fmt.Print(launchdPlist(cmdline, *interval))
+	case "windows":
+		line, err := schtasksCommand(cmdline, *interval)
+		if err != nil {
+			return configError(fmt.Errorf("schedule: %w", err))
+		}
+		fmt.Println(line)
+	default:
+		return configError(fmt.Errorf("schedule: unknown scheduler %q: must be systemd, launchd or windows", fset.Arg(0)))
+	}
+	return nil
+}
+
+func systemdUnits(cmdline []string, interval time.Duration) string {
+	quoted := make([]string, len(cmdline))
+	for i, arg := range cmdline {
+		quoted[i] = arg
+		if strings.ContainsAny(arg, " \t\"'\\") {
+			quoted[i] = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+		}
+	}
+	secs := int64(interval / time.Second)
+	return fmt.Sprintf(`# ~/.config/systemd/user/%[1]s.service
+[Unit]
+Description=Check updates of ngpkgmgr command sets
+
+[Service]
+Type=oneshot
+ExecStart=%[2]s
+
+# ~/.config/systemd/user/%[1]s.timer
+[Unit]
+Description=Check updates of ngpkgmgr command sets every %[3]s
+
+[Timer]
+OnBootSec=5min
+OnUnitActiveSec=%[4]ds
+Persistent=true
+
+[Install]
+WantedBy=timers.target
+
+# then run: systemctl --user daemon-reload && systemctl --user enable --now %[1]s.timer
+`, scheduleName, strings.Join(quoted, " "), interval, secs)
+}
+
+func launchdPlist(cmdline []string, interval time.Duration) string {
+	var args strings.Builder
+	for _, arg := range cmdline {
+		args.WriteString("        <string>" + xmlEscape(arg) + "</string>\n")
+	}
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!-- ~/Library/LaunchAgents/com.github.ngicks.%[1]s.plist -->
+<!-- then run: launchctl load ~/Library/LaunchAgents/com.github.ngicks.%[1]s.plist -->
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+    <key>Label</key>
+    <string>com.github.ngicks.%[1]s</string>
+    <key>ProgramArguments</key>
+    <array>
+%[2]s    </array>
+    <key>StartInterval</key>
+    <integer>%[3]d</integer>
+    <key>RunAtLoad</key>
+    <true/>
+</dict>
+</plist>
+`, scheduleName, args.String(), int64(interval/time.Second))
+}
+
+func xmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace(s)
+}
+
+// schtasksCommand takes interval in whole minutes or hours below a day, or whole days.
+func schtasksCommand(cmdline []string, interval time.Duration) (string, error) {
+	var schedule string
+	switch {
+	case interval%(24*time.Hour) == 0:
+		schedule = fmt.Sprintf("/SC DAILY /MO %d", interval/(24*time.Hour))
+	case interval < 24*time.Hour && interval%time.Hour == 0:
+		schedule = fmt.Sprintf("/SC HOURLY /MO %d", interval/time.Hour)
+	case interval < 24*time.Hour && interval%time.Minute == 0:
+		schedule = fmt.Sprintf("/SC MINUTE /MO %d", interval/time.Minute)
+	default:
+		return "", fmt.Errorf("interval %s can not be scheduled: use whole minutes below a day or whole days", interval)
+	}
+	quoted := make([]string, len(cmdline))
+	for i, arg := range cmdline {
+		quoted[i] = arg
+		if strings.ContainsAny(arg, " \t") {
+			quoted[i] = `\"` + arg + `\"`
+		}
+	}
+	return fmt.Sprintf(`schtasks /Create /F /TN %s %s /TR "%s"`, scheduleName, schedule, strings.Join(quoted, " ")), nil
+}
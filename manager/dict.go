@@ -0,0 +1,35 @@
+package manager
+
+import (
+	"iter"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// dictReplacer replaces keys, e.g. "${VER}", with values.
+type dictReplacer map[string]string
+
+func (r dictReplacer) Map(seq iter.Seq[string]) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		replacer := r.replacer()
+		for s := range seq {
+			if !yield(replacer.Replace(s)) {
+				return
+			}
+		}
+	}
+}
+
+// Expand replaces every occurrence of keys in s.
+func (r dictReplacer) Expand(s string) string {
+	return r.replacer().Replace(s)
+}
+
+func (r dictReplacer) replacer() *strings.Replacer {
+	oldnew := make([]string, 0, len(r)*2)
+	for _, k := range slices.Sorted(maps.Keys(r)) {
+		oldnew = append(oldnew, k, r[k])
+	}
+	return strings.NewReplacer(oldnew...)
+}
@@ -0,0 +1,122 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	disabledFileName = ".disabled.json"
+)
+
+func loadDisabled(cfgDir string) ([]string, error) {
+	var names []string
+	data, err := readAsJSON(filepath.Join(cfgDir, disabledFileName))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &names); err != nil {
+		return nil, fmt.Errorf("%s: %w", disabledFileName, err)
+	}
+	return names, nil
+}
+
+func storeDisabled(cfgDir string, names []string) error {
+	names = slices.Sorted(slices.Values(names))
+	names = slices.Compact(names)
+	if names == nil {
+		names = []string{}
+	}
+	data, err := json.MarshalIndent(names, "", "    ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(cfgDir, disabledFileName), append(data, '\n'), 0o644)
+}
+
+func isDisabled(set namedCommandSet, disabled []string) bool {
+	return set.Set.Disabled || slices.Contains(disabled, set.Name)
+}
+
+// dropDisabled keeps disabled sets tgt names explicitly, not by a pattern. It errors if no set is left.
+func dropDisabled(sets []namedCommandSet, disabled []string, tgt string) ([]namedCommandSet, error) {
+	explicit := explicitNames(tgt)
+	var dropped []string
+	sets = slices.DeleteFunc(sets, func(set namedCommandSet) bool {
+		if isDisabled(set, disabled) && !slices.Contains(explicit, set.Name) {
+			dropped = append(dropped, set.Name)
+			return true
+		}
+		return false
+	})
+	if len(sets) == 0 && len(dropped) > 0 {
+		return nil, fmt.Errorf("every selected command set is disabled: %s", strings.Join(dropped, ", "))
+	}
+	return sets, nil
+}
+
+// disable lists names in .disabled.json so that they are skipped unless named explicitly.
+//
+//	disable <name>...
+func (m *Manager) disable(_ context.Context, args []string) error {
+	if len(args) == 0 {
+		return configError(fmt.Errorf("disable: wrong args length: want 1 or more, got 0"))
+	}
+	disabled, err := loadDisabled(m.cfgDir)
+	if err != nil {
+		return configError(err)
+	}
+	for _, name := range args {
+		ok, err := commandSetExists(m.setDir(name), name)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return configError(fmt.Errorf("disable: file %[1]q.json or directory %[1]q must exist%[2]s", name, didYouMean(name, m.setNames())))
+		}
+		if slices.Contains(disabled, name) {
+			m.logger().Info("already disabled", "set", name)
+			continue
+		}
+		disabled = append(disabled, name)
+	}
+	return storeDisabled(m.cfgDir, disabled)
+}
+
+// enable removes names from .disabled.json. A set disabled by its own "disabled" stays disabled.
+//
+//	enable <name>...
+func (m *Manager) enable(_ context.Context, args []string) error {
+	if len(args) == 0 {
+		return configError(fmt.Errorf("enable: wrong args length: want 1 or more, got 0"))
+	}
+	disabled, err := loadDisabled(m.cfgDir)
+	if err != nil {
+		return configError(err)
+	}
+	for _, name := range args {
+		i := slices.Index(disabled, name)
+		if i < 0 {
+			set, err := findCommandSet(m.setDirs(), name)
+			switch {
+			case err != nil:
+				return configError(fmt.Errorf("enable: %w", err))
+			case set.Set.Disabled:
+				return configError(fmt.Errorf("enable: %q is disabled by \"disabled\" of its config: remove it instead", name))
+			}
+			m.logger().Info("not disabled", "set", name)
+			continue
+		}
+		disabled = slices.Delete(disabled, i, i+1)
+	}
+	return storeDisabled(m.cfgDir, disabled)
+}
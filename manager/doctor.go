@@ -0,0 +1,89 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// doctor checks, on top of validate, the sets work on this machine, still without executing any command.
+func (m *Manager) doctor(_ context.Context, args []string) error {
+	if len(args) != 0 {
+		return configError(fmt.Errorf("doctor: wrong args length: want 0, got %d", len(args)))
+	}
+	return checkConfig("doctor", m.cfgDir, m.setDirs(), diagnoseCommandSet)
+}
+
+func diagnoseCommandSet(set namedCommandSet) []string {
+	var problems []string
+	for _, c := range cmds {
+		args := set.Set.Select(c)
+		script, found := findScript(set.Dir, set.Name, c)
+		if found && len(args) > 0 {
+			problems = append(problems, fmt.Sprintf("%s: script %s is shadowed by args of the set", c, script))
+		}
+		switch {
+		case len(args) > 0:
+			if _, err := exec.LookPath(args[0]); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", c, err))
+			}
+		case found && runtime.GOOS == "windows":
+			if _, err := scriptArgs(script, set.Set.Interpreters); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", c, err))
+			}
+		case found:
+			s, err := os.Stat(script)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", c, err))
+			} else if s.Mode().Perm()&0o111 == 0 {
+				problems = append(problems, fmt.Sprintf("%s: script %s is not executable", c, script))
+			}
+		}
+	}
+	if src := set.Set.Source; src != nil && src.Type == "plugin" {
+		if _, err := exec.LookPath(pluginResolverPrefix + src.Name); err != nil {
+			problems = append(problems, fmt.Sprintf("source: %v", err))
+		}
+	}
+	if b := set.Set.backend(); b != nil {
+		if tool := backendTool(b); tool != "" {
+			if _, err := exec.LookPath(tool); err != nil {
+				problems = append(problems, fmt.Sprintf("backend: %v", err))
+			}
+		}
+	}
+	if sig := set.Set.Signature; sig != nil {
+		tool := "gpg"
+		if sig.Cosign != nil {
+			tool = "cosign"
+		}
+		if _, err := exec.LookPath(tool); err != nil {
+			problems = append(problems, fmt.Sprintf("signature: %v", err))
+		}
+	}
+	return problems
+}
+
+func backendTool(b backend) string {
+	switch b.(type) {
+	case *goInstallBackend:
+		return "go"
+	case *cargoBackend:
+		return "cargo"
+	case *npmBackend:
+		return "npm"
+	case *pipxBackend:
+		return "pipx"
+	case *brewBackend:
+		return "brew"
+	case *aptBackend:
+		return "apt-get"
+	case *scoopBackend:
+		return "scoop"
+	case *wingetBackend:
+		return "winget"
+	}
+	return ""
+}
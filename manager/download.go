@@ -0,0 +1,337 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// minChunkSize is the least chunk size of concurrent downloads. Smaller files are downloaded in one request.
+const minChunkSize = 8 << 20
+
+type downloader struct {
+	// Retries resume a failed download, waiting retryBaseDelay doubling each time.
+	Retries int
+	// Chunks are downloaded concurrently if the server supports ranges. Less than 2 downloads in one request.
+	Chunks int
+	// Progress should be a terminal, where a progress line is rewritten in place.
+	Progress io.Writer
+	Header   http.Header
+}
+
+func newDownloader(stderr io.Writer) downloader {
+	d := downloader{Retries: 3, Chunks: 4}
+	if f, ok := stderr.(*os.File); ok && isTerminal(f) {
+		d.Progress = f
+	}
+	return d
+}
+
+type httpStatusError struct {
+	url    string
+	status string
+	code   int
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("GET %s: %s", e.url, e.status)
+}
+
+func (e *httpStatusError) retryable() bool {
+	return e.code >= 500 || e.code == http.StatusRequestTimeout || e.code == http.StatusTooManyRequests
+}
+
+// Download downloads url to "<dst>.part", which a later Download resumes if the server supports ranges, then renames it to dst.
+func (d downloader) Download(ctx context.Context, url, dst string) error {
+	part := dst + ".part"
+	size, ranges := d.probe(ctx, url)
+	p := newProgress(d.Progress, url, size)
+	defer p.finish()
+
+	var err error
+	_, statErr := os.Stat(part)
+	if ranges && d.Chunks > 1 && size >= int64(d.Chunks)*minChunkSize && statErr != nil {
+		err = d.chunked(ctx, url, part, size, p)
+	} else {
+		err = d.retry(ctx, func() error { return d.resume(ctx, url, part, p) })
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(part, dst)
+}
+
+// probe returns the size of url, or -1 if unknown, and whether the server serves ranges of it.
+func (d downloader) probe(ctx context.Context, url string) (size int64, ranges bool) {
+	resp, err := d.do(ctx, http.MethodHead, url, "")
+	if err != nil {
+		return -1, false
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return -1, false
+	}
+	return resp.ContentLength, resp.Header.Get("Accept-Ranges") == "bytes"
+}
+
+func (d downloader) do(ctx context.Context, method, url, byteRange string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, vs := range d.Header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", "ngpkgmgr/"+version)
+	}
+	if byteRange != "" {
+		req.Header.Set("Range", "bytes="+byteRange)
+	}
+	return http.DefaultClient.Do(req)
+}
+
+func (d downloader) retry(ctx context.Context, fn func() error) error {
+	backoff := retryBaseDelay
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		var statusErr *httpStatusError
+		if err == nil || attempt >= d.Retries || ctx.Err() != nil || (errors.As(err, &statusErr) && !statusErr.retryable()) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (d downloader) resume(ctx context.Context, url, part string, p *progress) error {
+	var offset int64
+	if info, err := os.Stat(part); err == nil {
+		offset = info.Size()
+	}
+	byteRange := ""
+	if offset > 0 {
+		byteRange = strconv.FormatInt(offset, 10) + "-"
+	}
+	resp, err := d.do(ctx, http.MethodGet, url, byteRange)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	flag := os.O_CREATE | os.O_WRONLY
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		flag |= os.O_APPEND
+	case http.StatusOK:
+		// The server ignored the range.
+		offset = 0
+		flag |= os.O_TRUNC
+	case http.StatusRequestedRangeNotSatisfiable:
+		if offset > 0 {
+			// part is already complete.
+			p.set(offset)
+			return nil
+		}
+		fallthrough
+	default:
+		return &httpStatusError{url: url, status: resp.Status, code: resp.StatusCode}
+	}
+	f, err := os.OpenFile(part, flag, 0o644)
+	if err != nil {
+		return err
+	}
+	p.set(offset)
+	_, err = io.Copy(f, p.reader(resp.Body))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("GET %s: %w", url, err)
+	}
+	return nil
+}
+
+// chunked downloads url in d.Chunks ranges concurrently, each retried on its own.
+// part is removed if any of them fails, since which ranges are complete is not recorded.
+func (d downloader) chunked(ctx context.Context, url, part string, size int64, p *progress) (err error) {
+	f, err := os.Create(part)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			_ = os.Remove(part)
+		}
+	}()
+	if err := f.Truncate(size); err != nil {
+		return err
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	chunk := (size + int64(d.Chunks) - 1) / int64(d.Chunks)
+	for start := int64(0); start < size; start += chunk {
+		end := min(start+chunk, size) - 1
+		g.Go(func() error {
+			next := start
+			return d.retry(gctx, func() error {
+				resp, err := d.do(gctx, http.MethodGet, url, fmt.Sprintf("%d-%d", next, end))
+				if err != nil {
+					return err
+				}
+				defer resp.Body.Close()
+				if resp.StatusCode != http.StatusPartialContent {
+					return &httpStatusError{url: url, status: resp.Status, code: resp.StatusCode}
+				}
+				w := io.NewOffsetWriter(f, next)
+				n, err := io.Copy(w, p.reader(io.LimitReader(resp.Body, end+1-next)))
+				next += n
+				if err == nil && next <= end {
+					err = io.ErrUnexpectedEOF
+				}
+				if err != nil {
+					return fmt.Errorf("GET %s: %w", url, err)
+				}
+				return nil
+			})
+		})
+	}
+	return g.Wait()
+}
+
+// fetch downloads url for install scripts and prints the path written.
+//
+//	fetch [-o <path>] [--retries <n>] [--chunks <n>] [--header '<key>: <value>']... <url>
+func (m *Manager) fetch(ctx context.Context, args []string) error {
+	fset := flag.NewFlagSet("fetch", flag.ContinueOnError)
+	d := newDownloader(os.Stderr)
+	d.Header = http.Header{}
+	var output string
+	fset.StringVar(&output, "o", "", "path to write. defaults to the base name of url")
+	fset.StringVar(&output, "output", "", "same as -o")
+	fset.IntVar(&d.Retries, "retries", d.Retries, "times a failed download is resumed")
+	fset.IntVar(&d.Chunks, "chunks", d.Chunks, "ranges of a large file downloaded concurrently")
+	fset.Func("header", "header added to requests, as '<key>: <value>'. can be repeated", func(s string) error {
+		k, v, ok := strings.Cut(s, ":")
+		if !ok {
+			return fmt.Errorf("want <key>: <value>, got %q", s)
+		}
+		d.Header.Add(strings.TrimSpace(k), strings.TrimSpace(v))
+		return nil
+	})
+	if err := fset.Parse(args); err != nil {
+		return configError(err)
+	}
+	if fset.NArg() != 1 {
+		return configError(fmt.Errorf("fetch: wrong args length: want 1, got %d", fset.NArg()))
+	}
+	rawURL := fset.Arg(0)
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return configError(fmt.Errorf("fetch: not an http(s) url: %q", rawURL))
+	}
+	if output == "" {
+		output = path.Base(u.Path)
+		if output == "." || output == "/" {
+			return configError(fmt.Errorf("fetch: %q has no file name: specify -o", rawURL))
+		}
+	}
+	if m.opts.DryRun {
+		fmt.Printf("[dry-run] would download %s to %s\n", rawURL, output)
+		return nil
+	}
+	if err := d.Download(ctx, rawURL, output); err != nil {
+		return err
+	}
+	fmt.Println(output)
+	return nil
+}
+
+// progress renders a progress line to w, at most every progressInterval. A nil w renders nothing.
+type progress struct {
+	w     io.Writer
+	name  string
+	total int64
+	done  atomic.Int64
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+const progressInterval = 100 * time.Millisecond
+
+func newProgress(w io.Writer, name string, total int64) *progress {
+	return &progress{w: w, name: name, total: total}
+}
+
+func (p *progress) set(n int64) {
+	p.done.Store(n)
+	p.render(false)
+}
+
+func (p *progress) reader(r io.Reader) io.Reader {
+	if p.w == nil {
+		return r
+	}
+	return progressReader{r, p}
+}
+
+func (p *progress) render(force bool) {
+	if p.w == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !force && time.Since(p.last) < progressInterval {
+		return
+	}
+	p.last = time.Now()
+	done := p.done.Load()
+	if p.total > 0 {
+		fmt.Fprintf(p.w, "\r%s %s / %s (%d%%)\x1b[K", p.name, formatBytes(done), formatBytes(p.total), done*100/p.total)
+	} else {
+		fmt.Fprintf(p.w, "\r%s %s\x1b[K", p.name, formatBytes(done))
+	}
+}
+
+func (p *progress) finish() {
+	if p.w == nil {
+		return
+	}
+	p.render(true)
+	fmt.Fprintln(p.w)
+}
+
+type progressReader struct {
+	r io.Reader
+	p *progress
+}
+
+func (r progressReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	r.p.done.Add(int64(n))
+	r.p.render(false)
+	return n, err
+}
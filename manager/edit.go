@@ -0,0 +1,76 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// edit opens the file of name, or its directory, in the editor, then reports problems of the edited set.
+//
+//	edit [--dir] <name>
+func (m *Manager) edit(ctx context.Context, args []string) error {
+	fset := flag.NewFlagSet("edit", flag.ContinueOnError)
+	dir := fset.Bool("dir", false, "open the script directory instead of .json")
+	if err := fset.Parse(args); err != nil {
+		return configError(err)
+	}
+	if fset.NArg() != 1 {
+		return configError(fmt.Errorf("edit: wrong args length: want 1, got %d", fset.NArg()))
+	}
+	name := fset.Arg(0)
+
+	setDir := m.setDir(name)
+	dirPath := filepath.Join(setDir, name)
+	file, fileErr := findSetFile(setDir, name)
+	_, dirErr := os.Stat(dirPath)
+	switch {
+	case errors.Is(fileErr, fs.ErrNotExist) && errors.Is(dirErr, fs.ErrNotExist):
+		return configError(fmt.Errorf("edit: %q is not a command set: create it with -new first", name))
+	case fileErr != nil && !errors.Is(fileErr, fs.ErrNotExist):
+		return configError(fmt.Errorf("edit: %w", fileErr))
+	}
+	target := file
+	if *dir || fileErr != nil {
+		target = dirPath
+	}
+
+	editor := strings.Fields(editorCommand())
+	cmd := exec.CommandContext(ctx, editor[0], append(editor[1:], target)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("edit: running editor: %w", err)
+	}
+
+	set, err := loadCommandSet(setDir, name)
+	if err != nil {
+		return configError(fmt.Errorf("edit: %w", err))
+	}
+	problems := validateCommandSet(set)
+	for _, p := range problems {
+		fmt.Printf("%q: %s\n", name, p)
+	}
+	if len(problems) > 0 {
+		return configError(fmt.Errorf("edit: %d problem(s) found", len(problems)))
+	}
+	return nil
+}
+
+func editorCommand() string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if e := strings.TrimSpace(os.Getenv(env)); e != "" {
+			return e
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return "notepad"
+	}
+	return "vi"
+}
@@ -0,0 +1,23 @@
+//go:build !unix
+
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// execReplace runs path with args, args[0] included, as if it replaced the process:
+// stdio is inherited and the exit status of path is returned as that of the process.
+func execReplace(path string, args []string) error {
+	cmd := exec.Command(path, args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &exitError{code: exitErr.ExitCode(), err: fmt.Errorf("%s: %w", args[0], err)}
+	}
+	return err
+}
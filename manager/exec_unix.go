@@ -0,0 +1,13 @@
+//go:build unix
+
+package manager
+
+import (
+	"os"
+	"syscall"
+)
+
+// execReplace replaces the process with path run with args, args[0] included. It returns only on failure.
+func execReplace(path string, args []string) error {
+	return syscall.Exec(path, args, os.Environ())
+}
@@ -0,0 +1,429 @@
+package manager
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+var errTimeout = errors.New("timed out")
+
+var retryBaseDelay = time.Second
+
+type executorDefaults struct {
+	Timeout time.Duration
+	Retries int
+	// Hooks run around hooks of each set.
+	Hooks    hooksConfig
+	CacheTTL time.Duration
+	Refresh  bool
+	Shell    []string
+	// GitHubToken returns the token sent to the GitHub API. nil means none.
+	GitHubToken func() (string, error)
+	BinDir      string
+	// Managed is disabled by the zero value.
+	Managed managedDir
+	// CfgDir "" records no receipt.
+	CfgDir         string
+	NonInteractive bool
+}
+
+// nonInteractiveEnv tells commands, and tools they run, that nobody answers prompts.
+var nonInteractiveEnv = []string{
+	"CI=1",
+	"DEBIAN_FRONTEND=noninteractive",
+	"NONINTERACTIVE=1",
+	"GIT_TERMINAL_PROMPT=0",
+	"PIP_NO_INPUT=1",
+}
+
+type commandExecutor struct {
+	dir            string
+	commandSet     namedCommandSet
+	timeout        time.Duration
+	retries        int
+	backoff        time.Duration
+	retryable      []command
+	globalHooks    hooksConfig
+	cacheTTL       time.Duration
+	refresh        bool
+	stdin          io.Reader
+	stdout         io.Writer
+	stderr         io.Writer
+	shell          []string
+	githubToken    func() (string, error)
+	nonInteractive bool
+	binDir         string
+	managed        managedDir
+	cfgDir         string
+	args           []string
+	log            *slog.Logger
+	// observe is called after each Exec, hooks and retries included.
+	observe func(kind command, ver string, start time.Time, err error)
+}
+
+func newCommandExecutor(
+	commandSet namedCommandSet,
+	defaults executorDefaults,
+	stdin io.Reader,
+	stdout io.Writer,
+	stderr io.Writer,
+) *commandExecutor {
+	timeout := defaults.Timeout
+	if commandSet.Set.Timeout != nil {
+		timeout = time.Duration(*commandSet.Set.Timeout)
+	}
+	retries := defaults.Retries
+	if commandSet.Set.Retries != nil {
+		retries = *commandSet.Set.Retries
+	}
+	backoff, retryable := retryBaseDelay, defaultRetryableCmds
+	if p := commandSet.Set.Retry; p != nil {
+		if p.Count != nil {
+			retries = *p.Count
+		}
+		if p.Backoff != nil {
+			backoff = time.Duration(*p.Backoff)
+		}
+		if len(p.Commands) > 0 {
+			retryable = p.Commands
+		}
+	}
+	return &commandExecutor{
+		dir:            commandSet.Dir,
+		commandSet:     commandSet,
+		timeout:        timeout,
+		retries:        retries,
+		backoff:        backoff,
+		retryable:      retryable,
+		globalHooks:    defaults.Hooks,
+		cacheTTL:       defaults.CacheTTL,
+		refresh:        defaults.Refresh,
+		stdin:          stdin,
+		stdout:         stdout,
+		stderr:         stderr,
+		shell:          defaults.Shell,
+		githubToken:    defaults.GitHubToken,
+		binDir:         defaults.BinDir,
+		managed:        defaults.Managed,
+		cfgDir:         defaults.CfgDir,
+		nonInteractive: defaults.NonInteractive,
+		log:            slog.New(slog.DiscardHandler),
+	}
+}
+
+// Exec runs the command of kind, retrying it with backoff per the set's retry policy.
+// ver is never retried since its failure means the set is not installed.
+func (e commandExecutor) Exec(
+	ctx context.Context,
+	kind command,
+	ver string,
+	verbose bool,
+) (out string, err error) {
+	if e.observe != nil {
+		start := time.Now()
+		defer func() { e.observe(kind, ver, start, err) }()
+	}
+	if kind == commandChecklatest && e.cacheTTL > 0 {
+		return e.execCached(ctx, ver, verbose)
+	}
+	if err := e.runHooks(ctx, hookPre, kind, ver); err != nil {
+		return "", err
+	}
+	out, err = e.execRetry(ctx, kind, ver, verbose)
+	if err != nil {
+		return out, err
+	}
+	return out, e.runHooks(ctx, hookPost, kind, ver)
+}
+
+func (e commandExecutor) execCached(ctx context.Context, ver string, verbose bool) (string, error) {
+	if cached, ok := loadLatestCache(e.commandSet.Name, e.cacheTTL); ok && !e.refresh {
+		if verbose {
+			fmt.Fprintln(e.stdout, cached)
+		}
+		return cached, nil
+	}
+	out, err := e.execRetry(ctx, commandChecklatest, ver, verbose)
+	if err == nil && strings.TrimSpace(out) != "" {
+		if err := storeLatestCache(e.commandSet.Name, strings.TrimSpace(out)); err != nil {
+			e.log.Warn("caching checklatest failed", "set", e.commandSet.Name, "err", err)
+		}
+	}
+	return out, err
+}
+
+func (e commandExecutor) execRetry(
+	ctx context.Context,
+	kind command,
+	ver string,
+	verbose bool,
+) (string, error) {
+	out, err := e.exec(ctx, kind, ver, verbose)
+	if !slices.Contains(e.retryable, kind) {
+		return e.extract(kind, out, err)
+	}
+	delay := e.backoff
+	for i := range e.retries {
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+		e.log.Warn("retrying", "set", e.commandSet.Name, "command", kind, "attempt", i+1, "retries", e.retries, "err", err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return out, err
+		case <-timer.C:
+		}
+		delay *= 2
+		out, err = e.exec(ctx, kind, ver, verbose)
+	}
+	return e.extract(kind, out, err)
+}
+
+func (e commandExecutor) exec(
+	ctx context.Context,
+	kind command,
+	ver string,
+	verbose bool,
+) (out string, err error) {
+	// a command killed on cancellation fails with its own error, e.g. "signal: killed", rather than ctx.Err().
+	defer func(ctx context.Context) {
+		if err != nil && ctx.Err() != nil && !errors.Is(err, ctx.Err()) {
+			err = fmt.Errorf("%w: %w", ctx.Err(), err)
+		}
+	}(ctx)
+	if timeout := e.timeoutOf(kind); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+		defer func() {
+			if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				err = fmt.Errorf("%w after %s: %w", errTimeout, timeout, err)
+			}
+		}()
+	}
+
+	args := e.commandSet.Set.Select(kind)
+	if kind == commandChecklatest && len(args) == 0 && e.commandSet.Set.Source != nil {
+		return e.resolveSource(ctx, verbose)
+	}
+	if kind == commandUninstall && len(args) == 0 && e.cfgDir != "" {
+		if _, found := findScript(e.dir, e.commandSet.Name, kind); !found {
+			rec, ok, err := loadReceipt(e.cfgDir, e.commandSet.Name)
+			if err != nil {
+				return "", err
+			}
+			if ok {
+				return "", e.uninstallByReceipt(rec)
+			}
+		}
+	}
+	if b := e.commandSet.Set.backend(); len(args) == 0 && b != nil && slices.Contains(b.Commands(), kind) {
+		out, err := b.Exec(ctx, e, kind, ver)
+		if verbose && out != "" {
+			fmt.Fprintln(e.stdout, out)
+		}
+		return out, err
+	}
+	dict := e.dict(ver)
+	if c := e.commandSet.Set.Checksum; c != nil && c.Artifact != "" && (kind == commandInstall || kind == commandUpdate) {
+		artifact, cleanup, err := e.fetchArtifact(ctx, dict)
+		if err != nil {
+			return "", err
+		}
+		defer cleanup()
+		dict["${ARTIFACT}"] = artifact
+	}
+	if len(args) > 0 {
+		args = slices.Collect(dict.Map(slices.Values(args)))
+	} else {
+		name, ok := findScript(e.dir, e.commandSet.Name, kind)
+		if !ok {
+			return "", fmt.Errorf("command not found")
+		}
+		args, err = scriptArgs(name, e.interpreters())
+		if err != nil {
+			return "", err
+		}
+	}
+	user := !slices.Contains(cmds, kind)
+	if user {
+		args = append(args, e.args...)
+	}
+
+	cmd := e.command(ctx, args, dict)
+	buf := new(bytes.Buffer)
+	if kind == commandInstall || kind == commandUninstall || user {
+		cmd.Stdout = e.stdout
+	} else if !verbose {
+		cmd.Stdout = buf
+	} else {
+		cmd.Stdout = io.MultiWriter(buf, e.stdout)
+	}
+
+	if e.commandSet.Set.Pty {
+		err = runInPTY(cmd)
+		// terminals end lines with CRLF.
+		return strings.ReplaceAll(buf.String(), "\r\n", "\n"), err
+	}
+	err = runCmd(cmd)
+	return buf.String(), err
+}
+
+func (e commandExecutor) interpreters() map[string][]string {
+	if _, ok := e.commandSet.Set.Interpreters[".sh"]; ok || len(e.shell) == 0 {
+		return e.commandSet.Set.Interpreters
+	}
+	interpreters := maps.Clone(e.commandSet.Set.Interpreters)
+	if interpreters == nil {
+		interpreters = map[string][]string{}
+	}
+	interpreters[".sh"] = e.shell
+	return interpreters
+}
+
+// timeoutOf returns Timeouts of the set, then Timeout of the set, then -timeout.
+func (e commandExecutor) timeoutOf(kind command) time.Duration {
+	if d, ok := e.commandSet.Set.Timeouts[kind]; ok {
+		return time.Duration(d)
+	}
+	return e.timeout
+}
+
+func (e commandExecutor) extract(kind command, out string, err error) (string, error) {
+	x, ok := e.commandSet.Set.Extract[kind]
+	if err != nil || !ok {
+		return out, err
+	}
+	ver, err := x.Extract(out)
+	if err != nil {
+		return "", fmt.Errorf("extracting version: %w", err)
+	}
+	return ver, nil
+}
+
+var killGracePeriod = 5 * time.Second
+
+func (e commandExecutor) command(ctx context.Context, args []string, dict dictReplacer) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, args[0])
+	if len(args) > 1 {
+		cmd.Args = args
+	}
+
+	if w := e.commandSet.Set.Workdir; w != "" {
+		// an error is reported by cmd.Start.
+		cmd.Dir, cmd.Err = expandHome(dict.Expand(w))
+	}
+	cmd.Stdin = e.stdin
+	// commands under a pty are leaders of their own sessions, signaled as a group.
+	var tty *os.File
+	if f, ok := e.stdin.(*os.File); ok && isTerminal(f) && !e.commandSet.Set.Pty {
+		tty = f
+	}
+	setCancel(cmd, tty, killGracePeriod)
+	cmd.Stderr = e.stderr
+	cmd.Env = e.environ(dict)
+	if cmd.Dir != "" {
+		e.log.Debug("running", "set", e.commandSet.Name, "args", strings.Join(args, " "), "dir", cmd.Dir, "env", strings.Join(e.extraEnv(dict), " "))
+	} else {
+		e.log.Debug("running", "set", e.commandSet.Name, "args", strings.Join(args, " "), "env", strings.Join(e.extraEnv(dict), " "))
+	}
+	return cmd
+}
+
+func findScript(cfgDir, name string, kind command) (string, bool) {
+	for _, suf := range []string{"", ".sh", ".exe", ".bat", ".ps1"} {
+		p := filepath.Join(cfgDir, name, string(kind)+suf)
+		if _, err := os.Stat(p); err == nil {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+func (e commandExecutor) dict(ver string) dictReplacer {
+	noV := strings.TrimPrefix(ver, "v")
+	major, rest, _ := strings.Cut(noV, ".")
+	minor, rest, _ := strings.Cut(rest, ".")
+	patch, _, _ := strings.Cut(rest, ".")
+	// drop pre-release and build metadata, e.g. "3-rc.1" or "3+build".
+	patch, _, _ = strings.Cut(patch, "-")
+	patch, _, _ = strings.Cut(patch, "+")
+	home, _ := os.UserHomeDir()
+
+	dict := dictReplacer{
+		"${VER}":        ver,
+		"${VER_NO_V}":   noV,
+		"${VER_MAJOR}":  major,
+		"${VER_MINOR}":  minor,
+		"${VER_PATCH}":  patch,
+		"${OS}":         cmp.Or(e.commandSet.Set.OSMap[runtime.GOOS], runtime.GOOS),
+		"${ARCH}":       cmp.Or(e.commandSet.Set.ArchMap[runtime.GOARCH], runtime.GOARCH),
+		"${HOME}":       home,
+		"${CONFIG_DIR}": e.dir,
+		"${NAME}":       e.commandSet.Name,
+	}
+	builtin := maps.Clone(dict)
+	for k, v := range e.commandSet.Set.Vars {
+		dict["${"+k+"}"] = builtin.Expand(v)
+	}
+	withVars := maps.Clone(dict)
+	for k, v := range e.commandSet.Set.Env {
+		dict["${"+k+"}"] = withVars.Expand(v)
+	}
+	return dict
+}
+
+// environ returns os.Environ with OS, ARCH, PKGMGR, keys of Env, and VER and ARTIFACT when set in dict.
+func (e commandExecutor) environ(dict dictReplacer) []string {
+	return append(os.Environ(), e.extraEnv(dict)...)
+}
+
+func (e commandExecutor) extraEnv(dict dictReplacer) []string {
+	env := []string{"OS=" + runtime.GOOS, "ARCH=" + runtime.GOARCH}
+	if exe, err := os.Executable(); err == nil {
+		env = append(env, "PKGMGR="+exe)
+	}
+	if e.nonInteractive {
+		env = append(env, nonInteractiveEnv...)
+	}
+	for _, k := range slices.Sorted(maps.Keys(e.commandSet.Set.Env)) {
+		env = append(env, k+"="+dict["${"+k+"}"])
+	}
+	for _, k := range []string{"VER", "ARTIFACT"} {
+		if v := dict["${"+k+"}"]; v != "" {
+			env = append(env, k+"="+v)
+		}
+	}
+	return env
+}
+
+// verifyVersion runs ver and checks it reports want. Empty want only checks the set is installed.
+func verifyVersion(ctx context.Context, executor *commandExecutor, want string) (string, error) {
+	out, err := executor.Exec(ctx, commandVer, "", false)
+	if err != nil {
+		return "", err
+	}
+	got := strings.TrimSpace(out)
+	if got == "" {
+		return "", fmt.Errorf("empty output")
+	}
+	if want != "" && !sameVersion(executor.commandSet.Set.Versioning, got, want) {
+		return got, fmt.Errorf("version mismatch: want %s, got %s", want, got)
+	}
+	return got, nil
+}
@@ -0,0 +1,70 @@
+package manager
+
+import (
+	"context"
+	"errors"
+)
+
+// Exit codes.
+//
+//	0   success.
+//	1   command failure: a command failed and the run was aborted.
+//	2   config error: wrong flags, arguments or configuration. Nothing, or nothing more, was executed.
+//	3   partial failure: the run completed but some sets failed, e.g. under -f, or -verify-after found mismatches.
+//	4   outdated: some sets are not at their target versions. Returned only by commands checking that.
+//	5   locked: another run is using the config dir. Nothing was executed.
+//	6   vulnerable: audit found vulnerabilities at or above --fail-on.
+//	130 interrupted by SIGINT or SIGTERM.
+const (
+	exitOK             = 0
+	exitCommandFailure = 1
+	exitConfigError    = 2
+	exitPartialFailure = 3
+	exitOutdated       = 4
+	exitLocked         = 5
+	exitVulnerable     = 6
+	exitInterrupted    = 130
+)
+
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// configError returns nil if err is nil.
+func configError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{code: exitConfigError, err: err}
+}
+
+func partialFailure(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{code: exitPartialFailure, err: err}
+}
+
+// ExitCode returns the exit status for err. Errors not marked otherwise are command failures.
+func ExitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	if errors.Is(err, context.Canceled) {
+		return exitInterrupted
+	}
+	return exitCommandFailure
+}
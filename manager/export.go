@@ -0,0 +1,186 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+var exporters = map[string]func(m *Manager, ctx context.Context, args []string) error{
+	"sbom":          (*Manager).exportSBOM,
+	"tool-versions": (*Manager).exportToolVersions,
+}
+
+// export runs the exporter of kind, or exportConfig if args start with a flag.
+//
+//	export <kind> [<arg>...]
+//	export --format <format> [-o <path>] [<tgt>]
+func (m *Manager) export(ctx context.Context, args []string) error {
+	kinds := strings.Join(slices.Sorted(maps.Keys(exporters)), ", ")
+	if len(args) == 0 {
+		return configError(fmt.Errorf("export: kind or --format must be specified: kinds are %s", kinds))
+	}
+	if strings.HasPrefix(args[0], "-") {
+		return m.exportConfig(ctx, args)
+	}
+	fn, ok := exporters[args[0]]
+	if !ok {
+		return configError(fmt.Errorf("export: unknown kind %q: want one of %s", args[0], kinds))
+	}
+	return fn(m, ctx, args[1:])
+}
+
+func writeExport(output string, data []byte) error {
+	if output == "" || output == "-" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return writeFileAtomic(output, data, 0o644)
+}
+
+// exportSBOM exports installed sets as a CycloneDX or SPDX JSON document.
+//
+//	export sbom [--format <cyclonedx|spdx>] [-o <path>] [<tgt>]
+func (m *Manager) exportSBOM(ctx context.Context, args []string) error {
+	fset := flag.NewFlagSet("export sbom", flag.ContinueOnError)
+	format := fset.String("format", "cyclonedx", "document format: cyclonedx or spdx")
+	output := fset.String("o", "", "path to write. defaults to stdout")
+	if err := fset.Parse(args); err != nil {
+		return configError(err)
+	}
+	if fset.NArg() > 1 {
+		return configError(fmt.Errorf("export sbom: wrong args length: want 0 or 1, got %d", fset.NArg()))
+	}
+	var gen func(host string, components []sbomComponent, now time.Time) ([]byte, error)
+	switch *format {
+	case "cyclonedx":
+		gen = cycloneDX
+	case "spdx":
+		gen = spdx
+	default:
+		return configError(fmt.Errorf("export sbom: unknown --format %q: want cyclonedx or spdx", *format))
+	}
+	sets, err := m.resolveTargets(fset.Arg(0))
+	if err != nil {
+		return configError(err)
+	}
+	versions, err := m.installedVersions(ctx, sets)
+	if err != nil {
+		return err
+	}
+	data, err := gen(hostname(), sbomComponents(sets, versions), time.Now())
+	if err != nil {
+		return err
+	}
+	return writeExport(*output, append(data, '\n'))
+}
+
+// exportToolVersions writes sets at their exact pins, or else current versions, as a .tool-versions file of asdf and mise.
+// Other lines of an existing output are kept.
+//
+//	export tool-versions [--installed] [-o <path>] [<tgt>]
+func (m *Manager) exportToolVersions(ctx context.Context, args []string) error {
+	fset := flag.NewFlagSet("export tool-versions", flag.ContinueOnError)
+	installed := fset.Bool("installed", false, "uses current versions even of pinned sets")
+	output := fset.String("o", "", "path to write. defaults to stdout")
+	if err := fset.Parse(args); err != nil {
+		return configError(err)
+	}
+	if fset.NArg() > 1 {
+		return configError(fmt.Errorf("export tool-versions: wrong args length: want 0 or 1, got %d", fset.NArg()))
+	}
+	pinnedVersions, err := loadPinnedVersions(m.cfgDir)
+	if err != nil {
+		return configError(err)
+	}
+	sets, err := m.resolveTargets(fset.Arg(0))
+	if err != nil {
+		return configError(err)
+	}
+	versions := map[string]string{}
+	var unpinned []namedCommandSet
+	for _, set := range sets {
+		if pin := pinnedVersions[set.Name]; pin != "" && !isConstraint(pin) && !*installed {
+			versions[set.Name] = pin
+		} else {
+			unpinned = append(unpinned, set)
+		}
+	}
+	current, err := m.installedVersions(ctx, unpinned)
+	if err != nil {
+		return err
+	}
+	maps.Copy(versions, current)
+
+	var existing []byte
+	if *output != "" && *output != "-" {
+		existing, err = os.ReadFile(*output)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return writeExport(*output, updateToolVersions(existing, versions))
+}
+
+var bootstrapFormats = map[string]func(w io.Writer, sets []bootstrapSet) error{
+	"brewfile": writeBrewfile,
+	"shell":    writeShell,
+	"ansible":  writeAnsible,
+}
+
+// exportConfig converts sets into a Brewfile, a sh script or an Ansible tasks file installing them without ngpkgmgr,
+// at their exact pins, or else current versions, or else latest versions.
+//
+//	export --format <brewfile|shell|ansible> [-o <path>] [<tgt>]
+func (m *Manager) exportConfig(ctx context.Context, args []string) error {
+	fset := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fset.String("format", "", "bootstrap format: brewfile, shell or ansible")
+	output := fset.String("o", "", "path to write. defaults to stdout")
+	if err := fset.Parse(args); err != nil {
+		return configError(err)
+	}
+	if fset.NArg() > 1 {
+		return configError(fmt.Errorf("export: wrong args length: want 0 or 1, got %d", fset.NArg()))
+	}
+	write, ok := bootstrapFormats[*format]
+	if !ok {
+		return configError(fmt.Errorf("export: unknown --format %q: want one of %s", *format, strings.Join(slices.Sorted(maps.Keys(bootstrapFormats)), ", ")))
+	}
+	pinnedVersions, err := loadPinnedVersions(m.cfgDir)
+	if err != nil {
+		return configError(err)
+	}
+	sets, err := m.resolveTargets(fset.Arg(0))
+	if err != nil {
+		return configError(err)
+	}
+	if sets, err = topologicalSort(sets); err != nil {
+		return configError(err)
+	}
+	versions, err := m.installedVersions(ctx, sets)
+	if err != nil {
+		return err
+	}
+	bootstrap := make([]bootstrapSet, len(sets))
+	for i, set := range sets {
+		ver := versions[set.Name]
+		if pin := pinnedVersions[set.Name]; pin != "" && !isConstraint(pin) {
+			ver = pin
+		}
+		bootstrap[i] = newBootstrapSet(set, ver)
+	}
+	var b bytes.Buffer
+	if err := write(&b, bootstrap); err != nil {
+		return err
+	}
+	return writeExport(*output, b.Bytes())
+}
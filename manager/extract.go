@@ -0,0 +1,88 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// extractor applies JSONPath first and then Regex to its result if both are set.
+type extractor struct {
+	// Regex uses its first capture group if any, the whole match otherwise.
+	Regex string `json:"regex,omitzero"`
+	// JSONPath is a dot separated path, e.g. "tag_name" or "$.versions.0".
+	JSONPath string `json:"json_path,omitzero"`
+}
+
+func (x extractor) Validate() error {
+	if x.Regex == "" && x.JSONPath == "" {
+		return fmt.Errorf("either regex or json_path must be specified")
+	}
+	if x.Regex != "" {
+		if _, err := regexp.Compile(x.Regex); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (x extractor) Extract(s string) (string, error) {
+	if x.JSONPath != "" {
+		v, err := lookupJSONPath([]byte(s), x.JSONPath)
+		if err != nil {
+			return "", err
+		}
+		s = v
+	}
+	if x.Regex != "" {
+		re, err := regexp.Compile(x.Regex)
+		if err != nil {
+			return "", err
+		}
+		m := re.FindStringSubmatch(s)
+		switch {
+		case m == nil:
+			return "", fmt.Errorf("regex %q matched nothing in %q", x.Regex, strings.TrimSpace(s))
+		case len(m) > 1:
+			s = m[1]
+		default:
+			s = m[0]
+		}
+	}
+	return strings.TrimSpace(s), nil
+}
+
+// lookupJSONPath returns a non-string value as JSON.
+func lookupJSONPath(data []byte, path string) (string, error) {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return "", fmt.Errorf("json_path: %w", err)
+	}
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path != "" {
+		for _, key := range strings.Split(path, ".") {
+			switch node := v.(type) {
+			case map[string]any:
+				var ok bool
+				if v, ok = node[key]; !ok {
+					return "", fmt.Errorf("json_path: %q not found", key)
+				}
+			case []any:
+				i, err := strconv.Atoi(key)
+				if err != nil || i < 0 || i >= len(node) {
+					return "", fmt.Errorf("json_path: invalid index %q for array of %d", key, len(node))
+				}
+				v = node[i]
+			default:
+				return "", fmt.Errorf("json_path: can not look up %q in %T", key, node)
+			}
+		}
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	out, err := json.Marshal(v)
+	return string(out), err
+}
@@ -0,0 +1,149 @@
+package manager
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// setFileExts are in order of precedence. Every format is converted to JSON before decoding, so that they share one schema.
+var setFileExts = []string{".json", ".toml", ".yaml", ".yml"}
+
+func setFileExt(fileName string) (string, bool) {
+	ext := filepath.Ext(fileName)
+	for _, e := range setFileExts {
+		if ext == e {
+			return ext, true
+		}
+	}
+	return "", false
+}
+
+// findSetFile returns an error wrapping fs.ErrNotExist if no file defines name, and an error if more than one does.
+func findSetFile(cfgDir, name string) (string, error) {
+	var found []string
+	for _, ext := range setFileExts {
+		p := filepath.Join(cfgDir, name+ext)
+		s, err := os.Stat(p)
+		switch {
+		case err == nil && s.Mode().IsRegular():
+			found = append(found, p)
+		case err != nil && !errors.Is(err, fs.ErrNotExist):
+			return "", err
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("%s.json: %w", name, fs.ErrNotExist)
+	case 1:
+		return found[0], nil
+	}
+	return "", fmt.Errorf("%q is defined by more than one file: %s", name, strings.Join(found, ", "))
+}
+
+// readAsJSON converts fileName to JSON by its extension. JSON may have comments and trailing commas.
+func readAsJSON(fileName string) ([]byte, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var v any
+	switch filepath.Ext(fileName) {
+	default:
+		return stripJSONC(data), nil
+	case ".toml":
+		v, err = parseTOML(data)
+	case ".yaml", ".yml":
+		v, err = parseYAML(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// marshalAs keeps the field order of v.
+func marshalAs(ext string, v any) ([]byte, error) {
+	data, err := json.MarshalIndent(v, "", "    ")
+	if err != nil || ext == ".json" {
+		return append(data, '\n'), err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	ordered, err := decodeOrdered(dec)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := ordered.(*orderedObject)
+	if !ok {
+		return nil, fmt.Errorf("%s: top level must be an object", ext)
+	}
+	var buf bytes.Buffer
+	switch ext {
+	case ".toml":
+		err = writeTOML(&buf, obj)
+		return bytes.TrimLeft(buf.Bytes(), "\n"), err
+	case ".yaml", ".yml":
+		err = writeYAML(&buf, obj, 0)
+	default:
+		err = fmt.Errorf("unknown format %q", ext)
+	}
+	return buf.Bytes(), err
+}
+
+type orderedObject struct {
+	keys   []string
+	values []any
+}
+
+// decodeOrdered decodes into *orderedObject, []any, string, json.Number, bool or nil.
+func decodeOrdered(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		obj := &orderedObject{}
+		for dec.More() {
+			k, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj.keys = append(obj.keys, k.(string))
+			obj.values = append(obj.values, v)
+		}
+		_, err := dec.Token()
+		return obj, err
+	case json.Delim('['):
+		arr := []any{}
+		for dec.More() {
+			v, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		_, err := dec.Token()
+		return arr, err
+	}
+	return tok, nil
+}
+
+// quoteJSON returns s as a JSON string, which is also a valid TOML basic string and YAML double-quoted scalar.
+func quoteJSON(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,48 @@
+package manager
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// normalizeJSON re-decodes v through JSON so that values parsed from any format, e.g. int64 and float64 numbers,
+// compare equal to ones decoded from JSON.
+func normalizeJSON(t *testing.T, v any) any {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// assertJSON asserts got is the document want written in JSON.
+func assertJSON(t *testing.T, got any, want string) {
+	t.Helper()
+	var w any
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("bad expectation %s: %v", want, err)
+	}
+	if g := normalizeJSON(t, got); !reflect.DeepEqual(g, w) {
+		gotJSON, _ := json.Marshal(g)
+		t.Errorf("got %s, want %s", gotJSON, want)
+	}
+}
+
+// roundTripDocs are documents marshalAs writes and every parser must read back unchanged.
+var roundTripDocs = []string{
+	`{}`,
+	`{"ver": ["go", "version"], "checklatest": ["sh", "-c", "curl -s https://go.dev/VERSION?m=text | head -1"]}`,
+	`{"description": "line 1\nline 2\ttab \"quoted\" 'single' # not a comment", "pty": true, "retries": 3, "backoff": 1.5}`,
+	`{"env": {"GOBIN": "${HOME}/bin", "a.b": "dotted", "": "empty key"}, "os": ["linux", "darwin"]}`,
+	`{"nested": {"deeper": {"value": "x"}}, "after": []}`,
+	`{"hooks": [{"name": "first", "run": ["echo", "1"]}, {"name": "second", "run": ["echo", "2"]}]}`,
+	`{"mixed": ["a", 1, true, 2.5, ["b"], {"c": "d"}]}`,
+	`{"versions": ["1.0.0", "true", "null", "123", "-x", "@at", ": colon", "- dash", "yes"]}`,
+	`{"unicode": "日本語 ✓", "emptyobj": {}, "emptystr": ""}`,
+}
@@ -0,0 +1,174 @@
+package manager
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	lockFileName = ".lock.json"
+)
+
+func loadLockFile(name string) (map[string]string, error) {
+	data, err := readAsJSON(name)
+	if err != nil {
+		return nil, err
+	}
+	locked := map[string]string{}
+	if err := json.Unmarshal(data, &locked); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	for k, v := range locked {
+		if err := validatePin(k, v); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return locked, nil
+}
+
+func lockFileFlag(flags *flag.FlagSet, cfgDir string) *string {
+	return flags.String("file", filepath.Join(cfgDir, lockFileName), "path to the lock file")
+}
+
+// freeze records current versions of sets into the lock file.
+//
+//	freeze [--file <path>] [<tgt>]
+func (m *Manager) freeze(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("freeze", flag.ContinueOnError)
+	file := lockFileFlag(flags, m.cfgDir)
+	if err := flags.Parse(args); err != nil {
+		return configError(err)
+	}
+	if flags.NArg() > 1 {
+		return configError(fmt.Errorf("freeze: wrong args length: want 0 or 1, got %d", flags.NArg()))
+	}
+	sets, err := m.resolveTargets(flags.Arg(0))
+	if err != nil {
+		return configError(err)
+	}
+
+	versions, err := m.installedVersions(ctx, sets)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(versions, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(*file, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	m.logger().Info("froze", "sets", len(versions), "file", *file)
+	return nil
+}
+
+// installedVersions leaves out sets not installed with a warning.
+func (m *Manager) installedVersions(ctx context.Context, sets []namedCommandSet) (map[string]string, error) {
+	r, err := m.newRunner(commandVer, sets, nil, outputText)
+	if err != nil {
+		return nil, err
+	}
+	ver := func(ctx context.Context, executor *commandExecutor, log *slog.Logger) error {
+		out, err := executor.Exec(ctx, commandVer, "", false)
+		if err != nil || strings.TrimSpace(out) == "" {
+			log.Warn("skipping: seems not installed", "set", executor.commandSet.Name)
+			return nil
+		}
+		r.mu.Lock()
+		r.currentVersions[executor.commandSet.Name] = strings.TrimSpace(out)
+		r.mu.Unlock()
+		return nil
+	}
+	errs := runJobs(ctx, cmp.Or(r.opts.Parallel, 1), true, os.Stderr, r.jobs(ver))
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return r.currentVersions, nil
+}
+
+// restore installs locked sets at their locked versions, ignoring pins.
+//
+//	restore [--file <path>] [<tgt>]
+func (m *Manager) restore(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+	file := lockFileFlag(flags, m.cfgDir)
+	if err := flags.Parse(args); err != nil {
+		return configError(err)
+	}
+	if flags.NArg() > 1 {
+		return configError(fmt.Errorf("restore: wrong args length: want 0 or 1, got %d", flags.NArg()))
+	}
+
+	format := outputFormat(m.opts.Output)
+	if err := format.Validate(); err != nil {
+		return configError(err)
+	}
+	locked, err := loadLockFile(*file)
+	if err != nil {
+		return configError(err)
+	}
+	sets, err := m.resolveTargets(flags.Arg(0))
+	if err != nil {
+		return configError(err)
+	}
+	log := m.logger()
+	for _, name := range slices.Sorted(maps.Keys(locked)) {
+		if !slices.ContainsFunc(sets, func(set namedCommandSet) bool { return set.Name == name }) && flags.Arg(0) == "" {
+			log.Warn("locked but no such command set exists", "set", name)
+		}
+	}
+	sets = slices.DeleteFunc(sets, func(set namedCommandSet) bool { return locked[set.Name] == "" })
+
+	r, err := m.newRunner(commandInstall, sets, locked, format)
+	if err != nil {
+		return err
+	}
+	return r.finish(ctx, r.restore(ctx))
+}
+
+func (r *runner) restore(ctx context.Context) error {
+	restore := func(ctx context.Context, executor *commandExecutor, log *slog.Logger) error {
+		name := executor.commandSet.Name
+		res := r.report.Get(name)
+		res.Target = r.pinnedVersions[name]
+		r.mu.Lock()
+		r.targetVersions[name] = res.Target
+		r.mu.Unlock()
+
+		out, err := executor.Exec(ctx, commandVer, "", false)
+		res.Current = strings.TrimSpace(out)
+		if err == nil && sameVersion(executor.commandSet.Set.Versioning, res.Current, res.Target) {
+			res.Action = actionSkipped
+			log.Info("skipping: already at the locked version", "set", name, "version", res.Current)
+			return nil
+		}
+		if r.opts.DryRun {
+			log.Info("[dry-run] would install", "set", name, "version", res.Target)
+			return nil
+		}
+
+		log.Info("installing", "set", name, "version", res.Target)
+		if _, err := executor.Exec(ctx, commandInstall, res.Target, r.opts.Verbose); err != nil {
+			err := fmt.Errorf("install %q: %w", name, err)
+			res.Fail(err)
+			log.Warn("failed", "set", name, "err", err)
+			return err
+		}
+		res.Action = actionInstalled
+		log.Info("installed", "set", name, "version", res.Target)
+		return nil
+	}
+	errs := runJobs(ctx, cmp.Or(r.opts.Parallel, 1), r.opts.Force, r.logw, r.jobs(restore))
+	return r.jobErrors(commandInstall, errs)
+}
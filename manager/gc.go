@@ -0,0 +1,202 @@
+package manager
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// gcTempAge guards temp dirs of concurrent runs using the same config dir.
+const gcTempAge = time.Hour
+
+// tempPrefix names temp dirs of fetched artifacts after cfgDir so that gc only removes its own.
+func tempPrefix(cfgDir string) string {
+	if abs, err := filepath.Abs(cfgDir); err == nil {
+		cfgDir = abs
+	}
+	sum := sha256.Sum256([]byte(cfgDir))
+	return "pkgmgr-" + hex.EncodeToString(sum[:4]) + "-"
+}
+
+// gc removes versions in the managed dir beyond --keep, stale checklatest cache and leftovers of interrupted runs.
+// Versions and receipts of sets no longer defined are only reported.
+//
+//	gc [--keep <n>]
+func (m *Manager) gc(_ context.Context, args []string) error {
+	fset := flag.NewFlagSet("gc", flag.ContinueOnError)
+	keep := fset.Int("keep", 0, "versions of each set kept in the managed dir. negative keeps every version")
+	if err := fset.Parse(args); err != nil {
+		return configError(err)
+	}
+	if fset.NArg() != 0 {
+		return configError(fmt.Errorf("gc: wrong args length: want 0, got %d", fset.NArg()))
+	}
+	globalCfg, err := loadGlobalConfig(m.cfgDir)
+	if err != nil {
+		return configError(err)
+	}
+	d, err := globalCfg.Managed.dir()
+	if err != nil {
+		return configError(err)
+	}
+	if *keep != 0 {
+		d.keep = *keep
+	}
+	sets, err := loadCommandSets(m.setDirs())
+	if err != nil {
+		return configError(err)
+	}
+	known := func(name string) bool {
+		return slices.ContainsFunc(sets, func(s namedCommandSet) bool { return s.Name == name })
+	}
+	opts := globalCfg.apply(m.opts)
+
+	var (
+		garbage []string
+		errs    []error
+	)
+	if d.enabled() {
+		names, err := readDirNames(filepath.Join(d.root, "pkgs"))
+		errs = append(errs, err)
+		for _, name := range names {
+			if !known(name) {
+				m.logger().Warn("gc: versions of an unknown set: uninstall it before removing the set", "set", name, "dir", d.pkgDir(name))
+				continue
+			}
+			current, err := d.linked(name)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			dirs, err := d.superseded(name, current)
+			garbage, errs = append(garbage, dirs...), append(errs, err)
+		}
+		tmps, err := leftoverTemps(d.binDir())
+		garbage, errs = append(garbage, tmps...), append(errs, err)
+	}
+
+	receipts, err := readDirNames(filepath.Join(m.cfgDir, receiptsDirName))
+	errs = append(errs, err)
+	for _, f := range receipts {
+		if name, ok := strings.CutSuffix(f, ".json"); ok && !known(name) {
+			m.logger().Warn("gc: receipt of an unknown set: uninstall it before removing the set", "set", name)
+		}
+	}
+
+	if cacheDir, err := latestCacheDir(); err == nil {
+		files, err := readDirNames(cacheDir)
+		errs = append(errs, err)
+		for _, f := range files {
+			name, ok := strings.CutSuffix(f, ".json")
+			if !ok {
+				continue
+			}
+			if _, fresh := loadLatestCache(name, opts.CacheTTL); !fresh {
+				garbage = append(garbage, filepath.Join(cacheDir, f))
+			}
+		}
+		tmps, err := leftoverTemps(cacheDir)
+		garbage, errs = append(garbage, tmps...), append(errs, err)
+	}
+
+	tmps, err := leftoverTemps(m.cfgDir)
+	garbage, errs = append(garbage, tmps...), append(errs, err)
+	trashes, err := filepath.Glob(filepath.Join(m.cfgDir, ".remove-*"))
+	garbage, errs = append(garbage, trashes...), append(errs, err)
+	artifacts, err := filepath.Glob(filepath.Join(os.TempDir(), tempPrefix(m.cfgDir)+"*"))
+	errs = append(errs, err)
+	for _, p := range artifacts {
+		info, err := os.Lstat(p)
+		if err == nil && info.IsDir() && ownedByUser(info) && time.Since(info.ModTime()) >= gcTempAge {
+			garbage = append(garbage, p)
+		}
+	}
+
+	var freed int64
+	for _, p := range garbage {
+		size := diskUsage(p)
+		if m.opts.DryRun {
+			fmt.Printf("would remove %s\n", p)
+			freed += size
+			continue
+		}
+		if err := os.RemoveAll(p); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		fmt.Printf("removed %s\n", p)
+		freed += size
+	}
+	m.logger().Info("gc: done", "removed", len(garbage), "freed", formatBytes(freed), "dry_run", m.opts.DryRun)
+	return errors.Join(errs...)
+}
+
+// readDirNames returns no names for a missing dir.
+func readDirNames(dir string) ([]string, error) {
+	dirents, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	names := make([]string, len(dirents))
+	for i, dirent := range dirents {
+		names[i] = dirent.Name()
+	}
+	return names, nil
+}
+
+func leftoverTemps(dir string) ([]string, error) {
+	dirents, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var temps []string
+	for _, dirent := range dirents {
+		name := dirent.Name()
+		if strings.HasPrefix(name, ".") && strings.HasSuffix(name, ".tmp") && !dirent.IsDir() {
+			temps = append(temps, filepath.Join(dir, name))
+		}
+	}
+	return temps, nil
+}
+
+// diskUsage does not follow links.
+func diskUsage(p string) int64 {
+	var size int64
+	_ = filepath.WalkDir(p, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
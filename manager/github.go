@@ -0,0 +1,335 @@
+package manager
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// githubBackend implements checklatest, install and update with GitHub Releases.
+//
+//	{"github": {"repo": "owner/name", "asset": "tool_${VER}_${OS}_${ARCH}.tar.gz"}}
+type githubBackend struct {
+	Repo string `json:"repo"`
+	// .tar.gz, .tgz and .zip assets are extracted, anything else is the binary itself.
+	Asset     string  `json:"asset"`
+	TagPrefix *string `json:"tag_prefix,omitzero"`
+	// Binaries default to the name part of Repo.
+	Binaries []string `json:"binaries,omitzero"`
+	// BinDir defaults to the managed dir if enabled, bin_dir of config.json, then "~/.local/bin".
+	BinDir string `json:"bin_dir,omitzero"`
+}
+
+var githubAPI = "https://api.github.com"
+
+func (g *githubBackend) Commands() []command {
+	return []command{commandChecklatest, commandInstall, commandUpdate}
+}
+
+func (g *githubBackend) Validate() error {
+	owner, name, ok := strings.Cut(g.Repo, "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return fmt.Errorf("github: repo must be \"owner/name\" but is %q", g.Repo)
+	}
+	if g.Asset == "" {
+		return fmt.Errorf("github: asset must be specified")
+	}
+	return nil
+}
+
+func (g *githubBackend) tagPrefix() string {
+	if g.TagPrefix != nil {
+		return *g.TagPrefix
+	}
+	return "v"
+}
+
+func (g *githubBackend) binaries() []string {
+	if len(g.Binaries) > 0 {
+		return g.Binaries
+	}
+	return []string{path.Base(g.Repo)}
+}
+
+func (g *githubBackend) Exec(ctx context.Context, e commandExecutor, kind command, ver string) (string, error) {
+	c := githubClient{token: e.githubToken, log: e.log}
+	switch kind {
+	case commandChecklatest:
+		return g.latest(ctx, c)
+	case commandInstall, commandUpdate:
+		if ver == "" {
+			latest, err := g.latest(ctx, c)
+			if err != nil {
+				return "", err
+			}
+			ver = latest
+		}
+		return "", g.install(ctx, e, ver)
+	}
+	return "", fmt.Errorf("github: %s is not supported", kind)
+}
+
+// githubMaxRateLimitWait is the longest a rate limit is waited for. Longer limits fail immediately.
+var githubMaxRateLimitWait = time.Minute
+
+type githubClient struct {
+	// token nil means none.
+	token func() (string, error)
+	log   *slog.Logger
+}
+
+// get sends the token only to the GitHub API and retries rate limited requests resetting soon enough.
+func (c githubClient) get(ctx context.Context, url, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("User-Agent", "ngpkgmgr/"+version)
+	if err := c.authorize(req); err != nil {
+		return nil, err
+	}
+	for attempt := 0; ; attempt++ {
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusOK {
+			return resp, nil
+		}
+		_ = resp.Body.Close()
+		wait, limited := githubRateLimit(resp, time.Now())
+		if !limited {
+			return nil, fmt.Errorf("github: GET %s: %s", url, resp.Status)
+		}
+		if wait > githubMaxRateLimitWait || attempt >= 2 {
+			return nil, githubRateLimitError(req, wait)
+		}
+		if c.log != nil {
+			c.log.Warn("github: rate limited, waiting", "wait", wait.Round(time.Second), "url", url)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (c githubClient) authorize(req *http.Request) error {
+	if c.token == nil || !strings.HasPrefix(req.URL.String(), githubAPI) || req.Header.Get("Authorization") != "" {
+		return nil
+	}
+	token, err := c.token()
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return nil
+}
+
+// githubRateLimit reports whether resp is rate limited and how long to wait, by Retry-After, then X-RateLimit-Reset.
+func githubRateLimit(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return time.Duration(s) * time.Second, true
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return 0, false
+	}
+	reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return 0, resp.StatusCode == http.StatusTooManyRequests
+	}
+	// one more second for clock skew.
+	return max(time.Unix(reset, 0).Sub(now)+time.Second, 0), true
+}
+
+func githubRateLimitError(req *http.Request, wait time.Duration) error {
+	msg := fmt.Sprintf("github: GET %s: rate limit exceeded; resets in %s", req.URL, wait.Round(time.Second))
+	if req.Header.Get("Authorization") == "" {
+		msg += "; set GITHUB_TOKEN, github_token or github_token_command in config.json to raise the limit"
+	}
+	return errors.New(msg)
+}
+
+func (g *githubBackend) latest(ctx context.Context, c githubClient) (string, error) {
+	resp, err := c.get(ctx, githubAPI+"/repos/"+g.Repo+"/releases/latest", "application/vnd.github+json")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return "", fmt.Errorf("github: decoding release: %w", err)
+	}
+	if release.TagName == "" {
+		return "", fmt.Errorf("github: latest release of %s has no tag", g.Repo)
+	}
+	return strings.TrimPrefix(release.TagName, g.tagPrefix()), nil
+}
+
+// versions lists the latest 100 releases, newest first, excluding drafts and pre-releases.
+func (g *githubBackend) versions(ctx context.Context, c githubClient) ([]string, error) {
+	resp, err := c.get(ctx, githubAPI+"/repos/"+g.Repo+"/releases?per_page=100", "application/vnd.github+json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var releases []struct {
+		TagName    string `json:"tag_name"`
+		Draft      bool   `json:"draft"`
+		Prerelease bool   `json:"prerelease"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return nil, fmt.Errorf("github: decoding releases: %w", err)
+	}
+	var vers []string
+	for _, r := range releases {
+		if r.TagName != "" && !r.Draft && !r.Prerelease {
+			vers = append(vers, strings.TrimPrefix(r.TagName, g.tagPrefix()))
+		}
+	}
+	return vers, nil
+}
+
+func (g *githubBackend) install(ctx context.Context, e commandExecutor, ver string) error {
+	dict := e.dict(ver)
+	asset := dict.Expand(g.Asset)
+	managed := g.BinDir == "" && e.managed.enabled()
+	var (
+		binDir string
+		err    error
+	)
+	if managed {
+		binDir, err = e.managed.versionDir(e.commandSet.Name, ver)
+	} else {
+		binDir, err = expandHome(dict.Expand(cmp.Or(g.BinDir, e.binDir, "~/.local/bin")))
+	}
+	if err != nil {
+		return err
+	}
+
+	data, err := g.download(ctx, e.cfgDir, newDownloader(e.stderr), e.stdout, dict, ver, asset, e.commandSet.Set.Checksum, e.commandSet.Set.Signature)
+	if err != nil {
+		return err
+	}
+
+	files, err := pickBinaries(asset, data, g.binaries())
+	if err != nil {
+		return fmt.Errorf("github: %s: %w", asset, err)
+	}
+
+	if err := os.MkdirAll(binDir, 0o755); err != nil {
+		return err
+	}
+	var paths []string
+	for _, name := range g.binaries() {
+		dst := filepath.Join(binDir, name)
+		if err := writeFileAtomic(dst, files[name], 0o755); err != nil {
+			return err
+		}
+		fmt.Fprintf(e.stdout, "placed %s\n", dst)
+		paths = append(paths, dst)
+	}
+	if managed {
+		entries, err := e.managed.link(e.commandSet.Name, ver, g.binaries())
+		if err != nil {
+			return err
+		}
+		if err := e.managed.prune(e.commandSet.Name, ver); err != nil {
+			return err
+		}
+		paths = e.managed.paths(e.commandSet.Name, entries)
+	}
+	return e.recordInstall(g, ver, paths)
+}
+
+// download downloads asset into a temp dir named after cfgDir for gc, and verifies it.
+func (g *githubBackend) download(
+	ctx context.Context,
+	cfgDir string,
+	dl downloader,
+	w io.Writer,
+	dict dictReplacer,
+	ver string,
+	asset string,
+	checksum *checksumConfig,
+	signature *signatureConfig,
+) ([]byte, error) {
+	url := fmt.Sprintf("https://github.com/%s/releases/download/%s%s/%s", g.Repo, g.tagPrefix(), ver, asset)
+	fmt.Fprintf(w, "downloading %s\n", url)
+	dir, err := os.MkdirTemp("", tempPrefix(cfgDir)+path.Base(g.Repo)+"-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	dst := filepath.Join(dir, path.Base(asset))
+	if err := dl.Download(ctx, url, dst); err != nil {
+		return nil, fmt.Errorf("github: %w", err)
+	}
+	data, err := os.ReadFile(dst)
+	if err != nil {
+		return nil, err
+	}
+
+	if checksum != nil {
+		if err := checksum.Verify(ctx, dict, url, data); err != nil {
+			return nil, err
+		}
+	}
+	if signature != nil {
+		if err := signature.Verify(ctx, dict, url, dst); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// pickBinaries returns contents of files in the asset whose base names are in names.
+func pickBinaries(asset string, data []byte, names []string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	if format := archiveFormat(asset); format == "raw" {
+		if len(names) != 1 {
+			return nil, fmt.Errorf("a raw binary asset can only be placed as exactly 1 binary")
+		}
+		files[names[0]] = data
+	} else {
+		err := walkArchive(format, bytes.NewReader(data), int64(len(data)), func(e archiveEntry, r io.Reader) error {
+			if !e.Mode.IsRegular() || !slices.Contains(names, path.Base(e.Name)) {
+				return nil
+			}
+			var err error
+			files[path.Base(e.Name)], err = io.ReadAll(r)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range names {
+		if _, ok := files[name]; !ok {
+			return nil, fmt.Errorf("%q not found in the asset", name)
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,148 @@
+package manager
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	globalConfigFileName = "config.json"
+	// globalConfigName may also have .toml or .yaml.
+	globalConfigName = "config"
+)
+
+// globalConfig is read from config.json under the config dir. Fields named after flags are their defaults,
+// applying only where the flag is left at its zero value.
+type globalConfig struct {
+	Hooks    hooksConfig `json:"hooks,omitzero"`
+	Parallel int         `json:"parallel,omitzero"`
+	Verbose  bool        `json:"verbose,omitzero"`
+	Timeout  duration    `json:"timeout,omitzero"`
+	Retries  int         `json:"retries,omitzero"`
+	CacheTTL duration    `json:"cache_ttl,omitzero"`
+	Color    string      `json:"color,omitzero"`
+	Shell    []string    `json:"shell,omitzero"`
+	// GITHUB_TOKEN and GH_TOKEN take precedence over GitHubToken.
+	GitHubToken string `json:"github_token,omitzero"`
+	// GitHubTokenCommand runs only if no other token is set and a GitHub request is about to be sent.
+	GitHubTokenCommand []string `json:"github_token_command,omitzero"`
+	BinDir             string   `json:"bin_dir,omitzero"`
+	// Managed takes precedence over BinDir.
+	Managed        *managedConfig     `json:"managed,omitzero"`
+	Notify         *notifyConfig      `json:"notify,omitzero"`
+	Tracing        *tracingConfig     `json:"tracing,omitzero"`
+	NonInteractive bool               `json:"non_interactive,omitzero"`
+	LogLevel       string             `json:"log_level,omitzero"`
+	LogFormat      string             `json:"log_format,omitzero"`
+	LogToFile      bool               `json:"log_to_file,omitzero"`
+	Profiles       map[string]profile `json:"profiles,omitzero"`
+	// FuzzyTargets makes a target naming no set select the only set it is a prefix or subsequence of, e.g. "rg" for "ripgrep".
+	FuzzyTargets bool `json:"fuzzy_targets,omitzero"`
+}
+
+func (c globalConfig) Validate() error {
+	switch {
+	case c.Parallel < 0:
+		return fmt.Errorf("parallel: must not be negative")
+	case c.Retries < 0:
+		return fmt.Errorf("retries: must not be negative")
+	case c.Timeout < 0:
+		return fmt.Errorf("timeout: must not be negative")
+	case c.CacheTTL < 0:
+		return fmt.Errorf("cache_ttl: must not be negative")
+	}
+	if err := c.Notify.Validate(); err != nil {
+		return err
+	}
+	if err := c.Tracing.Validate(); err != nil {
+		return err
+	}
+	if _, err := c.Managed.dir(); err != nil {
+		return err
+	}
+	if _, err := parseLogLevel(c.LogLevel); err != nil {
+		return fmt.Errorf("log_level: %w", err)
+	}
+	if err := logFormat(c.LogFormat).Validate(); err != nil {
+		return fmt.Errorf("log_format: %w", err)
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.Profiles)) {
+		if err := c.Profiles[name].Validate(); err != nil {
+			return fmt.Errorf("profiles.%s: %w", name, err)
+		}
+	}
+	if !validColor(c.Color) {
+		return fmt.Errorf("color: must be auto, always or never, got %q", c.Color)
+	}
+	return nil
+}
+
+func (c globalConfig) apply(opts Options) Options {
+	opts.Parallel = cmp.Or(opts.Parallel, c.Parallel)
+	opts.Verbose = opts.VeryVerbose || (opts.Verbose || c.Verbose) && !opts.Quiet
+	opts.Timeout = cmp.Or(opts.Timeout, time.Duration(c.Timeout))
+	opts.Retries = cmp.Or(opts.Retries, c.Retries)
+	opts.CacheTTL = cmp.Or(opts.CacheTTL, time.Duration(c.CacheTTL))
+	// -q and -vv take precedence over log_level, but not over -log-level.
+	switch {
+	case opts.LogLevel != "":
+	case opts.Quiet:
+		opts.LogLevel = "error"
+	case opts.VeryVerbose:
+		opts.LogLevel = "debug"
+	default:
+		opts.LogLevel = c.LogLevel
+	}
+	opts.LogFormat = cmp.Or(opts.LogFormat, c.LogFormat)
+	opts.LogToFile = opts.LogToFile || c.LogToFile
+	opts.NonInteractive = opts.NonInteractive || c.NonInteractive
+	opts.Color = cmp.Or(opts.Color, c.Color)
+	return opts
+}
+
+// githubToken returns a func returning the first token set. GitHubTokenCommand runs at most once, when first needed.
+func (c globalConfig) githubToken() func() (string, error) {
+	return sync.OnceValues(func() (string, error) {
+		if t := cmp.Or(os.Getenv("GITHUB_TOKEN"), os.Getenv("GH_TOKEN"), c.GitHubToken); t != "" || len(c.GitHubTokenCommand) == 0 {
+			return t, nil
+		}
+		out, err := exec.Command(c.GitHubTokenCommand[0], c.GitHubTokenCommand[1:]...).Output()
+		if err != nil {
+			return "", fmt.Errorf("github_token_command: %w", err)
+		}
+		return strings.TrimSpace(string(out)), nil
+	})
+}
+
+func loadGlobalConfig(cfgDir string) (globalConfig, error) {
+	path, err := findSetFile(cfgDir, globalConfigName)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return globalConfig{}, nil
+		}
+		return globalConfig{}, err
+	}
+	data, err := readAsJSON(path)
+	if err != nil {
+		return globalConfig{}, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	var cfg globalConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return globalConfig{}, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return globalConfig{}, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,208 @@
+package manager
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode"
+)
+
+// goInstallBackend is a package path with a version query, installed with the local Go toolchain.
+//
+//	{"goinstall": "golang.org/x/tools/gopls@${VER}"}
+type goInstallBackend string
+
+func (g *goInstallBackend) Commands() []command {
+	return []command{commandVer, commandChecklatest, commandInstall, commandUpdate, commandUninstall}
+}
+
+func (g *goInstallBackend) Validate() error {
+	pkg, query, ok := strings.Cut(string(*g), "@")
+	if !ok || pkg == "" || query == "" {
+		return fmt.Errorf("goinstall: must be \"<package>@<version>\", e.g. \"example.com/tool@${VER}\", but is %q", *g)
+	}
+	return nil
+}
+
+func (g *goInstallBackend) pkg() string {
+	pkg, _, _ := strings.Cut(string(*g), "@")
+	return pkg
+}
+
+// binary is the last element of the package path unless it is a major version suffix like "v2".
+func (g *goInstallBackend) binary() string {
+	elem := path.Base(g.pkg())
+	if len(elem) > 1 && elem[0] == 'v' && strings.IndexFunc(elem[1:], func(r rune) bool { return !unicode.IsDigit(r) }) < 0 {
+		elem = path.Base(path.Dir(g.pkg()))
+	}
+	if runtime.GOOS == "windows" {
+		elem += ".exe"
+	}
+	return elem
+}
+
+func (g *goInstallBackend) Exec(ctx context.Context, e commandExecutor, kind command, ver string) (string, error) {
+	switch kind {
+	case commandVer:
+		return g.ver(ctx, e)
+	case commandChecklatest:
+		return g.latest(ctx)
+	case commandInstall, commandUpdate:
+		dict := e.dict(ver)
+		if ver == "" {
+			dict["${VER}"] = "latest"
+		}
+		cmd := e.command(ctx, []string{"go", "install", dict.Expand(string(*g))}, dict)
+		cmd.Stdout = e.stdout
+		if !e.managed.enabled() {
+			if err := runCmd(cmd); err != nil {
+				return "", err
+			}
+			bin, err := g.binPath(ctx, e)
+			if err != nil {
+				return "", err
+			}
+			return "", e.recordInstall(g, dict["${VER}"], []string{bin})
+		}
+		dir, err := e.managed.versionDir(e.commandSet.Name, dict["${VER}"])
+		if err != nil {
+			return "", err
+		}
+		cmd.Env = append(cmd.Env, "GOBIN="+dir)
+		if err := runCmd(cmd); err != nil {
+			return "", err
+		}
+		entries, err := e.managed.link(e.commandSet.Name, dict["${VER}"], []string{g.binary()})
+		if err != nil {
+			return "", err
+		}
+		if err := e.managed.prune(e.commandSet.Name, dict["${VER}"]); err != nil {
+			return "", err
+		}
+		return "", e.recordInstall(g, dict["${VER}"], e.managed.paths(e.commandSet.Name, entries))
+	case commandUninstall:
+		if e.managed.enabled() {
+			return "", e.managed.remove(e.commandSet.Name)
+		}
+		bin, err := g.binPath(ctx, e)
+		if err != nil {
+			return "", err
+		}
+		return "", os.Remove(bin)
+	}
+	return "", fmt.Errorf("goinstall: %s is not supported", kind)
+}
+
+// binPath returns GOBIN, or bin under the first GOPATH, or the binary in the managed dir, joined with the binary.
+func (g *goInstallBackend) binPath(ctx context.Context, e commandExecutor) (string, error) {
+	if e.managed.enabled() {
+		return e.managed.binaryPath(e.commandSet.Name, g.binary())
+	}
+	cmd := e.command(ctx, []string{"go", "env", "GOBIN", "GOPATH"}, e.dict(""))
+	var buf bytes.Buffer
+	cmd.Stdout = &buf
+	if err := runCmd(cmd); err != nil {
+		return "", fmt.Errorf("goinstall: go env: %w", err)
+	}
+	out := buf.Bytes()
+	lines := strings.Split(string(out), "\n")
+	dir := strings.TrimSpace(lines[0])
+	if dir == "" && len(lines) > 1 {
+		dir = filepath.Join(filepath.SplitList(strings.TrimSpace(lines[1]))[0], "bin")
+	}
+	return filepath.Join(dir, g.binary()), nil
+}
+
+func (g *goInstallBackend) ver(ctx context.Context, e commandExecutor) (string, error) {
+	bin, err := g.binPath(ctx, e)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(bin); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("goinstall: %s is not installed", bin)
+		}
+		return "", err
+	}
+	cmd := e.command(ctx, []string{"go", "version", "-m", bin}, e.dict(""))
+	var buf bytes.Buffer
+	cmd.Stdout = &buf
+	if err := runCmd(cmd); err != nil {
+		return "", fmt.Errorf("goinstall: go version -m: %w", err)
+	}
+	out := buf.Bytes()
+	// "\tmod\t<module>\t<version>\t<sum>"
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) >= 3 && fields[0] == "mod" {
+			return fields[2], nil
+		}
+	}
+	return "", fmt.Errorf("goinstall: no module version in %s", bin)
+}
+
+// latest trims elements of the package path until the module proxy knows it as a module.
+func (g *goInstallBackend) latest(ctx context.Context) (string, error) {
+	proxy := goProxy()
+	for mod := g.pkg(); mod != "." && mod != "/"; mod = path.Dir(mod) {
+		url := strings.TrimSuffix(proxy, "/") + "/" + escapeModulePath(mod) + "/@latest"
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return "", err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return "", err
+		}
+		if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+			_ = resp.Body.Close()
+			continue
+		}
+		var info struct {
+			Version string
+		}
+		err = json.NewDecoder(resp.Body).Decode(&info)
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("goinstall: GET %s: %s", url, resp.Status)
+		}
+		if err != nil {
+			return "", fmt.Errorf("goinstall: GET %s: %w", url, err)
+		}
+		return info.Version, nil
+	}
+	return "", fmt.Errorf("goinstall: no module provides %s", g.pkg())
+}
+
+func goProxy() string {
+	for _, p := range strings.FieldsFunc(os.Getenv("GOPROXY"), func(r rune) bool { return r == ',' || r == '|' }) {
+		if strings.HasPrefix(p, "https://") || strings.HasPrefix(p, "http://") {
+			return p
+		}
+	}
+	return "https://proxy.golang.org"
+}
+
+// escapeModulePath escapes upper case letters as the module proxy protocol requires, e.g. "Azure" to "!azure".
+func escapeModulePath(p string) string {
+	var b strings.Builder
+	for _, r := range p {
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,163 @@
+package manager
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	historyFileName = ".history.jsonl"
+)
+
+type historyEntry struct {
+	Time       time.Time `json:"time"`
+	Name       string    `json:"name"`
+	Command    command   `json:"command"`
+	Action     action    `json:"action"`
+	From       string    `json:"from,omitzero"`
+	To         string    `json:"to,omitzero"`
+	ExitCode   *int      `json:"exit_code,omitzero"`
+	Error      string    `json:"error,omitzero"`
+	Duration   *duration `json:"duration,omitzero"`
+	RolledBack *bool     `json:"rolled_back,omitzero"`
+}
+
+func historyEntries(report *runReport, now time.Time) []historyEntry {
+	var entries []historyEntry
+	for _, res := range report.Results {
+		switch res.Action {
+		case actionInstalled, actionUpdated, actionUninstalled, actionFailed:
+		default:
+			continue
+		}
+		e := historyEntry{
+			Time:       now,
+			Name:       res.Name,
+			Command:    command(report.Command),
+			Action:     res.Action,
+			From:       res.Current,
+			To:         res.Target,
+			ExitCode:   res.exitCode,
+			Error:      res.Error,
+			Duration:   res.Duration,
+			RolledBack: res.RolledBack,
+		}
+		if e.Command == commandUninstall {
+			e.To = ""
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func appendHistory(cfgDir string, entries []historyEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	_, err := appendFile(filepath.Join(cfgDir, historyFileName)).Write(buf.Bytes())
+	return err
+}
+
+func loadHistory(cfgDir string) ([]historyEntry, error) {
+	f, err := os.Open(filepath.Join(cfgDir, historyFileName))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []historyEntry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 1<<20)
+	for line := 1; sc.Scan(); line++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		var e historyEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", historyFileName, line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, sc.Err()
+}
+
+// history prints what install, update and uninstall did, oldest first.
+//
+//	history [--limit <n>] [<name>]
+func (m *Manager) history(_ context.Context, args []string) error {
+	fset := flag.NewFlagSet("history", flag.ContinueOnError)
+	limit := fset.Int("limit", 0, "prints only the last n entries. 0 means all")
+	if err := fset.Parse(args); err != nil {
+		return configError(err)
+	}
+	if fset.NArg() > 1 {
+		return configError(fmt.Errorf("history: wrong args length: want 0 or 1, got %d", fset.NArg()))
+	}
+	format := outputFormat(m.opts.Output)
+	if err := format.Validate(); err != nil {
+		return configError(err)
+	}
+
+	all, err := loadHistory(m.cfgDir)
+	if err != nil {
+		return err
+	}
+	entries := make([]historyEntry, 0, len(all))
+	for _, e := range all {
+		if fset.NArg() == 0 || e.Name == fset.Arg(0) {
+			entries = append(entries, e)
+		}
+	}
+	if *limit > 0 && len(entries) > *limit {
+		entries = entries[len(entries)-*limit:]
+	}
+
+	if format == outputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "    ")
+		return enc.Encode(entries)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tNAME\tCOMMAND\tACTION\tFROM\tTO\tEXIT\tDURATION\tERROR")
+	for _, e := range entries {
+		var exit, dur string
+		if e.ExitCode != nil {
+			exit = strconv.Itoa(*e.ExitCode)
+		}
+		if e.Duration != nil {
+			dur = time.Duration(*e.Duration).Round(time.Millisecond).String()
+		}
+		action := string(e.Action)
+		if e.RolledBack != nil && *e.RolledBack {
+			action += " (rolled back)"
+		}
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.Time.Local().Format(time.DateTime), e.Name, e.Command, action, e.From, e.To, exit, dur, e.Error,
+		)
+	}
+	return w.Flush()
+}
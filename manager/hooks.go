@@ -0,0 +1,65 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// hooksConfig hooks are args of a single command, expanded with the same placeholders as commands.
+type hooksConfig struct {
+	PreInstall  []string `json:"pre_install,omitzero"`
+	PostInstall []string `json:"post_install,omitzero"`
+	PreUpdate   []string `json:"pre_update,omitzero"`
+	PostUpdate  []string `json:"post_update,omitzero"`
+}
+
+type hookTiming string
+
+const (
+	hookPre  hookTiming = "pre"
+	hookPost hookTiming = "post"
+)
+
+func (h *hooksConfig) Select(timing hookTiming, kind command) []string {
+	if h == nil {
+		return nil
+	}
+	switch {
+	case timing == hookPre && kind == commandInstall:
+		return h.PreInstall
+	case timing == hookPost && kind == commandInstall:
+		return h.PostInstall
+	case timing == hookPre && kind == commandUpdate:
+		return h.PreUpdate
+	case timing == hookPost && kind == commandUpdate:
+		return h.PostUpdate
+	}
+	return nil
+}
+
+// runHooks runs the global hook and then the set's hook. Hooks are never retried.
+func (e commandExecutor) runHooks(ctx context.Context, timing hookTiming, kind command, ver string) error {
+	hooks := [][]string{e.globalHooks.Select(timing, kind), e.commandSet.Set.Hooks.Select(timing, kind)}
+	if timing == hookPost {
+		// post hooks unwind in reverse order so that the global one runs last.
+		slices.Reverse(hooks)
+	}
+	for _, args := range hooks {
+		if len(args) == 0 {
+			continue
+		}
+		if timeout := e.timeoutOf(kind); timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		dict := e.dict(ver)
+		cmd := e.command(ctx, slices.Collect(dict.Map(slices.Values(args))), dict)
+		cmd.Stdout = e.stdout
+		if err := runCmd(cmd); err != nil {
+			return fmt.Errorf("%s_%s hook: %w", timing, kind, err)
+		}
+	}
+	return nil
+}
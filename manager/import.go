@@ -0,0 +1,226 @@
+package manager
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// asdfPlugins are plugins import knows a declarative backend of. Others delegate to asdf; see asdfSet.
+var asdfPlugins = map[string]func() commandSet{
+	"fzf": func() commandSet {
+		return commandSet{
+			Ver:     []string{"fzf", "--version"},
+			Extract: map[command]extractor{commandVer: {Regex: `\d+\.\d+\.\d+`}},
+			GitHub:  &githubBackend{Repo: "junegunn/fzf", Asset: "fzf-${VER}-${OS}_${ARCH}.tar.gz"},
+		}
+	},
+	"jq": func() commandSet {
+		prefix := "jq-"
+		return commandSet{
+			Ver:     []string{"jq", "--version"},
+			Extract: map[command]extractor{commandVer: {Regex: `\d+\.\d+(?:\.\d+)?`}},
+			OSMap:   map[string]string{"darwin": "macos"},
+			GitHub:  &githubBackend{Repo: "jqlang/jq", Asset: "jq-${OS}-${ARCH}", TagPrefix: &prefix},
+		}
+	},
+	"pnpm":       func() commandSet { b := npmBackend("pnpm"); return commandSet{Npm: &b} },
+	"yarn":       func() commandSet { b := npmBackend("yarn"); return commandSet{Npm: &b} },
+	"poetry":     func() commandSet { b := pipxBackend("poetry"); return commandSet{Pipx: &b} },
+	"pipenv":     func() commandSet { b := pipxBackend("pipenv"); return commandSet{Pipx: &b} },
+	"pre-commit": func() commandSet { b := pipxBackend("pre-commit"); return commandSet{Pipx: &b} },
+	"awscli":     func() commandSet { b := pipxBackend("awscli"); return commandSet{Pipx: &b} },
+}
+
+func asdfSet(plugin string) commandSet {
+	install := []string{"asdf", "install", plugin, "${VER}"}
+	return commandSet{
+		Ver:         []string{"asdf", "current", plugin},
+		CheckLatest: []string{"asdf", "latest", plugin},
+		Install:     install,
+		Update:      install,
+		Versions:    []string{"asdf", "list", "all", plugin},
+		// "<plugin> <version> <source>", after a header line since asdf 0.16.
+		Extract: map[command]extractor{commandVer: {Regex: `\s(\d\S*)`}},
+	}
+}
+
+type importedSet struct {
+	name string
+	set  commandSet
+	pin  string
+}
+
+// importToolVersions pins sets to versions of the file, except "system", "latest", "ref:" and "path:".
+func importToolVersions(data []byte) []importedSet {
+	vers := parseToolVersions(data)
+	var sets []importedSet
+	for _, tool := range slices.Sorted(maps.Keys(vers)) {
+		s := importedSet{name: tool, pin: vers[tool]}
+		if fn, ok := asdfPlugins[tool]; ok {
+			s.set = fn()
+		} else {
+			s.set = asdfSet(tool)
+		}
+		if s.pin == "system" || s.pin == "latest" || strings.ContainsRune(s.pin, ':') {
+			s.pin = ""
+		}
+		sets = append(sets, s)
+	}
+	return sets
+}
+
+var brewfileLineRe = regexp.MustCompile(`^(\w+)\s+["']([^"']+)["']`)
+
+// importBrewfile names formulae of taps, "<user>/<tap>/<name>", by their last element. Other entries, e.g. taps, are skipped.
+func importBrewfile(data []byte) (sets []importedSet, skipped []string) {
+	for line := range strings.Lines(string(data)) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		m := brewfileLineRe.FindStringSubmatch(line)
+		if m == nil {
+			skipped = append(skipped, line)
+			continue
+		}
+		kind, name := m[1], m[2]
+		switch kind {
+		case "brew":
+			b := brewBackend(name)
+			sets = append(sets, importedSet{name: path.Base(name), set: commandSet{Brew: &b}})
+		case "cask":
+			sets = append(sets, importedSet{name: path.Base(name), set: caskSet(name)})
+		default:
+			skipped = append(skipped, line)
+		}
+	}
+	return sets, skipped
+}
+
+// caskSet handles a cask, which the brew backend does not.
+func caskSet(cask string) commandSet {
+	return commandSet{
+		// "<cask> <version>"
+		Ver:         []string{"brew", "list", "--cask", "--versions", cask},
+		CheckLatest: []string{"brew", "info", "--cask", "--json=v2", cask},
+		Install:     []string{"brew", "install", "--cask", cask},
+		Update:      []string{"brew", "upgrade", "--cask", cask},
+		Uninstall:   []string{"brew", "uninstall", "--cask", cask},
+		Extract: map[command]extractor{
+			commandVer:         {Regex: `^\S+\s+(\S+)`},
+			commandChecklatest: {JSONPath: "casks.0.version"},
+		},
+	}
+}
+
+// importSets creates sets from a .tool-versions file or a Brewfile, pinning them, existing ones included.
+//
+//	import [--format <tool-versions|brewfile>] <file>
+func (m *Manager) importSets(_ context.Context, args []string) error {
+	fset := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := fset.String("format", "", "file format: tool-versions or brewfile. defaults to the one of the file name")
+	if err := fset.Parse(args); err != nil {
+		return configError(err)
+	}
+	if fset.NArg() != 1 {
+		return configError(fmt.Errorf("import: wrong args length: want 1, got %d", fset.NArg()))
+	}
+	file := fset.Arg(0)
+	if *format == "" {
+		switch base := filepath.Base(file); {
+		case base == toolVersionsFileName:
+			*format = "tool-versions"
+		case strings.HasPrefix(strings.TrimPrefix(base, "."), "Brewfile"):
+			*format = "brewfile"
+		default:
+			return configError(fmt.Errorf("import: unknown format of %q: use --format", file))
+		}
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return configError(err)
+	}
+	log := m.logger()
+	var sets []importedSet
+	switch *format {
+	case "tool-versions":
+		sets = importToolVersions(data)
+	case "brewfile":
+		var skipped []string
+		sets, skipped = importBrewfile(data)
+		for _, line := range skipped {
+			log.Warn("import: skipping: not a formula or cask", "line", line)
+		}
+	default:
+		return configError(fmt.Errorf("import: unknown --format %q: want tool-versions or brewfile", *format))
+	}
+
+	pinned, err := loadPinnedVersions(m.cfgDir)
+	if err != nil {
+		return configError(err)
+	}
+	var created, pins int
+	for _, s := range sets {
+		if err := validateSetName(s.name); err != nil {
+			log.Warn("import: skipping", "err", err)
+			continue
+		}
+		exists, err := commandSetExists(m.setDir(s.name), s.name)
+		if err != nil {
+			return err
+		}
+		p := filepath.Join(m.cfgDir, s.name+".json")
+		backend := cmp.Or(backendName(s.set.backend()), "args")
+		switch {
+		case exists:
+			log.Info("import: already exists", "set", s.name)
+		case m.opts.DryRun:
+			fmt.Printf("[dry-run] would create %s (%s)\n", p, backend)
+		default:
+			data, err := json.MarshalIndent(s.set, "", "    ")
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(m.cfgDir, 0o755); err != nil {
+				return err
+			}
+			if err := createFileAtomic(p, append(data, '\n'), 0o644); err != nil && !errors.Is(err, fs.ErrExist) {
+				return err
+			}
+			log.Info("import: created", "set", s.name, "backend", backend)
+			created++
+		}
+		if s.pin == "" || pinned[s.name] == s.pin {
+			continue
+		}
+		if err := validatePin(s.name, s.pin); err != nil {
+			log.Warn("import: not pinning", "err", err)
+			continue
+		}
+		if m.opts.DryRun {
+			fmt.Printf("[dry-run] would pin %s to %s\n", s.name, s.pin)
+			continue
+		}
+		pinned[s.name] = s.pin
+		pins++
+	}
+	if pins > 0 {
+		if err := storePinnedVersions(m.cfgDir, pinned); err != nil {
+			return err
+		}
+	}
+	log.Info("import: done", "file", file, "created", created, "pinned", pins)
+	return nil
+}
@@ -0,0 +1,56 @@
+package manager
+
+// stripJSONC blanks out comments outside strings and drops trailing commas.
+// Newlines are kept so that positions in decoding errors still point to the right line.
+func stripJSONC(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	// comma is the index in out of a comma which may be trailing, or -1.
+	comma := -1
+	// value is whether the last token is a value, which only a trailing comma may follow.
+	value := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c == '"':
+			start := i
+			for i++; i < len(data) && data[i] != '"'; i++ {
+				if data[i] == '\\' {
+					i++
+				}
+			}
+			out = append(out, data[start:min(i+1, len(data))]...)
+			comma, value = -1, true
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			i--
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			i += 2
+			for i < len(data) && !(data[i] == '*' && i+1 < len(data) && data[i+1] == '/') {
+				if data[i] == '\n' {
+					out = append(out, '\n')
+				}
+				i++
+			}
+			i++
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			out = append(out, c)
+		case c == ',':
+			comma = -1
+			if value {
+				comma = len(out)
+			}
+			value = false
+			out = append(out, c)
+		case (c == '}' || c == ']') && comma >= 0:
+			out[comma] = ' '
+			comma, value = -1, true
+			out = append(out, c)
+		default:
+			comma, value = -1, c != '[' && c != '{' && c != ':'
+			out = append(out, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,50 @@
+package manager
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestStripJSONC(t *testing.T) {
+	for _, tc := range []struct {
+		name, in, want string
+	}{
+		{"plain", `{"a": [1, 2]}`, `{"a": [1, 2]}`},
+		{"line comment", "{\n// comment\n\"a\": 1 // trailing\n}", `{"a": 1}`},
+		{"block comment", "{/* one\ntwo */\"a\": /* inline */ 1}", `{"a": 1}`},
+		{"comments in strings", `{"url": "https://example.com/*x*/", "c": "// no"}`, `{"url": "https://example.com/*x*/", "c": "// no"}`},
+		{"escaped quote", `{"a": "say \"hi\" // still string", "b": "\\"}`, `{"a": "say \"hi\" // still string", "b": "\\"}`},
+		{"trailing commas", "{\"a\": [1, 2,], \"b\": {\"c\": 1,},\n}", `{"a": [1, 2], "b": {"c": 1}}`},
+		{"trailing comma before comment", "[1, 2, // last\n]", `[1, 2]`},
+		{"comma in string", `{"a": ",}", "b": ",]"}`, `{"a": ",}", "b": ",]"}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out := stripJSONC([]byte(tc.in))
+			if got, want := strings.Count(string(out), "\n"), strings.Count(tc.in, "\n"); got != want {
+				t.Errorf("%d lines, want %d", got, want)
+			}
+			var v any
+			if err := json.Unmarshal(out, &v); err != nil {
+				t.Fatalf("decoding %q: %v", out, err)
+			}
+			assertJSON(t, v, tc.want)
+		})
+	}
+}
+
+func TestStripJSONCInvalid(t *testing.T) {
+	// stripping keeps what is not a comment or trailing comma, so that decoding still reports errors.
+	for _, in := range []string{
+		`{"a": 1,, }`,
+		`[,]`,
+		`{"a": "unterminated}`,
+		`{"a": 1 /* unterminated`,
+		`{"a": 1} // ok` + "\n" + `{"b": 2}`,
+	} {
+		var v any
+		if err := json.Unmarshal(stripJSONC([]byte(in)), &v); err == nil {
+			t.Errorf("%q decoded as %v, want an error", in, v)
+		}
+	}
+}
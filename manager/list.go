@@ -0,0 +1,92 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+)
+
+type listEntry struct {
+	Name string `json:"name"`
+	// Source is where the set is defined: "json", "toml", "yaml", "yml", "dir" or one of the formats and "dir", e.g. "json+dir".
+	Source   string    `json:"source"`
+	Commands []command `json:"commands"`
+	Pinned   string    `json:"pinned,omitzero"`
+	Tags     []string  `json:"tags,omitzero"`
+	Disabled bool      `json:"disabled,omitzero"`
+}
+
+// list implements the list subcommand. It never executes commands.
+//
+//	list [<tgt>]
+func (m *Manager) list(_ context.Context, args []string) error {
+	if len(args) > 1 {
+		return configError(fmt.Errorf("list: wrong args length: want 0 or 1, got %d", len(args)))
+	}
+	var tgt string
+	if len(args) == 1 {
+		tgt = args[0]
+	}
+
+	format := outputFormat(m.opts.Output)
+	if err := format.Validate(); err != nil {
+		return configError(err)
+	}
+
+	pinnedVersions, err := loadPinnedVersions(m.cfgDir)
+	if err != nil {
+		return configError(err)
+	}
+	disabled, err := loadDisabled(m.cfgDir)
+	if err != nil {
+		return configError(err)
+	}
+	sets, err := m.resolveAllTargets(tgt)
+	if err != nil {
+		return configError(err)
+	}
+
+	entries := make([]listEntry, len(sets))
+	for i, set := range sets {
+		var sources []string
+		if file, err := findSetFile(set.Dir, set.Name); err == nil {
+			sources = append(sources, strings.TrimPrefix(filepath.Ext(file), "."))
+		}
+		if s, err := os.Stat(filepath.Join(set.Dir, set.Name)); err == nil && s.IsDir() {
+			sources = append(sources, "dir")
+		}
+		entries[i] = listEntry{
+			Name:     set.Name,
+			Source:   strings.Join(sources, "+"),
+			Commands: definedCommands(set),
+			Pinned:   pinnedVersions[set.Name],
+			Tags:     set.Set.Tags,
+			Disabled: isDisabled(set, disabled),
+		}
+	}
+
+	if format == outputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "    ")
+		return enc.Encode(entries)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSOURCE\tCOMMANDS\tPINNED\tTAGS\tDISABLED")
+	for _, e := range entries {
+		cmds := make([]string, len(e.Commands))
+		for i, c := range e.Commands {
+			cmds[i] = string(c)
+		}
+		var disabled string
+		if e.Disabled {
+			disabled = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", e.Name, e.Source, strings.Join(cmds, ","), e.Pinned, strings.Join(e.Tags, ","), disabled)
+	}
+	return w.Flush()
+}
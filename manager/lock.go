@@ -0,0 +1,112 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// dirLockFileName holds the pid of the holder, for telling who it is.
+const dirLockFileName = ".run.lock"
+
+var errLockHeld = errors.New("lock held by another process")
+
+var lockPollInterval = 250 * time.Millisecond
+
+var (
+	locksMu sync.Mutex
+	// locks are keyed by lock file paths.
+	locks = map[string]*dirLock{}
+)
+
+type dirLock struct {
+	f *os.File
+	n int
+}
+
+// lock takes the advisory lock on the config dir, so that concurrent runs do not interleave installs or overwrite
+// each other's .pin.json. Locking again in this process only counts up.
+func (m *Manager) lock(ctx context.Context) (unlock func(), err error) {
+	path, err := filepath.Abs(filepath.Join(m.cfgDir, dirLockFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	locksMu.Lock()
+	defer locksMu.Unlock()
+
+	unlock = func() {
+		locksMu.Lock()
+		defer locksMu.Unlock()
+		l := locks[path]
+		l.n--
+		if l.n == 0 {
+			// closing releases the lock. The file is left to avoid racing with processes opening it.
+			_ = l.f.Close()
+			delete(locks, path)
+		}
+	}
+	if l, ok := locks[path]; ok {
+		l.n++
+		return unlock, nil
+	}
+
+	if err := os.MkdirAll(m.cfgDir, 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	var logged bool
+	for {
+		err := tryLockFile(f)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errLockHeld) {
+			_ = f.Close()
+			return nil, fmt.Errorf("locking %s: %w", path, err)
+		}
+		holder := lockHolder(path)
+		if !m.opts.Wait {
+			_ = f.Close()
+			return nil, &exitError{
+				code: exitLocked,
+				err:  fmt.Errorf("config dir %s is in use by another run (%s); pass -wait to wait for it", m.cfgDir, holder),
+			}
+		}
+		if !logged {
+			m.logger().Info("waiting for another run to finish", "holder", holder)
+			logged = true
+		}
+		select {
+		case <-ctx.Done():
+			_ = f.Close()
+			return nil, ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+
+	// the pid is informational; failing to record it does not matter.
+	if err := f.Truncate(0); err == nil {
+		_, _ = f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+	locks[path] = &dirLock{f: f, n: 1}
+	return unlock, nil
+}
+
+func lockHolder(path string) string {
+	data, _ := os.ReadFile(path)
+	pid := string(bytes.TrimSpace(data))
+	if pid == "" {
+		return "pid unknown"
+	}
+	return "pid " + pid
+}
@@ -0,0 +1,15 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package manager
+
+import "os"
+
+// tryLockFile always succeeds where no file locking is implemented; concurrent runs are not guarded.
+func tryLockFile(_ *os.File) error {
+	return nil
+}
+
+// ownedByUser always reports true where file ownership is not checked.
+func ownedByUser(_ os.FileInfo) bool {
+	return true
+}
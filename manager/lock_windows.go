@@ -0,0 +1,44 @@
+//go:build windows
+
+package manager
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var procLockFileEx = syscall.NewLazyDLL("kernel32.dll").NewProc("LockFileEx")
+
+const (
+	lockfileFailImmediately = 0x1
+	lockfileExclusiveLock   = 0x2
+
+	errorLockViolation syscall.Errno = 33
+)
+
+// tryLockFile takes an exclusive lock on f without blocking.
+// It locks a byte far beyond the pid written at the start, since Windows locks also block reading locked bytes.
+func tryLockFile(f *os.File) error {
+	ol := &syscall.Overlapped{OffsetHigh: 1}
+	r, _, err := procLockFileEx.Call(
+		f.Fd(),
+		lockfileExclusiveLock|lockfileFailImmediately,
+		0,
+		1,
+		0,
+		uintptr(unsafe.Pointer(ol)),
+	)
+	if r == 0 {
+		if err == errorLockViolation {
+			return errLockHeld
+		}
+		return err
+	}
+	return nil
+}
+
+// ownedByUser always reports true since the temp dir is per user on Windows.
+func ownedByUser(_ os.FileInfo) bool {
+	return true
+}
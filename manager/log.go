@@ -0,0 +1,220 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var logFileName = filepath.Join(".log", "ngpkgmgr.log")
+
+type logFormat string
+
+const (
+	// logText writes human-readable lines to the console and slog's text format to the log file.
+	logText logFormat = "text"
+	logJSON logFormat = "json"
+)
+
+func (f logFormat) Validate() error {
+	switch f {
+	case "", logText, logJSON:
+		return nil
+	}
+	return fmt.Errorf("unknown log format %q: must be text or json", f)
+}
+
+func parseLogLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	if s == "" {
+		return l, nil
+	}
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return l, fmt.Errorf("unknown log level %q: must be debug, info, warn or error", s)
+	}
+	return l, nil
+}
+
+func (o Options) validateLog() error {
+	if o.Quiet && (o.Verbose || o.VeryVerbose) {
+		return errors.New("-q can not be used with -v")
+	}
+	if _, err := parseLogLevel(o.LogLevel); err != nil {
+		return err
+	}
+	if !validColor(o.Color) {
+		return fmt.Errorf("unknown color %q: must be auto, always or never", o.Color)
+	}
+	return logFormat(o.LogFormat).Validate()
+}
+
+// newLogger falls back to defaults on invalid LogLevel and LogFormat; see Options.validateLog.
+func newLogger(w io.Writer, opts Options, file string) *slog.Logger {
+	level, _ := parseLogLevel(opts.LogLevel)
+	hOpts := &slog.HandlerOptions{Level: level}
+	format := logFormat(opts.LogFormat)
+
+	var h slog.Handler
+	if format == logJSON {
+		h = slog.NewJSONHandler(w, hOpts)
+	} else {
+		h = newConsoleHandler(w, level, useColor(opts.Color, w))
+	}
+	if file == "" {
+		return slog.New(h)
+	}
+	var fh slog.Handler
+	if format == logJSON {
+		fh = slog.NewJSONHandler(appendFile(file), hOpts)
+	} else {
+		fh = slog.NewTextHandler(appendFile(file), hOpts)
+	}
+	return slog.New(teeHandler{h, fh})
+}
+
+func (m *Manager) logger() *slog.Logger {
+	globalCfg, _ := loadGlobalConfig(m.cfgDir)
+	opts := globalCfg.apply(m.opts)
+	return newLogger(os.Stderr, opts, m.logFile(opts))
+}
+
+func (m *Manager) logFile(opts Options) string {
+	if !opts.LogToFile {
+		return ""
+	}
+	return filepath.Join(m.cfgDir, logFileName)
+}
+
+// appendFile opens the file per write, creating it and its directory as needed; logs are not written often.
+type appendFile string
+
+func (f appendFile) Write(p []byte) (int, error) {
+	if err := os.MkdirAll(filepath.Dir(string(f)), 0o755); err != nil {
+		return 0, err
+	}
+	file, err := os.OpenFile(string(f), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	n, err := file.Write(p)
+	if cErr := file.Close(); err == nil {
+		err = cErr
+	}
+	return n, err
+}
+
+type teeHandler []slog.Handler
+
+func (t teeHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return slices.ContainsFunc(t, func(h slog.Handler) bool { return h.Enabled(ctx, l) })
+}
+
+func (t teeHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range t {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make(teeHandler, len(t))
+	for i, h := range t {
+		out[i] = h.WithAttrs(attrs)
+	}
+	return out
+}
+
+func (t teeHandler) WithGroup(name string) slog.Handler {
+	out := make(teeHandler, len(t))
+	for i, h := range t {
+		out[i] = h.WithGroup(name)
+	}
+	return out
+}
+
+// consoleHandler writes records as lines for humans, with no time, the level unless info, and the "err" attr
+// following the message:
+//
+//	warn: failed: install "foo": exit status 1 set=foo
+type consoleHandler struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	level  slog.Leveler
+	color  bool
+	attrs  []slog.Attr
+	prefix string
+}
+
+func newConsoleHandler(w io.Writer, level slog.Leveler, color bool) *consoleHandler {
+	return &consoleHandler{mu: new(sync.Mutex), w: w, level: level, color: color}
+}
+
+func (h *consoleHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= h.level.Level()
+}
+
+func (h *consoleHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	msg := r.Message
+	if r.Level != slog.LevelInfo {
+		msg = strings.ToLower(r.Level.String()) + ": " + msg
+	}
+	if c := recordColor(r); h.color && c != "" {
+		msg = paint(c, msg)
+	}
+	b.WriteString(msg)
+
+	attrs := slices.Clone(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		a.Key = h.prefix + a.Key
+		attrs = append(attrs, a)
+		return true
+	})
+	if i := slices.IndexFunc(attrs, func(a slog.Attr) bool { return a.Key == "err" }); i >= 0 {
+		b.WriteString(": " + attrs[i].Value.String())
+		attrs = slices.Delete(attrs, i, i+1)
+	}
+	for _, a := range attrs {
+		v := a.Value.Resolve().String()
+		if v == "" || strings.ContainsFunc(v, func(r rune) bool { return r <= ' ' || r == '"' || r == '=' }) {
+			v = strconv.Quote(v)
+		}
+		b.WriteString(" " + a.Key + "=" + v)
+	}
+	b.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *consoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = slices.Clone(h.attrs)
+	for _, a := range attrs {
+		a.Key = h.prefix + a.Key
+		h2.attrs = append(h2.attrs, a)
+	}
+	return &h2
+}
+
+func (h *consoleHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix = h.prefix + name + "."
+	return &h2
+}
@@ -0,0 +1,416 @@
+package manager
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	defaultManagedDir = "~/.local/share/ngpkgmgr"
+	currentFileName   = ".current"
+)
+
+// managedConfig enables the managed dir: backends install each version of a set into pkgs/<name>/<version>
+// and link binaries from its bin dir.
+//
+//	{"managed": {}}
+type managedConfig struct {
+	Dir string `json:"dir,omitzero"`
+	// Keep includes the linked version. Negative keeps every version.
+	Keep int `json:"keep,omitzero"`
+	// Shims run the version .tool-versions names, or the current version.
+	Shims bool `json:"shims,omitzero"`
+}
+
+func (c *managedConfig) dir() (managedDir, error) {
+	if c == nil {
+		return managedDir{}, nil
+	}
+	root, err := expandHome(cmp.Or(c.Dir, defaultManagedDir))
+	if err != nil {
+		return managedDir{}, fmt.Errorf("managed: %w", err)
+	}
+	return managedDir{root: root, keep: cmp.Or(c.Keep, 1), shims: c.Shims}, nil
+}
+
+// managedDir is the managed dir. The zero value means disabled.
+//
+//	bin/<binary> -> ../pkgs/<name>/<version>/<binary>
+//	pkgs/<name>/.current holds the version linked.
+type managedDir struct {
+	root  string
+	keep  int
+	shims bool
+}
+
+func (d managedDir) enabled() bool {
+	return d.root != ""
+}
+
+func (d managedDir) binDir() string {
+	return filepath.Join(d.root, "bin")
+}
+
+func (d managedDir) pkgDir(name string) string {
+	return filepath.Join(d.root, "pkgs", name)
+}
+
+func (d managedDir) versionDir(name, ver string) (string, error) {
+	if ver == "" || ver == "." || ver == ".." || strings.ContainsAny(ver, `/\`) {
+		return "", fmt.Errorf("managed: version %q can not be a directory name", ver)
+	}
+	return filepath.Join(d.pkgDir(name), ver), nil
+}
+
+// owner returns the set and version p links into. ver is always "" for shims, which run any version.
+func (d managedDir) owner(p string) (name, ver string, err error) {
+	target, err := os.Readlink(p)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return "", "", nil
+	case err != nil:
+		if name, ok := shimOwner(p); ok {
+			return name, "", nil
+		}
+		return "", "", fmt.Errorf("managed: %s is neither a link nor a shim made by ngpkgmgr", p)
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(p), target)
+	}
+	rel, err := filepath.Rel(filepath.Join(d.root, "pkgs"), target)
+	if err != nil || !filepath.IsLocal(rel) {
+		return "", "", fmt.Errorf("managed: %s links to %s, outside of the managed dir", p, target)
+	}
+	elems := strings.Split(filepath.ToSlash(rel), "/")
+	if len(elems) < 3 {
+		return "", "", fmt.Errorf("managed: %s links to %s, not a version of a set", p, target)
+	}
+	return elems[0], elems[1], nil
+}
+
+// link points binaries in the bin dir to ver of name and makes it current. Binaries linked by another set are refused.
+// It returns names of entries made in the bin dir.
+func (d managedDir) link(name, ver string, binaries []string) ([]string, error) {
+	verDir, err := d.versionDir(name, ver)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(d.binDir(), 0o755); err != nil {
+		return nil, err
+	}
+	var exe string
+	if d.shims {
+		if exe, err = os.Executable(); err != nil {
+			return nil, err
+		}
+	}
+	entries := make([]string, len(binaries))
+	for i, bin := range binaries {
+		dst := filepath.Join(d.binDir(), bin)
+		if d.shims {
+			dst = filepath.Join(d.binDir(), shimName(bin))
+		}
+		owner, _, err := d.owner(dst)
+		if err != nil {
+			return nil, err
+		}
+		if owner != "" && owner != name {
+			return nil, fmt.Errorf("managed: %s is linked by %q", dst, owner)
+		}
+		if d.shims {
+			err = writeFileAtomic(dst, shimScript(exe, d.root, name, bin), 0o755)
+		} else {
+			var target string
+			target, err = filepath.Rel(d.binDir(), filepath.Join(verDir, bin))
+			if err == nil {
+				err = replaceSymlink(target, dst)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("managed: %w", err)
+		}
+		entries[i] = filepath.Base(dst)
+	}
+	if err := writeFileAtomic(filepath.Join(d.pkgDir(name), currentFileName), []byte(ver+"\n"), 0o644); err != nil {
+		return nil, fmt.Errorf("managed: %w", err)
+	}
+	return entries, nil
+}
+
+func (d managedDir) paths(name string, entries []string) []string {
+	var paths []string
+	for _, entry := range entries {
+		paths = append(paths, filepath.Join(d.binDir(), entry))
+	}
+	return append(paths, d.pkgDir(name))
+}
+
+func replaceSymlink(target, dst string) error {
+	tmp := filepath.Join(filepath.Dir(dst), "."+filepath.Base(dst)+".tmp")
+	_ = os.Remove(tmp)
+	if err := os.Symlink(target, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// installed returns versions of name, most recently installed first.
+func (d managedDir) installed(name string) ([]string, error) {
+	dirents, err := os.ReadDir(d.pkgDir(name))
+	if err != nil {
+		return nil, err
+	}
+	type version struct {
+		ver     string
+		modTime time.Time
+	}
+	var vers []version
+	for _, dirent := range dirents {
+		info, err := dirent.Info()
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			vers = append(vers, version{dirent.Name(), info.ModTime()})
+		}
+	}
+	slices.SortStableFunc(vers, func(i, j version) int { return j.modTime.Compare(i.modTime) })
+	names := make([]string, len(vers))
+	for i, v := range vers {
+		names[i] = v.ver
+	}
+	return names, nil
+}
+
+func (d managedDir) linked(name string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(d.pkgDir(name), currentFileName))
+	switch {
+	case err == nil:
+		return strings.TrimSpace(string(data)), nil
+	case !errors.Is(err, fs.ErrNotExist):
+		return "", err
+	}
+	// the version its binaries link to.
+	dirents, err := os.ReadDir(d.binDir())
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	for _, dirent := range dirents {
+		if owner, ver, err := d.owner(filepath.Join(d.binDir(), dirent.Name())); err == nil && owner == name {
+			return ver, nil
+		}
+	}
+	return "", nil
+}
+
+// superseded returns dirs of versions of name other than current, except most recent ones up to keep in total.
+func (d managedDir) superseded(name, current string) ([]string, error) {
+	if d.keep < 0 {
+		return nil, nil
+	}
+	vers, err := d.installed(name)
+	if err != nil {
+		return nil, err
+	}
+	vers = slices.DeleteFunc(vers, func(v string) bool { return v == current })
+	var dirs []string
+	for _, ver := range vers[min(max(d.keep-1, 0), len(vers)):] {
+		dirs = append(dirs, filepath.Join(d.pkgDir(name), ver))
+	}
+	return dirs, nil
+}
+
+func (d managedDir) prune(name, current string) error {
+	dirs, err := d.superseded(name, current)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, dir := range dirs {
+		errs = append(errs, os.RemoveAll(dir))
+	}
+	return errors.Join(errs...)
+}
+
+// use links every file of ver of name from the bin dir and returns names of entries made.
+func (d managedDir) use(name, ver string) ([]string, error) {
+	verDir, err := d.versionDir(name, ver)
+	if err != nil {
+		return nil, err
+	}
+	dirents, err := os.ReadDir(verDir)
+	if err != nil {
+		return nil, err
+	}
+	var binaries []string
+	for _, dirent := range dirents {
+		if !dirent.IsDir() {
+			binaries = append(binaries, dirent.Name())
+		}
+	}
+	entries, err := d.link(name, ver, binaries)
+	if err != nil {
+		return nil, err
+	}
+	stale, err := os.ReadDir(d.binDir())
+	if err != nil {
+		return nil, err
+	}
+	for _, dirent := range stale {
+		p := filepath.Join(d.binDir(), dirent.Name())
+		if owner, _, err := d.owner(p); err == nil && owner == name && !slices.Contains(entries, dirent.Name()) {
+			if err := os.Remove(p); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return entries, nil
+}
+
+func (d managedDir) remove(name string) error {
+	dirents, err := os.ReadDir(d.binDir())
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	for _, dirent := range dirents {
+		p := filepath.Join(d.binDir(), dirent.Name())
+		if owner, _, err := d.owner(p); err == nil && owner == name {
+			if err := os.Remove(p); err != nil {
+				return err
+			}
+		}
+	}
+	return os.RemoveAll(d.pkgDir(name))
+}
+
+// use switches binaries of name to another installed version, or lists installed versions.
+//
+//	use <name> [<version>]
+func (m *Manager) use(_ context.Context, args []string) error {
+	if len(args) != 1 && len(args) != 2 {
+		return configError(fmt.Errorf("use: wrong args length: want 1 or 2, got %d", len(args)))
+	}
+	globalCfg, err := loadGlobalConfig(m.cfgDir)
+	if err != nil {
+		return configError(err)
+	}
+	d, err := globalCfg.Managed.dir()
+	if err != nil {
+		return configError(err)
+	}
+	if !d.enabled() {
+		return configError(fmt.Errorf("use: the managed dir is not enabled: add \"managed\": {} to config.json"))
+	}
+	name := args[0]
+	vers, err := d.installed(name)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if len(vers) == 0 {
+		return configError(fmt.Errorf("use: no version of %q is installed in the managed dir", name))
+	}
+	current, err := d.linked(name)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 1 {
+		for _, ver := range vers {
+			mark := " "
+			if ver == current {
+				mark = "*"
+			}
+			fmt.Printf("%s %s\n", mark, ver)
+		}
+		return nil
+	}
+
+	ver := args[1]
+	if !slices.Contains(vers, ver) {
+		return configError(fmt.Errorf("use: %s of %q is not installed: installed are %s", ver, name, strings.Join(vers, ", ")))
+	}
+	if ver != current {
+		entries, err := d.use(name, ver)
+		if err != nil {
+			return fmt.Errorf("use: %w", err)
+		}
+		m.logger().Info("switched", "set", name, "from", current, "to", ver)
+		if rec, ok, err := loadReceipt(m.cfgDir, name); err == nil && ok {
+			rec.Version, rec.Paths = ver, d.paths(name, entries)
+			if err := storeReceipt(m.cfgDir, name, rec); err != nil {
+				return fmt.Errorf("use: %w", err)
+			}
+		}
+	}
+	if !m.opts.Pin {
+		return nil
+	}
+	pinnedVersions, err := loadPinnedVersions(m.cfgDir)
+	if err != nil {
+		return configError(err)
+	}
+	pinnedVersions[name] = ver
+	return storePinnedVersions(m.cfgDir, pinnedVersions)
+}
+
+// env prints a line for shell init files adding the managed bin dir to PATH.
+//
+//	env [--shell <sh|fish|powershell>]
+func (m *Manager) env(_ context.Context, args []string) error {
+	fset := flag.NewFlagSet("env", flag.ContinueOnError)
+	shell := fset.String("shell", "", "sh, fish or powershell")
+	if err := fset.Parse(args); err != nil {
+		return configError(err)
+	}
+	if fset.NArg() != 0 {
+		return configError(fmt.Errorf("env: wrong args length: want 0, got %d", fset.NArg()))
+	}
+	globalCfg, err := loadGlobalConfig(m.cfgDir)
+	if err != nil {
+		return configError(err)
+	}
+	d, err := globalCfg.Managed.dir()
+	if err != nil {
+		return configError(err)
+	}
+	if !d.enabled() {
+		return configError(fmt.Errorf("env: the managed dir is not enabled: add \"managed\": {} to config.json"))
+	}
+
+	if *shell == "" {
+		switch {
+		case runtime.GOOS == "windows":
+			*shell = "powershell"
+		case filepath.Base(os.Getenv("SHELL")) == "fish":
+			*shell = "fish"
+		default:
+			*shell = "sh"
+		}
+	}
+	bin := d.binDir()
+	switch *shell {
+	case "sh":
+		fmt.Printf("export PATH=\"%s:$PATH\"\n", bin)
+	case "fish":
+		fmt.Printf("fish_add_path --path --move \"%s\"\n", bin)
+	case "powershell":
+		fmt.Printf("$env:Path = \"%s;\" + $env:Path\n", bin)
+	default:
+		return configError(fmt.Errorf("env: unknown shell %q: must be sh, fish or powershell", *shell))
+	}
+	return nil
+}
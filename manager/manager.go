@@ -0,0 +1,378 @@
+// Package manager implements ngpkgmgr, a meta package manager storing commands to install and update packages
+// as command sets under a config dir. The ngpkgmgr command is a thin CLI over Manager.
+package manager
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Options are settings of a Manager. Each field corresponds to a flag of the CLI, as noted.
+type Options struct {
+	Verbose        bool          // -v
+	VeryVerbose    bool          // -vv
+	Quiet          bool          // -q
+	Force          bool          // -f
+	Debug          bool          // -debug
+	Output         string        // -o; "" means "text"
+	DryRun         bool          // -dry-run
+	Pin            bool          // -pin
+	Reinstall      bool          // -reinstall
+	Tag            string        // -tag
+	Profile        string        // -profile
+	Parallel       int           // -j; 0 means 5 for checking versions and 1 otherwise
+	Timeout        time.Duration // -timeout; 0 means none
+	Retries        int           // -retries
+	CacheTTL       time.Duration // -cache-ttl; 0 disables the cache
+	Refresh        bool          // -refresh
+	AllowDowngrade bool          // -allow-downgrade
+	VerifyAfter    bool          // -verify-after
+	NoRollback     bool          // -no-rollback
+	Sync           bool          // -sync
+	Yes            bool          // -yes
+	NonInteractive bool          // -non-interactive
+	Wait           bool          // -wait
+	LogLevel       string        // -log-level; "" means "info"
+	LogFormat      string        // -log-format; "" means "text"
+	LogToFile      bool          // -log-to-file
+	Color          string        // -color; "" means "auto"
+
+	// BaseDirs are config dirs layered under the config dir, later ones taking precedence.
+	// State like pins is only kept in the config dir. -dir
+	BaseDirs []string
+	// ProjectDir is a project-local config dir, usually found by FindProjectDir. The CLI sets it unless -no-project.
+	ProjectDir string
+	// Flags are the global flags of the CLI, offered by completion.
+	Flags *flag.FlagSet
+}
+
+// Manager manages command sets under a config dir. Commands it runs inherit stdin and stderr.
+type Manager struct {
+	cfgDir string
+	opts   Options
+}
+
+// New returns a Manager for command sets under cfgDir.
+func New(cfgDir string, opts Options) *Manager {
+	opts.Output = cmp.Or(opts.Output, string(outputText))
+	return &Manager{cfgDir: cfgDir, opts: opts}
+}
+
+// DefaultConfigDir returns ngpkgmgr under os.UserConfigDir.
+func DefaultConfigDir() (string, error) {
+	userCfgDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("getting os.UserConfigDir: %w", err)
+	}
+	return filepath.Join(userCfgDir, "ngpkgmgr"), nil
+}
+
+const projectDirName = ".pkgmgr"
+
+// FindProjectDir searches for a .pkgmgr directory in dir and its parents. It returns "" if none is found.
+func FindProjectDir(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		p := filepath.Join(dir, projectDirName)
+		s, err := os.Stat(p)
+		switch {
+		case err == nil && s.IsDir():
+			return p, nil
+		case err != nil && !errors.Is(err, fs.ErrNotExist):
+			return "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// subcommands take precedence over <tgt> <cmd>: a set named after one can not be targeted alone.
+var subcommands = map[string]func(m *Manager, ctx context.Context, args []string) error{
+	"pin":      (*Manager).pin,
+	"unpin":    (*Manager).unpin,
+	"enable":   (*Manager).enable,
+	"disable":  (*Manager).disable,
+	"validate": (*Manager).validate,
+	"doctor":   (*Manager).doctor,
+	"list":     (*Manager).list,
+	"outdated": (*Manager).outdated,
+	"audit":    (*Manager).audit,
+	"check":    (*Manager).check,
+	"daemon":   (*Manager).daemon,
+	"schedule": (*Manager).schedule,
+	"env":      (*Manager).env,
+	"use":      (*Manager).use,
+	"gc":       (*Manager).gc,
+	"fetch":    (*Manager).fetch,
+	"extract":  (*Manager).extract,
+	"sync":     (*Manager).syncConfig,
+	"freeze":   (*Manager).freeze,
+	"export":   (*Manager).export,
+	"import":   (*Manager).importSets,
+	"restore":  (*Manager).restore,
+	"edit":     (*Manager).edit,
+	"show":     (*Manager).show,
+	"history":  (*Manager).history,
+	"remove":   (*Manager).remove,
+	"rename":   (*Manager).rename,
+	"new":      (*Manager).newSet,
+	"tui":      (*Manager).tui,
+
+	"completion": (*Manager).completion,
+
+	"self-update": (*Manager).selfUpdate,
+	"shim-exec":   (*Manager).shimExec,
+}
+
+// lockingSubcommands run holding the config dir lock.
+var lockingSubcommands = map[string]bool{
+	"pin":     true,
+	"unpin":   true,
+	"enable":  true,
+	"disable": true,
+	"use":     true,
+	"gc":      true,
+	"sync":    true,
+	"restore": true,
+	"import":  true,
+	"remove":  true,
+	"rename":  true,
+	"new":     true,
+	"tui":     true,
+}
+
+// Run runs args as the CLI does: either a subcommand, "<tgt> <cmd>" or "<cmd> [<tgt>...]".
+// The returned error can be converted to an exit status by ExitCode.
+func (m *Manager) Run(ctx context.Context, args []string) error {
+	if err := m.opts.validateLog(); err != nil {
+		return configError(err)
+	}
+	if m.opts.Sync && (len(args) == 0 || args[0] != "sync") {
+		unlock, err := m.lock(ctx)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+		if err := syncConfigDir(ctx, m.logger(), m.cfgDir, ""); err != nil {
+			return err
+		}
+	}
+
+	if len(args) > 0 {
+		if subcommand, ok := subcommands[args[0]]; ok {
+			if lockingSubcommands[args[0]] {
+				unlock, err := m.lock(ctx)
+				if err != nil {
+					return err
+				}
+				defer unlock()
+			}
+			return subcommand(m, ctx, args[1:])
+		}
+	}
+
+	if len(args) >= 3 && args[1] == "run" {
+		return m.RunUserCommand(ctx, args[2], args[3:], args[0])
+	}
+
+	tgt, cmd, err := parseArgs(args)
+	if err != nil {
+		return configError(err)
+	}
+	return m.runCommand(ctx, command(cmd), tgt, nil)
+}
+
+// CreateCommandSet creates name.json and directory name with empty scripts, leaving existing files untouched.
+func (m *Manager) CreateCommandSet(name string) error {
+	return createCommandSet(m.cfgDir, name, "")
+}
+
+// CreateCommandSetFromTemplate is like CreateCommandSet but pre-fills the set from template:
+// "script", "github-release" or "go-install".
+func (m *Manager) CreateCommandSetFromTemplate(name, template string) error {
+	return createCommandSet(m.cfgDir, name, template)
+}
+
+// Ver runs ver for sets selected by targets, or all sets if none.
+func (m *Manager) Ver(ctx context.Context, targets ...string) error {
+	return m.runCommand(ctx, commandVer, strings.Join(targets, ","), nil)
+}
+
+// CheckLatest runs ver and checklatest for sets selected by targets, or all sets if none.
+func (m *Manager) CheckLatest(ctx context.Context, targets ...string) error {
+	return m.runCommand(ctx, commandChecklatest, strings.Join(targets, ","), nil)
+}
+
+// Install installs sets selected by targets, or all sets if none, unless already installed.
+func (m *Manager) Install(ctx context.Context, targets ...string) error {
+	return m.runCommand(ctx, commandInstall, strings.Join(targets, ","), nil)
+}
+
+// Update updates sets selected by targets, or all sets if none, which are not at their target versions.
+func (m *Manager) Update(ctx context.Context, targets ...string) error {
+	return m.runCommand(ctx, commandUpdate, strings.Join(targets, ","), nil)
+}
+
+// RunUserCommand runs the user-defined command name of sets selected by targets, with args appended.
+func (m *Manager) RunUserCommand(ctx context.Context, name string, args []string, targets ...string) error {
+	if slices.Contains(cmds, command(name)) {
+		return configError(fmt.Errorf("run: %q is a built-in command", name))
+	}
+	return m.runCommand(ctx, command(name), strings.Join(targets, ","), args)
+}
+
+// Uninstall uninstalls sets selected by targets. At least one target is required.
+func (m *Manager) Uninstall(ctx context.Context, targets ...string) error {
+	return m.runCommand(ctx, commandUninstall, strings.Join(targets, ","), nil)
+}
+
+func (m *Manager) runCommand(ctx context.Context, cmd command, tgt string, args []string) error {
+	if (cmd == commandUninstall || !slices.Contains(cmds, cmd)) && tgt == "" {
+		return configError(fmt.Errorf("%s needs explicit target", cmd))
+	}
+
+	format := outputFormat(m.opts.Output)
+	if err := format.Validate(); err != nil {
+		return configError(err)
+	}
+	if m.opts.Retries < 0 {
+		return configError(fmt.Errorf("-retries must not be negative"))
+	}
+	if m.opts.Parallel < 0 {
+		return configError(fmt.Errorf("-j must not be negative"))
+	}
+
+	tgt, requested, err := splitRequestedVersions(m.expandTargets(tgt))
+	if err != nil {
+		return configError(err)
+	}
+	if len(requested) > 0 && cmd != commandInstall && cmd != commandUpdate {
+		return configError(fmt.Errorf("%s: <name>@<version> is only for install and update", cmd))
+	}
+	if m.opts.Pin && len(requested) == 0 {
+		return configError(fmt.Errorf("-pin needs <name>@<version> targets of install or update"))
+	}
+
+	pinnedVersions, err := loadPinnedVersions(m.cfgDir)
+	if err != nil {
+		return configError(err)
+	}
+
+	sets, err := m.resolveTargets(tgt)
+	if err != nil {
+		return configError(err)
+	}
+	for name, ver := range requested {
+		if !slices.ContainsFunc(sets, func(set namedCommandSet) bool { return set.Name == name }) {
+			return configError(fmt.Errorf("%s@%s: no such command set selected", name, ver))
+		}
+		pinnedVersions[name] = ver
+	}
+
+	if m.opts.Debug {
+		for _, s := range sets {
+			fmt.Printf("name = %s, after = %v, deps = %v\n", s.Name, s.Set.After, s.Set.dependencies())
+		}
+		return nil
+	}
+
+	if cmd != commandVer && cmd != commandChecklatest && !m.opts.DryRun {
+		unlock, err := m.lock(ctx)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	r, err := m.newRunner(cmd, sets, pinnedVersions, format)
+	if err != nil {
+		return err
+	}
+	r.args = args
+	r.requested = requested
+	err = r.Run(ctx)
+	if m.opts.Pin && !m.opts.DryRun {
+		if pinErr := m.pinRequested(r.report, requested); pinErr != nil {
+			err = errors.Join(err, pinErr)
+		}
+	}
+	return err
+}
+
+// splitRequestedVersions strips versions from <name>@<version> elements of tgt and returns them by name.
+func splitRequestedVersions(tgt string) (string, map[string]string, error) {
+	if !strings.Contains(tgt, "@") {
+		return tgt, nil, nil
+	}
+	requested := map[string]string{}
+	elems := strings.Split(tgt, ",")
+	for i, elem := range elems {
+		at := strings.LastIndexByte(elem, '@')
+		if at < 0 {
+			continue
+		}
+		name, ver := elem[:at], elem[at+1:]
+		switch {
+		case name == "" || ver == "":
+			return "", nil, fmt.Errorf("%q: want <name>@<version>", elem)
+		case hasMeta(name):
+			return "", nil, fmt.Errorf("%q: a version can not be given to a pattern", elem)
+		}
+		if err := validatePin(name, ver); err != nil {
+			return "", nil, err
+		}
+		if v, ok := requested[name]; ok && v != ver {
+			return "", nil, fmt.Errorf("%s: conflicting versions %s and %s", name, v, ver)
+		}
+		requested[name] = ver
+		elems[i] = name
+	}
+	return strings.Join(elems, ","), requested, nil
+}
+
+func (m *Manager) pinRequested(report *runReport, requested map[string]string) error {
+	pinnedVersions, err := loadPinnedVersions(m.cfgDir)
+	if err != nil {
+		return err
+	}
+	var changed bool
+	for name, ver := range requested {
+		if a := report.Get(name).Action; a == actionFailed || a == "" {
+			continue
+		}
+		changed = changed || pinnedVersions[name] != ver
+		pinnedVersions[name] = ver
+	}
+	if !changed {
+		return nil
+	}
+	return storePinnedVersions(m.cfgDir, pinnedVersions)
+}
+
+func parseArgs(args []string) (tgt, cmd string, err error) {
+	isCmd := func(s string) bool { return slices.Contains(cmds, command(s)) }
+	switch {
+	case len(args) == 0:
+		return "", "", fmt.Errorf("no command: must be one of %v", cmds)
+	case len(args) == 2 && isCmd(args[1]):
+		return args[0], args[1], nil
+	case isCmd(args[0]):
+		return strings.Join(args[1:], ","), args[0], nil
+	}
+	return "", "", fmt.Errorf("unknown command: must be one of %v", cmds)
+}
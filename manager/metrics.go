@@ -0,0 +1,119 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// daemonMetrics are in the Prometheus text format.
+type daemonMetrics struct {
+	mu sync.Mutex
+	// entries are of the last check which succeeded.
+	entries       []*checkEntry
+	lastCheck     time.Time
+	lastSuccess   time.Time
+	checks        int
+	checkFailures int
+}
+
+func (dm *daemonMetrics) record(entries []*checkEntry, err error, now time.Time) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	dm.checks++
+	dm.lastCheck = now
+	if err != nil {
+		dm.checkFailures++
+		return
+	}
+	dm.entries = entries
+	dm.lastSuccess = now
+}
+
+func (dm *daemonMetrics) WriteTo(w io.Writer) (int64, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	var b bytes.Buffer
+	metric := func(name, typ, help string, samples ...string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+		for _, s := range samples {
+			fmt.Fprintf(&b, "%s%s\n", name, s)
+		}
+	}
+	timestamp := func(t time.Time) string {
+		if t.IsZero() {
+			return " 0"
+		}
+		return " " + strconv.FormatInt(t.Unix(), 10)
+	}
+
+	counts := map[string]int{}
+	var outdated, failed []string
+	for _, e := range dm.entries {
+		counts[e.Problem]++
+		label := fmt.Sprintf("{set=%q}", e.Name)
+		outdated = append(outdated, label+" "+boolMetric(e.Problem == "outdated"))
+		failed = append(failed, label+" "+boolMetric(e.Problem == "checklatest failed"))
+	}
+	metric("ngpkgmgr_sets", "gauge", "Number of sets checked.", " "+strconv.Itoa(len(dm.entries)))
+	metric("ngpkgmgr_sets_outdated", "gauge", "Number of sets not at their target versions.", " "+strconv.Itoa(counts["outdated"]))
+	metric("ngpkgmgr_sets_not_installed", "gauge", "Number of sets not installed.", " "+strconv.Itoa(counts["not installed"]))
+	metric("ngpkgmgr_sets_check_failed", "gauge", "Number of sets whose checklatest failed.", " "+strconv.Itoa(counts["checklatest failed"]))
+	metric("ngpkgmgr_set_outdated", "gauge", "Whether the set is not at its target version.", outdated...)
+	metric("ngpkgmgr_set_check_failed", "gauge", "Whether checklatest of the set failed.", failed...)
+	metric("ngpkgmgr_last_check_timestamp_seconds", "gauge", "Time of the last check.", timestamp(dm.lastCheck))
+	metric("ngpkgmgr_last_success_timestamp_seconds", "gauge", "Time of the last check which did not fail as a whole.", timestamp(dm.lastSuccess))
+	metric("ngpkgmgr_checks_total", "counter", "Number of checks.", " "+strconv.Itoa(dm.checks))
+	metric("ngpkgmgr_check_failures_total", "counter", "Number of checks which failed as a whole.", " "+strconv.Itoa(dm.checkFailures))
+	return b.WriteTo(w)
+}
+
+func boolMetric(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+func (dm *daemonMetrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = dm.WriteTo(w)
+}
+
+// writeFile is for the textfile collector of node_exporter.
+func (dm *daemonMetrics) writeFile(path string) error {
+	var b bytes.Buffer
+	_, _ = dm.WriteTo(&b)
+	return writeFileAtomic(path, b.Bytes(), 0o644)
+}
+
+// push replaces metrics of this host in the Pushgateway.
+func (dm *daemonMetrics) push(ctx context.Context, gateway string) error {
+	var b bytes.Buffer
+	_, _ = dm.WriteTo(&b)
+	u := strings.TrimSuffix(gateway, "/") + "/metrics/job/" + scheduleName + "/instance/" + url.PathEscape(hostname())
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u, &b)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	req.Header.Set("User-Agent", "ngpkgmgr/"+version)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("PUT %s: %s", u, resp.Status)
+	}
+	return nil
+}
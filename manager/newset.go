@@ -0,0 +1,158 @@
+package manager
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+type setTemplate struct {
+	set     func(name string) commandSet
+	scripts bool
+	// examples are commented examples in scripts, rather than just a shebang line.
+	examples bool
+}
+
+// setTemplates are selectable by -template. "" is the default.
+var setTemplates = map[string]setTemplate{
+	"": {
+		set: func(string) commandSet {
+			return commandSet{
+				Ver:         []string{},
+				Install:     []string{},
+				CheckLatest: []string{},
+				Update:      []string{},
+				Uninstall:   []string{},
+				After:       []string{},
+			}
+		},
+		scripts: true,
+	},
+	"script": {
+		set:      func(string) commandSet { return commandSet{} },
+		scripts:  true,
+		examples: true,
+	},
+	"github-release": {
+		set: func(name string) commandSet {
+			return commandSet{
+				Ver:     []string{name, "--version"},
+				Extract: map[command]extractor{commandVer: {Regex: `\d+\.\d+\.\d+`}},
+				GitHub: &githubBackend{
+					Repo:  "OWNER/" + name,
+					Asset: name + "_${VER}_${OS}_${ARCH}.tar.gz",
+				},
+			}
+		},
+	},
+	"go-install": {
+		set: func(name string) commandSet {
+			v := goInstallBackend("github.com/OWNER/" + name + "@${VER}")
+			return commandSet{GoInstall: &v}
+		},
+	},
+}
+
+var (
+	shExamples = map[command]string{
+		commandVer: `# Print the installed version to stdout. Fail if not installed.
+# %[1]s --version | awk '{print $2}'
+`,
+		commandChecklatest: `# Print the latest available version to stdout.
+# curl -fsSL https://api.github.com/repos/OWNER/%[1]s/releases/latest | jq -r .tag_name
+`,
+		commandInstall: `# Install version $VER, or the latest if empty. $OS and $ARCH are GOOS and GOARCH.
+# curl -fsSL "https://example.com/%[1]s/${VER}/%[1]s_${OS}_${ARCH}.tar.gz" | tar -C ~/.local/bin -xz %[1]s
+`,
+		commandUpdate: `# Update to version $VER. Often the same as install.
+# exec "$(dirname "$0")/install.sh"
+`,
+		commandUninstall: `# Remove what install placed.
+# rm -f ~/.local/bin/%[1]s
+`,
+	}
+	ps1Examples = map[command]string{
+		commandVer: `# Print the installed version to stdout. Fail if not installed.
+# (%[1]s --version).Split(' ')[1]
+`,
+		commandChecklatest: `# Print the latest available version to stdout.
+# (Invoke-RestMethod https://api.github.com/repos/OWNER/%[1]s/releases/latest).tag_name
+`,
+		commandInstall: `# Install version $env:VER, or the latest if empty. $env:OS and $env:ARCH are GOOS and GOARCH.
+# Invoke-WebRequest "https://example.com/%[1]s/$env:VER/%[1]s_$($env:OS)_$($env:ARCH).zip" -OutFile "$env:TEMP\%[1]s.zip"
+# Expand-Archive -Force "$env:TEMP\%[1]s.zip" "$env:LOCALAPPDATA\Programs\%[1]s"
+`,
+		commandUpdate: `# Update to version $env:VER. Often the same as install.
+# & "$PSScriptRoot\install.ps1"
+`,
+		commandUninstall: `# Remove what install placed.
+# Remove-Item -Recurse -Force "$env:LOCALAPPDATA\Programs\%[1]s"
+`,
+	}
+)
+
+// createCommandSet writes the format of the extension of name, if any, and leaves existing files, in any format, untouched.
+func createCommandSet(cfgDir, name, template string) error {
+	tmpl, ok := setTemplates[template]
+	if !ok {
+		return configError(fmt.Errorf("unknown template %q: must be one of %q", template, slices.Sorted(maps.Keys(setTemplates))[1:]))
+	}
+	ext, ok := setFileExt(name)
+	if ok {
+		name = strings.TrimSuffix(name, ext)
+	} else {
+		ext = ".json"
+	}
+
+	_, err := findSetFile(cfgDir, name)
+	switch {
+	case err == nil:
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	default:
+		data, err := marshalAs(ext, tmpl.set(name))
+		if err != nil {
+			return err
+		}
+		err = createFileAtomic(filepath.Join(cfgDir, name+ext), data, 0o644)
+		if err != nil && !errors.Is(err, fs.ErrExist) {
+			return err
+		}
+	}
+	if !tmpl.scripts {
+		return nil
+	}
+
+	err = os.Mkdir(filepath.Join(cfgDir, name), fs.ModePerm)
+	if err != nil && !errors.Is(err, fs.ErrExist) {
+		return err
+	}
+	for _, c := range cmds {
+		scriptName := filepath.Join(cfgDir, name, string(c))
+		content := fmt.Sprintf("#!%s\n", cmp.Or(os.Getenv("SHELL"), "/bin/bash"))
+		switch runtime.GOOS {
+		case "windows":
+			scriptName += ".ps1"
+			if tmpl.examples {
+				content = "$ErrorActionPreference = 'Stop'\n\n" + fmt.Sprintf(ps1Examples[c], name)
+			}
+		default:
+			scriptName += ".sh"
+			if tmpl.examples {
+				content = "#!/usr/bin/env bash\nset -eo pipefail\n\n" + fmt.Sprintf(shExamples[c], name)
+			}
+		}
+		err := createFileAtomic(scriptName, []byte(content), 0o755)
+		if err != nil && !errors.Is(err, fs.ErrExist) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package manager
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// notifyConfig sends a summary of update runs.
+//
+//	{"notify": {"desktop": true, "webhooks": [{"type": "slack", "url": "https://hooks.slack.com/..."}]}}
+type notifyConfig struct {
+	// On is "changes" (default), "failure" or "always".
+	On string `json:"on,omitzero"`
+	// Desktop uses notify-send on Linux, osascript on macOS and a balloon tip on Windows.
+	Desktop  bool            `json:"desktop,omitzero"`
+	Webhooks []notifyWebhook `json:"webhooks,omitzero"`
+}
+
+type notifyWebhook struct {
+	// Type is "slack", "discord" or "generic", which POSTs the run report with the summary.
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+func (c *notifyConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	switch c.On {
+	case "", "changes", "failure", "always":
+	default:
+		return fmt.Errorf("notify: on must be changes, failure or always, got %q", c.On)
+	}
+	for i, w := range c.Webhooks {
+		switch w.Type {
+		case "slack", "discord", "generic":
+		default:
+			return fmt.Errorf("notify: webhooks[%d]: type must be slack, discord or generic, got %q", i, w.Type)
+		}
+		if !strings.HasPrefix(w.URL, "https://") && !strings.HasPrefix(w.URL, "http://") {
+			return fmt.Errorf("notify: webhooks[%d]: url must be http(s), got %q", i, w.URL)
+		}
+	}
+	return nil
+}
+
+func (c *notifyConfig) shouldNotify(report *runReport) bool {
+	if c == nil || (!c.Desktop && len(c.Webhooks) == 0) {
+		return false
+	}
+	var changed, failed bool
+	for _, res := range report.Results {
+		changed = changed || res.Action == actionUpdated
+		failed = failed || res.Action == actionFailed
+	}
+	switch c.On {
+	case "always":
+		return true
+	case "failure":
+		return failed
+	}
+	return changed || failed
+}
+
+func notifySummary(report *runReport) (string, string) {
+	counts := map[action]int{}
+	var lines []string
+	for _, res := range report.Results {
+		counts[res.Action]++
+		switch res.Action {
+		case actionUpdated:
+			lines = append(lines, fmt.Sprintf("%s: %s -> %s", res.Name, res.Current, res.Target))
+		case actionFailed:
+			lines = append(lines, fmt.Sprintf("%s: failed: %s", res.Name, res.Error))
+		}
+	}
+	var parts []string
+	for _, a := range []action{actionUpdated, actionSkipped, actionFailed} {
+		if counts[a] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[a], a))
+		}
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "nothing to do")
+	}
+	return "ngpkgmgr " + report.Command + ": " + strings.Join(parts, ", "), strings.Join(lines, "\n")
+}
+
+var notifyTimeout = 30 * time.Second
+
+// Notify joins failures of each destination.
+func (c *notifyConfig) Notify(ctx context.Context, report *runReport) error {
+	title, body := notifySummary(report)
+	return c.send(ctx, title, body, report)
+}
+
+func (c *notifyConfig) send(ctx context.Context, title, body string, payload any) error {
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+	var errs []error
+	if c.Desktop {
+		if err := notifyDesktop(ctx, title, body); err != nil {
+			errs = append(errs, fmt.Errorf("desktop: %w", err))
+		}
+	}
+	for _, w := range c.Webhooks {
+		if err := w.post(ctx, title, body, payload); err != nil {
+			errs = append(errs, fmt.Errorf("webhook %s: %w", w.Type, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func notifyDesktop(ctx context.Context, title, body string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		cmd = exec.CommandContext(ctx, "notify-send", title, body)
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(body), appleScriptString(title))
+		cmd = exec.CommandContext(ctx, "osascript", "-e", script)
+	case "windows":
+		script := `Add-Type -AssemblyName System.Windows.Forms
+$n = New-Object System.Windows.Forms.NotifyIcon
+$n.Icon = [System.Drawing.SystemIcons]::Information
+$n.Visible = $true
+$n.ShowBalloonTip(10000, $env:PKGMGR_TITLE, $env:PKGMGR_BODY, 'Info')
+Start-Sleep -Seconds 5
+$n.Dispose()`
+		cmd = exec.CommandContext(ctx, "powershell", "-NoProfile", "-Command", script)
+		cmd.Env = append(cmd.Environ(), "PKGMGR_TITLE="+title, "PKGMGR_BODY="+cmp.Or(body, " ")) // balloon tips reject empty text.
+	default:
+		return fmt.Errorf("not supported on %s", runtime.GOOS)
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil && len(out) > 0 {
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(out))
+	}
+	return err
+}
+
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func (w notifyWebhook) post(ctx context.Context, title, body string, report any) error {
+	text := title
+	if body != "" {
+		text += "\n" + body
+	}
+	var payload any
+	switch w.Type {
+	case "slack":
+		payload = map[string]string{"text": text}
+	case "discord":
+		payload = map[string]string{"content": text}
+	default:
+		payload = struct {
+			Title  string `json:"title"`
+			Body   string `json:"body"`
+			Report any    `json:"report"`
+		}{title, body, report}
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ngpkgmgr/"+version)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("POST: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+type outdatedEntry struct {
+	Name     string `json:"name"`
+	Current  string `json:"current"`
+	Latest   string `json:"latest"`
+	Target   string `json:"target"`
+	Pinned   bool   `json:"pinned"`
+	Outdated bool   `json:"outdated"`
+	Error    string `json:"error,omitzero"`
+}
+
+// outdated prints versions of sets in a table. Sets not installed are outdated.
+//
+//	outdated [--json] [<tgt>]
+func (m *Manager) outdated(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("outdated", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "prints json instead of a table. Same as -o json")
+	if err := flags.Parse(args); err != nil {
+		return configError(err)
+	}
+	if flags.NArg() > 1 {
+		return configError(fmt.Errorf("outdated: wrong args length: want 0 or 1, got %d", flags.NArg()))
+	}
+
+	format := outputFormat(m.opts.Output)
+	if err := format.Validate(); err != nil {
+		return configError(err)
+	}
+	if *asJSON {
+		format = outputJSON
+	}
+
+	pinnedVersions, err := loadPinnedVersions(m.cfgDir)
+	if err != nil {
+		return configError(err)
+	}
+	sets, err := m.resolveTargets(flags.Arg(0))
+	if err != nil {
+		return configError(err)
+	}
+
+	r, err := m.newRunner(commandChecklatest, sets, pinnedVersions, format)
+	if err != nil {
+		return err
+	}
+	// like check, a set failing, e.g. not installed, is reported in its row rather than aborting the report.
+	r.opts.Force = true
+	if err := r.resolveVersions(ctx); err != nil {
+		return err
+	}
+
+	entries := make([]outdatedEntry, len(sets))
+	var numOutdated, numFailed int
+	for i, res := range r.report.Results {
+		entries[i] = outdatedEntry{
+			Name:    res.Name,
+			Current: res.Current,
+			Latest:  res.Latest,
+			Target:  res.Target,
+			Pinned:  res.Pinned,
+		}
+		e := &entries[i]
+		switch {
+		case res.Latest == "" || res.Action == actionFailed && res.Current != "":
+			e.Error = res.Error
+		case res.Current == "":
+			// not installed: the target is what install would install.
+			e.Target, e.Outdated = res.Latest, true
+			if pin := pinnedVersions[res.Name]; pin != "" {
+				tgt, err := r.executor(sets[i]).resolvePin(ctx, pin, "", res.Latest)
+				if err != nil {
+					e.Error, e.Outdated = err.Error(), false
+				}
+				e.Target = tgt
+			}
+		default:
+			e.Outdated = needsUpdate(sets[i].Set.Versioning, res.Current, res.Target, m.opts.AllowDowngrade)
+		}
+		if e.Error != "" {
+			numFailed++
+		}
+		if e.Outdated {
+			numOutdated++
+		}
+	}
+
+	if format == outputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "    ")
+		if err := enc.Encode(entries); err != nil {
+			return err
+		}
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tCURRENT\tLATEST\tTARGET\tOUTDATED\tERROR")
+		for _, e := range entries {
+			tgt := e.Target
+			if e.Pinned {
+				tgt += " (pinned)"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\t%s\n", e.Name, e.Current, e.Latest, tgt, e.Outdated, e.Error)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case numOutdated > 0:
+		return &exitError{code: exitOutdated, err: fmt.Errorf("%d of %d set(s) outdated", numOutdated, len(sets))}
+	case numFailed > 0:
+		return fmt.Errorf("checklatest failed for %d set(s)", numFailed)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package manager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os/exec"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+type outputFormat string
+
+const (
+	outputText  outputFormat = "text"
+	outputJSON  outputFormat = "json"
+	outputTable outputFormat = "table"
+	outputPlain outputFormat = "plain"
+)
+
+var outputFormats = []outputFormat{outputText, outputJSON, outputTable, outputPlain}
+
+func (o outputFormat) Validate() error {
+	if slices.Contains(outputFormats, o) {
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q: must be one of %v", string(o), outputFormats)
+}
+
+// structured reports whether o prints results after the run, leaving stdout to them.
+func (o outputFormat) structured() bool {
+	return o != outputText
+}
+
+type action string
+
+const (
+	actionInstalled   action = "installed"
+	actionUpdated     action = "updated"
+	actionUninstalled action = "uninstalled"
+	actionRan         action = "ran"
+	actionSkipped     action = "skipped"
+	actionFailed      action = "failed"
+)
+
+type packageResult struct {
+	Name     string `json:"name"`
+	Current  string `json:"current,omitzero"`
+	Latest   string `json:"latest,omitzero"`
+	Target   string `json:"target,omitzero"`
+	Pinned   bool   `json:"pinned"`
+	Action   action `json:"action,omitzero"`
+	Error    string `json:"error,omitzero"`
+	Verified *bool  `json:"verified,omitzero"`
+	// Duration is set only for sets processed.
+	Duration *duration            `json:"duration,omitzero"`
+	Steps    map[command]duration `json:"steps,omitzero"`
+	// RolledBack reports whether rolling back a failed update succeeded.
+	RolledBack *bool `json:"rolled_back,omitzero"`
+
+	// exitCode is recorded in the history.
+	exitCode *int
+}
+
+func (r *packageResult) Fail(err error) {
+	r.Action = actionFailed
+	r.Error = err.Error()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		r.exitCode = ptr(exitErr.ExitCode())
+	}
+}
+
+type runReport struct {
+	Command string           `json:"command"`
+	Results []*packageResult `json:"results"`
+}
+
+func newRunReport(cmd command, sets []namedCommandSet, pinnedVersions map[string]string) *runReport {
+	r := &runReport{Command: string(cmd), Results: make([]*packageResult, len(sets))}
+	for i, set := range sets {
+		r.Results[i] = &packageResult{Name: set.Name, Pinned: pinnedVersions[set.Name] != ""}
+	}
+	return r
+}
+
+// Get panics if name is not a known set.
+func (r *runReport) Get(name string) *packageResult {
+	for _, res := range r.Results {
+		if res.Name == name {
+			return res
+		}
+	}
+	panic(fmt.Errorf("unknown command set %q", name))
+}
+
+func (r *runReport) Failure() error {
+	var failed []string
+	for _, res := range r.Results {
+		if res.Action == actionFailed {
+			failed = append(failed, res.Name)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return partialFailure(fmt.Errorf("%d of %d set(s) failed: %v", len(failed), len(r.Results), failed))
+}
+
+func (r *runReport) Encode(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	return enc.Encode(r)
+}
+
+func (r *runReport) Write(w io.Writer, format outputFormat) error {
+	return r.write(w, format, false)
+}
+
+func (r *runReport) write(w io.Writer, format outputFormat, color bool) error {
+	switch format {
+	case outputJSON:
+		return r.Encode(w)
+	case outputTable, outputPlain:
+		var tw io.Writer = w
+		color = color && format == outputTable
+		if format == outputTable {
+			tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			header := "ACTION"
+			if color {
+				header = paint("", header)
+			}
+			fmt.Fprintf(tw, "NAME\tCURRENT\tLATEST\tTARGET\t%s\tDURATION\tERROR\tSTEPS\n", header)
+		}
+		for _, res := range r.Results {
+			var d string
+			if res.Duration != nil {
+				d = time.Duration(*res.Duration).Round(time.Millisecond).String()
+			}
+			a := string(res.Action)
+			if color {
+				a = paint(actionColor(res), a)
+			}
+			fmt.Fprintf(
+				tw,
+				"%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				res.Name, res.Current, res.Latest, res.Target, a, d, res.Error, res.stepsString(),
+			)
+		}
+		if tw, ok := tw.(*tabwriter.Writer); ok {
+			return tw.Flush()
+		}
+		return nil
+	}
+	return fmt.Errorf("output format %q is not structured", format)
+}
+
+// stepsString lists built-in commands in the order they run, then user-defined ones.
+func (r *packageResult) stepsString() string {
+	kinds := slices.Sorted(maps.Keys(r.Steps))
+	slices.SortStableFunc(kinds, func(a, b command) int {
+		ia, ib := slices.Index(cmds, a), slices.Index(cmds, b)
+		if ia < 0 {
+			ia = len(cmds)
+		}
+		if ib < 0 {
+			ib = len(cmds)
+		}
+		return ia - ib
+	})
+	parts := make([]string, len(kinds))
+	for i, k := range kinds {
+		parts[i] = fmt.Sprintf("%s=%s", k, time.Duration(r.Steps[k]).Round(time.Millisecond))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (r *runReport) WriteSummary(w io.Writer, color bool) error {
+	counts := map[action]int{}
+	for _, res := range r.Results {
+		counts[res.Action]++
+	}
+	fmt.Fprintf(w, "\nsummary:\n")
+	if err := r.write(w, outputTable, color); err != nil {
+		return err
+	}
+	var parts []string
+	for _, a := range []action{actionInstalled, actionUpdated, actionUninstalled, actionSkipped, actionFailed} {
+		if counts[a] > 0 {
+			part := fmt.Sprintf("%d %s", counts[a], a)
+			if color && a != actionSkipped {
+				part = paint(actionColor(&packageResult{Action: a}), part)
+			}
+			parts = append(parts, part)
+		}
+	}
+	_, err := fmt.Fprintf(w, "%s\n", strings.Join(parts, ", "))
+	return err
+}
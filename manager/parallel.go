@@ -0,0 +1,103 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+var errSkipped = errors.New("skipped")
+
+type job struct {
+	name string
+	// deps not in the same runJobs call are ignored.
+	deps []string
+	run  func(ctx context.Context, w io.Writer) error
+}
+
+// runJobs runs jobs with at most limit concurrently, each after its deps succeeded and skipped otherwise.
+// Parallel outputs are buffered per job and flushed in order. Unless keepGoing, jobs not started are skipped after a failure.
+func runJobs(ctx context.Context, limit int, keepGoing bool, out io.Writer, jobs []job) []error {
+	errs := make([]error, len(jobs))
+	index := make(map[string]int, len(jobs))
+	for i, j := range jobs {
+		index[j.name] = i
+	}
+
+	startCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	depErr := func(j job) error {
+		for _, dep := range j.deps {
+			k, ok := index[dep]
+			if ok && errs[k] != nil {
+				return fmt.Errorf("%w: dependency %q failed", errSkipped, dep)
+			}
+		}
+		return nil
+	}
+
+	if limit <= 1 {
+		for i, j := range jobs {
+			if err := depErr(j); err != nil {
+				errs[i] = err
+				continue
+			}
+			if startCtx.Err() != nil {
+				errs[i] = fmt.Errorf("%w: %w", errSkipped, startCtx.Err())
+				continue
+			}
+			errs[i] = j.run(ctx, out)
+			if errs[i] != nil && !keepGoing {
+				cancel()
+			}
+		}
+		return errs
+	}
+
+	done := make([]chan struct{}, len(jobs))
+	bufs := make([]bytes.Buffer, len(jobs))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, j := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done[i])
+			for _, dep := range j.deps {
+				if k, ok := index[dep]; ok {
+					<-done[k]
+				}
+			}
+			if err := depErr(j); err != nil {
+				errs[i] = err
+				return
+			}
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-startCtx.Done():
+			}
+			if startCtx.Err() != nil {
+				errs[i] = fmt.Errorf("%w: %w", errSkipped, startCtx.Err())
+				return
+			}
+			errs[i] = j.run(ctx, &bufs[i])
+			if errs[i] != nil && !keepGoing {
+				cancel()
+			}
+		}()
+	}
+	for i := range jobs {
+		<-done[i]
+		_, _ = io.Copy(out, &bufs[i])
+	}
+	wg.Wait()
+	return errs
+}
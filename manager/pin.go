@@ -0,0 +1,132 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+const (
+	pinnedVersionsFileName = ".pin.json"
+)
+
+func loadPinnedVersions(cfgDir string) (map[string]string, error) {
+	pinnedVersions := map[string]string{}
+	data, err := readAsJSON(filepath.Join(cfgDir, pinnedVersionsFileName))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return pinnedVersions, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &pinnedVersions); err != nil {
+		return nil, err
+	}
+	for k, v := range pinnedVersions {
+		if err := validatePin(k, v); err != nil {
+			return nil, err
+		}
+	}
+	return pinnedVersions, nil
+}
+
+func storePinnedVersions(cfgDir string, pinnedVersions map[string]string) error {
+	data, err := json.MarshalIndent(pinnedVersions, "", "    ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(cfgDir, pinnedVersionsFileName), append(data, '\n'), 0o644)
+}
+
+func validatePin(name, ver string) error {
+	if name != strings.TrimSpace(name) || ver != strings.TrimSpace(ver) {
+		return fmt.Errorf("pinned version %q has space prefix and/or suffix in name or version", name)
+	}
+	if ver != "" && isConstraint(ver) {
+		if _, err := parseConstraint(ver); err != nil {
+			return fmt.Errorf("pinned version of %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func commandSetExists(cfgDir, name string) (bool, error) {
+	for _, ext := range append(slices.Clone(setFileExts), "") {
+		p := name + ext
+		_, err := os.Stat(filepath.Join(cfgDir, p))
+		switch {
+		case err == nil:
+			return true, nil
+		case !errors.Is(err, fs.ErrNotExist):
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// pin implements the pin subcommand.
+//
+//	pin                  prints current pinned versions
+//	pin <name> <version> pins name to version
+func (m *Manager) pin(_ context.Context, args []string) error {
+	pinnedVersions, err := loadPinnedVersions(m.cfgDir)
+	if err != nil {
+		return configError(err)
+	}
+
+	switch len(args) {
+	default:
+		return configError(fmt.Errorf("pin: wrong args length: want 0 or 2, got %d", len(args)))
+	case 0:
+		out, err := json.MarshalIndent(pinnedVersions, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\n", out)
+		return nil
+	case 2:
+	}
+
+	name, ver := args[0], args[1]
+	if name == "" || ver == "" {
+		return configError(fmt.Errorf("pin: name and version must not be empty"))
+	}
+	if err := validatePin(name, ver); err != nil {
+		return configError(fmt.Errorf("pin: %w", err))
+	}
+	if strings.ContainsFunc(name, unicode.IsSpace) || strings.ContainsFunc(ver, unicode.IsSpace) {
+		return configError(fmt.Errorf("pin: name and version must not contain whitespace"))
+	}
+	ok, err := commandSetExists(m.setDir(name), name)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return configError(fmt.Errorf("pin: file %[1]q.json or directory %[1]q must exist%[2]s", name, didYouMean(name, m.setNames())))
+	}
+
+	pinnedVersions[name] = ver
+	return storePinnedVersions(m.cfgDir, pinnedVersions)
+}
+
+func (m *Manager) unpin(_ context.Context, args []string) error {
+	if len(args) != 1 {
+		return configError(fmt.Errorf("unpin: wrong args length: want 1, got %d", len(args)))
+	}
+	pinnedVersions, err := loadPinnedVersions(m.cfgDir)
+	if err != nil {
+		return configError(err)
+	}
+	if _, ok := pinnedVersions[args[0]]; !ok {
+		return configError(fmt.Errorf("unpin: %q is not pinned", args[0]))
+	}
+	delete(pinnedVersions, args[0])
+	return storePinnedVersions(m.cfgDir, pinnedVersions)
+}
@@ -0,0 +1,67 @@
+package manager
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// prefixWriter prefixes every line, like "docker compose" does, so that output of concurrent sets stays attributable.
+// Each Write is one call to w, so whole lines of concurrent writers do not mix.
+type prefixWriter struct {
+	w      io.Writer
+	prefix []byte
+
+	mu  sync.Mutex
+	mid bool
+}
+
+func newPrefixWriter(w io.Writer, prefix string) *prefixWriter {
+	return &prefixWriter{w: w, prefix: []byte(prefix)}
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := len(b)
+	var buf bytes.Buffer
+	for len(b) > 0 {
+		if !p.mid {
+			buf.Write(p.prefix)
+		}
+		i := bytes.IndexByte(b, '\n')
+		if i < 0 {
+			buf.Write(b)
+			p.mid = true
+			break
+		}
+		buf.Write(b[:i+1])
+		b = b[i+1:]
+		p.mid = false
+	}
+	if _, err := p.w.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// outputPrefix is "" unless -v is set and r runs over more than one set.
+func (r *runner) outputPrefix(name string) string {
+	if !r.opts.Verbose || len(r.sets) <= 1 {
+		return ""
+	}
+	var width int
+	for _, set := range r.sets {
+		width = max(width, len(set.Name))
+	}
+	return fmt.Sprintf("%-*s | ", width, name)
+}
+
+func (r *runner) output(name string, w io.Writer) io.Writer {
+	prefix := r.outputPrefix(name)
+	if prefix == "" || w == nil {
+		return w
+	}
+	return newPrefixWriter(w, prefix)
+}
@@ -0,0 +1,18 @@
+//go:build !unix && !windows
+
+package manager
+
+import (
+	"os"
+	"os/exec"
+	"time"
+)
+
+// setCancel only bounds waiting for output of cmd after it is killed.
+func setCancel(cmd *exec.Cmd, _ *os.File, grace time.Duration) {
+	cmd.WaitDelay = grace
+}
+
+func runCmd(cmd *exec.Cmd) error {
+	return cmd.Run()
+}
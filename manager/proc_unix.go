@@ -0,0 +1,61 @@
+//go:build unix
+
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// setCancel makes cmd receive SIGTERM once its context is done, then SIGKILL after grace.
+// cmd runs in a process group of its own, signaled as a whole, so that grandchildren do not outlive it.
+// If tty is not nil, the group is put into the foreground where possible; runCmd takes it back.
+func setCancel(cmd *exec.Cmd, tty *os.File, grace time.Duration) {
+	group := true
+	switch {
+	case tty == nil:
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	case inForeground(tty):
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Foreground: true, Ctty: int(tty.Fd())}
+	default:
+		group = false
+	}
+	cmd.Cancel = func() error {
+		pid := cmd.Process.Pid
+		if group {
+			pid = -pid
+		}
+		err := syscall.Kill(pid, syscall.SIGTERM)
+		if errors.Is(err, syscall.ESRCH) {
+			return os.ErrProcessDone
+		}
+		time.AfterFunc(grace, func() { _ = syscall.Kill(pid, syscall.SIGKILL) })
+		return err
+	}
+	// leave a moment for the group SIGKILL above, which also reaches grandchildren, to come first.
+	cmd.WaitDelay = grace + time.Second
+}
+
+var foreground sync.Mutex
+
+// runCmd takes the terminal back from a foreground command. Ctrl-C then signals only its group, so we interrupt ourselves too.
+func runCmd(cmd *exec.Cmd) error {
+	if cmd.SysProcAttr == nil || !cmd.SysProcAttr.Foreground {
+		return cmd.Run()
+	}
+	foreground.Lock()
+	defer foreground.Unlock()
+	err := cmd.Run()
+	takeTerminal(cmd.SysProcAttr.Ctty)
+	if ws, ok := cmd.ProcessState.Sys().(syscall.WaitStatus); ok && ws.Signaled() && ws.Signal() == syscall.SIGINT {
+		_ = syscall.Kill(os.Getpid(), syscall.SIGINT)
+		return fmt.Errorf("%w: %w", context.Canceled, err)
+	}
+	return err
+}
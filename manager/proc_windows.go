@@ -0,0 +1,26 @@
+//go:build windows
+
+package manager
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// setCancel kills cmd with its whole process tree once its context is done.
+// Windows has no graceful termination for console processes; grace only bounds waiting for its output.
+func setCancel(cmd *exec.Cmd, _ *os.File, grace time.Duration) {
+	cmd.Cancel = func() error {
+		if err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run(); err != nil {
+			return cmd.Process.Kill()
+		}
+		return nil
+	}
+	cmd.WaitDelay = grace
+}
+
+func runCmd(cmd *exec.Cmd) error {
+	return cmd.Run()
+}
@@ -0,0 +1,82 @@
+package manager
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+)
+
+// profile selects the sets one kind of machine uses, e.g. "work" or "server".
+type profile struct {
+	// Sets are names or path.Match patterns, e.g. ["ripgrep", "k9s*"].
+	Sets []string `json:"sets,omitzero"`
+	Tags []string `json:"tags,omitzero"`
+}
+
+func (p profile) Validate() error {
+	if len(p.Sets) == 0 && len(p.Tags) == 0 {
+		return fmt.Errorf("either sets or tags must be set")
+	}
+	for _, pat := range p.Sets {
+		if pat == "" {
+			return fmt.Errorf("sets: empty pattern")
+		}
+		if _, err := path.Match(pat, ""); err != nil {
+			return fmt.Errorf("sets: %q: %w", pat, err)
+		}
+	}
+	for _, t := range p.Tags {
+		if t == "" {
+			return fmt.Errorf("tags: empty tag")
+		}
+	}
+	return nil
+}
+
+func (p profile) includes(set namedCommandSet) bool {
+	for _, pat := range p.Sets {
+		if ok, _ := path.Match(pat, set.Name); ok {
+			return true
+		}
+	}
+	return slices.ContainsFunc(p.Tags, func(t string) bool { return slices.Contains(set.Set.Tags, t) })
+}
+
+// applyProfile keeps sets named explicitly in tgt.
+func (m *Manager) applyProfile(sets []namedCommandSet, tgt string) ([]namedCommandSet, error) {
+	if m.opts.Profile == "" {
+		return sets, nil
+	}
+	cfg, err := loadGlobalConfig(m.cfgDir)
+	if err != nil {
+		return nil, err
+	}
+	p, ok := cfg.Profiles[m.opts.Profile]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q", m.opts.Profile)
+	}
+	explicit := explicitNames(tgt)
+	sets = slices.DeleteFunc(sets, func(set namedCommandSet) bool {
+		return !p.includes(set) && !slices.Contains(explicit, set.Name)
+	})
+	if len(sets) == 0 {
+		return nil, fmt.Errorf("profile %q selects no command set", m.opts.Profile)
+	}
+	return sets, nil
+}
+
+func validateProfiles(profiles map[string]profile, sets []namedCommandSet) []string {
+	var problems []string
+	for _, name := range slices.Sorted(maps.Keys(profiles)) {
+		for _, pat := range profiles[name].Sets {
+			if !slices.ContainsFunc(sets, func(s namedCommandSet) bool {
+				ok, _ := path.Match(pat, s.Name)
+				return ok
+			}) {
+				problems = append(problems, fmt.Sprintf("profiles.%s: %q matches no command set", name, pat))
+			}
+		}
+	}
+	return problems
+}
@@ -0,0 +1,48 @@
+package manager
+
+import (
+	"bytes"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	ioctlGetTermios = syscall.TIOCGETA
+	ioctlSetTermios = syscall.TIOCSETA
+	ioctlGetWinsize = syscall.TIOCGWINSZ
+	ioctlSetWinsize = syscall.TIOCSWINSZ
+)
+
+func selectRead(nfd int, r *syscall.FdSet, timeout *syscall.Timeval) error {
+	return syscall.Select(nfd, r, nil, nil, timeout)
+}
+
+// openPTY opens a new pseudo-terminal pair, as posix_openpt, grantpt, unlockpt and ptsname do.
+func openPTY() (master, slave *os.File, err error) {
+	master, err = os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	var name [128]byte
+	for _, req := range []uintptr{syscall.TIOCPTYGRANT, syscall.TIOCPTYUNLK, syscall.TIOCPTYGNAME} {
+		var arg unsafe.Pointer
+		if req == syscall.TIOCPTYGNAME {
+			arg = unsafe.Pointer(&name)
+		}
+		if err := ioctl(master, req, arg); err != nil {
+			_ = master.Close()
+			return nil, nil, err
+		}
+	}
+	n := bytes.IndexByte(name[:], 0)
+	if n < 0 {
+		n = len(name)
+	}
+	slave, err = os.OpenFile(string(name[:n]), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		_ = master.Close()
+		return nil, nil, err
+	}
+	return master, slave, nil
+}
@@ -0,0 +1,19 @@
+//go:build !linux && !darwin
+
+package manager
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// runInPTY fails; pseudo-terminals are supported only on Linux and macOS.
+func runInPTY(_ *exec.Cmd) error {
+	return fmt.Errorf("pty is not supported on %s", runtime.GOOS)
+}
+
+func inForeground(_ *os.File) bool { return false }
+
+func takeTerminal(_ int) {}
@@ -0,0 +1,226 @@
+//go:build linux || darwin
+
+package manager
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// runInPTY runs cmd with a pseudo-terminal as its stdin, stdout and stderr. If cmd.Stdin is a terminal,
+// it is put into raw mode and what the user types is forwarded, so that prompts, and Ctrl-C, reach the command.
+func runInPTY(cmd *exec.Cmd) error {
+	master, slave, err := openPTY()
+	if err != nil {
+		return err
+	}
+	defer master.Close()
+
+	stdin, _ := cmd.Stdin.(*os.File)
+	interactive := stdin != nil && isTerminal(stdin)
+	if interactive {
+		var ws [4]uint16 // rows, columns, x and y pixels
+		if ioctl(stdin, ioctlGetWinsize, unsafe.Pointer(&ws)) == nil {
+			_ = ioctl(slave, ioctlSetWinsize, unsafe.Pointer(&ws))
+		}
+	}
+
+	out := cmd.Stdout
+	if out == nil {
+		out = io.Discard
+	}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = slave, slave, slave
+	// Setpgid, set by setCancel, fails for session leaders. The session leader leads its process group too.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true, Ctty: 0}
+	err = cmd.Start()
+	_ = slave.Close()
+	if err != nil {
+		return err
+	}
+
+	if interactive {
+		if restore, err := makeRaw(stdin); err == nil {
+			defer restore()
+		}
+		defer forwardStdin(stdin, master)()
+	} else {
+		_, _ = master.Write([]byte{4}) // ^D
+	}
+
+	copied := make(chan struct{})
+	go func() {
+		// reading fails with EIO once every process closes the terminal.
+		_, _ = io.Copy(out, master)
+		close(copied)
+	}()
+	err = cmd.Wait()
+	select {
+	case <-copied:
+	case <-time.After(time.Second):
+		// background processes still hold the terminal. Closing master ends the copy,
+		// which must not write to out once we return.
+		_ = master.Close()
+		<-copied
+	}
+	return err
+}
+
+// stdinForwarder makes only one forwarder read at a time, so that concurrent pty commands do not split input.
+var stdinForwarder struct {
+	mu     sync.Mutex
+	active bool
+}
+
+// forwardStdin forwards stdin to dst until stop returns, after which nothing more is read. It does nothing if stdin is already forwarded.
+func forwardStdin(stdin *os.File, dst io.Writer) (stop func()) {
+	f := &stdinForwarder
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.active {
+		return func() {}
+	}
+	f.active = true
+
+	var stopped atomic.Bool
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buf := make([]byte, 1024)
+		for !stopped.Load() {
+			// a pending read can not be cancelled, so read only what is there.
+			ready, err := waitReadable(stdin, 100*time.Millisecond)
+			if err != nil {
+				return
+			}
+			if !ready {
+				continue
+			}
+			n, err := stdin.Read(buf)
+			if n > 0 {
+				_, _ = dst.Write(buf[:n])
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return func() {
+		stopped.Store(true)
+		<-done
+		f.mu.Lock()
+		f.active = false
+		f.mu.Unlock()
+	}
+}
+
+func waitReadable(f *os.File, timeout time.Duration) (bool, error) {
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return false, err
+	}
+	var ready bool
+	var selErr error
+	err = rc.Control(func(fd uintptr) {
+		var set syscall.FdSet
+		n := uintptr(unsafe.Sizeof(set.Bits[0])) * 8
+		set.Bits[fd/n] |= 1 << (fd % n)
+		tv := syscall.NsecToTimeval(timeout.Nanoseconds())
+		selErr = selectRead(int(fd)+1, &set, &tv)
+		ready = selErr == nil && set.Bits[fd/n]&(1<<(fd%n)) != 0
+	})
+	if err != nil {
+		return false, err
+	}
+	if selErr == syscall.EINTR {
+		return false, nil
+	}
+	return ready, selErr
+}
+
+// rawTerminal makes concurrent pty commands put the terminal into raw mode once and restore the mode from before the first.
+var rawTerminal struct {
+	mu      sync.Mutex
+	n       int
+	restore func()
+}
+
+func makeRaw(f *os.File) (restore func(), err error) {
+	t := &rawTerminal
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.n == 0 {
+		if t.restore, err = setRaw(f); err != nil {
+			return nil, err
+		}
+	}
+	t.n++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if t.n--; t.n == 0 {
+				t.restore()
+			}
+		})
+	}, nil
+}
+
+// setRaw puts f into raw mode, like cfmakeraw(3).
+func setRaw(f *os.File) (restore func(), err error) {
+	var old syscall.Termios
+	if err := ioctl(f, ioctlGetTermios, unsafe.Pointer(&old)); err != nil {
+		return nil, err
+	}
+	raw := old
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := ioctl(f, ioctlSetTermios, unsafe.Pointer(&raw)); err != nil {
+		return nil, err
+	}
+	return func() { _ = ioctl(f, ioctlSetTermios, unsafe.Pointer(&old)) }, nil
+}
+
+func inForeground(f *os.File) bool {
+	var pgrp int32
+	return ioctl(f, syscall.TIOCGPGRP, unsafe.Pointer(&pgrp)) == nil && int(pgrp) == syscall.Getpgrp()
+}
+
+// takeTerminal puts our process group back into the foreground. tcsetpgrp(3) from a background group raises SIGTTOU unless ignored.
+func takeTerminal(fd int) {
+	signal.Ignore(syscall.SIGTTOU)
+	defer signal.Reset(syscall.SIGTTOU)
+	pgrp := int32(syscall.Getpgrp())
+	_, _, _ = syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.TIOCSPGRP, uintptr(unsafe.Pointer(&pgrp)))
+}
+
+// ioctl does not put f into blocking mode, which f.Fd would do.
+func ioctl(f *os.File, req uintptr, arg unsafe.Pointer) error {
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno syscall.Errno
+	err = rc.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(arg))
+	})
+	if err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
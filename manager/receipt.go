@@ -0,0 +1,90 @@
+package manager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+const receiptsDirName = ".receipts"
+
+// receipt records what a backend created installing a set, so that uninstall removes exactly that.
+type receipt struct {
+	Version     string    `json:"version"`
+	Backend     string    `json:"backend"`
+	InstalledAt time.Time `json:"installed_at"`
+	// Paths are removed recursively, never following links.
+	Paths []string `json:"paths"`
+}
+
+func receiptPath(cfgDir, name string) string {
+	return filepath.Join(cfgDir, receiptsDirName, name+".json")
+}
+
+func loadReceipt(cfgDir, name string) (rec receipt, ok bool, err error) {
+	data, err := os.ReadFile(receiptPath(cfgDir, name))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return rec, false, nil
+		}
+		return rec, false, err
+	}
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return rec, false, fmt.Errorf("receipt of %q: %w", name, err)
+	}
+	return rec, true, nil
+}
+
+func storeReceipt(cfgDir, name string, rec receipt) error {
+	if err := os.MkdirAll(filepath.Join(cfgDir, receiptsDirName), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(rec, "", "    ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(receiptPath(cfgDir, name), append(data, '\n'), 0o644)
+}
+
+// recordInstall removes paths of the previous receipt not created this time. Without a config dir, it does nothing.
+func (e commandExecutor) recordInstall(b backend, ver string, paths []string) error {
+	if e.cfgDir == "" {
+		return nil
+	}
+	name := e.commandSet.Name
+	prev, ok, err := loadReceipt(e.cfgDir, name)
+	if err != nil {
+		e.log.Warn("ignoring broken receipt", "set", name, "err", err)
+	}
+	if ok {
+		for _, p := range prev.Paths {
+			if !slices.Contains(paths, p) {
+				if err := os.RemoveAll(p); err != nil {
+					return err
+				}
+				e.log.Debug("removed left over", "set", name, "path", p)
+			}
+		}
+	}
+	return storeReceipt(e.cfgDir, name, receipt{
+		Version:     ver,
+		Backend:     backendName(b),
+		InstalledAt: time.Now().UTC(),
+		Paths:       paths,
+	})
+}
+
+func (e commandExecutor) uninstallByReceipt(rec receipt) error {
+	for _, p := range rec.Paths {
+		if err := os.RemoveAll(p); err != nil {
+			return err
+		}
+		fmt.Fprintf(e.stdout, "removed %s\n", p)
+	}
+	return os.Remove(receiptPath(e.cfgDir, e.commandSet.Name))
+}
@@ -0,0 +1,212 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Backends for language package managers. Each is the package name. Checklatest asks the registry.
+//
+//	{"cargo": "ripgrep"}
+//	{"npm": "typescript"}
+//	{"pipx": "black"}
+
+var (
+	cratesAPI   = "https://crates.io/api/v1/crates"
+	npmRegistry = "https://registry.npmjs.org"
+	pypiAPI     = "https://pypi.org/pypi"
+)
+
+var registryCommands = []command{commandVer, commandChecklatest, commandInstall, commandUpdate, commandUninstall}
+
+func validatePackageName(backend, name string) error {
+	if name == "" || strings.ContainsAny(name, " \t\n") {
+		return fmt.Errorf("%s: invalid package name %q", backend, name)
+	}
+	return nil
+}
+
+func fetchJSON(ctx context.Context, url string, v any) error {
+	data, err := fetch(ctx, url)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("GET %s: %w", url, err)
+	}
+	return nil
+}
+
+func run(ctx context.Context, e commandExecutor, args ...string) (string, error) {
+	cmd := e.command(ctx, args, e.dict(""))
+	var out strings.Builder
+	cmd.Stdout = &out
+	if err := runCmd(cmd); err != nil {
+		return "", fmt.Errorf("%s: %w", strings.Join(args, " "), err)
+	}
+	return out.String(), nil
+}
+
+func runOut(ctx context.Context, e commandExecutor, args ...string) error {
+	cmd := e.command(ctx, args, e.dict(""))
+	cmd.Stdout = e.stdout
+	return runCmd(cmd)
+}
+
+type cargoBackend string
+
+func (c *cargoBackend) Commands() []command { return registryCommands }
+
+func (c *cargoBackend) Validate() error { return validatePackageName("cargo", string(*c)) }
+
+func (c *cargoBackend) Exec(ctx context.Context, e commandExecutor, kind command, ver string) (string, error) {
+	name := string(*c)
+	switch kind {
+	case commandVer:
+		// "ripgrep v14.1.0:" followed by indented binaries.
+		out, err := run(ctx, e, "cargo", "install", "--list")
+		if err != nil {
+			return "", err
+		}
+		for line := range strings.Lines(out) {
+			if v, ok := strings.CutPrefix(line, name+" v"); ok {
+				v, _, _ = strings.Cut(v, ":")
+				v, _, _ = strings.Cut(v, " ")
+				return v, nil
+			}
+		}
+		return "", fmt.Errorf("cargo: %s is not installed", name)
+	case commandChecklatest:
+		var res struct {
+			Crate struct {
+				MaxStableVersion string `json:"max_stable_version"`
+				MaxVersion       string `json:"max_version"`
+			} `json:"crate"`
+		}
+		if err := fetchJSON(ctx, cratesAPI+"/"+url.PathEscape(name), &res); err != nil {
+			return "", fmt.Errorf("cargo: %w", err)
+		}
+		if res.Crate.MaxStableVersion != "" {
+			return res.Crate.MaxStableVersion, nil
+		}
+		return res.Crate.MaxVersion, nil
+	case commandInstall, commandUpdate:
+		args := []string{"cargo", "install", "--locked", name}
+		if ver != "" {
+			args = append(args, "--version", ver)
+		}
+		return "", runOut(ctx, e, args...)
+	case commandUninstall:
+		return "", runOut(ctx, e, "cargo", "uninstall", name)
+	}
+	return "", fmt.Errorf("cargo: %s is not supported", kind)
+}
+
+type npmBackend string
+
+func (n *npmBackend) Commands() []command { return registryCommands }
+
+func (n *npmBackend) Validate() error { return validatePackageName("npm", string(*n)) }
+
+func (n *npmBackend) Exec(ctx context.Context, e commandExecutor, kind command, ver string) (string, error) {
+	name := string(*n)
+	switch kind {
+	case commandVer:
+		// npm ls exits non-zero when the package is missing, but still prints the JSON.
+		out, _ := run(ctx, e, "npm", "ls", "--global", "--depth=0", "--json", name)
+		var res struct {
+			Dependencies map[string]struct {
+				Version string `json:"version"`
+			} `json:"dependencies"`
+		}
+		if err := json.Unmarshal([]byte(out), &res); err != nil {
+			return "", fmt.Errorf("npm: decoding npm ls: %w", err)
+		}
+		dep, ok := res.Dependencies[name]
+		if !ok || dep.Version == "" {
+			return "", fmt.Errorf("npm: %s is not installed", name)
+		}
+		return dep.Version, nil
+	case commandChecklatest:
+		var res struct {
+			Version string `json:"version"`
+		}
+		// Scoped packages keep their leading "@" but escape the slash: "@scope%2Fname".
+		if err := fetchJSON(ctx, npmRegistry+"/"+strings.ReplaceAll(name, "/", "%2F")+"/latest", &res); err != nil {
+			return "", fmt.Errorf("npm: %w", err)
+		}
+		return res.Version, nil
+	case commandInstall, commandUpdate:
+		if ver == "" {
+			ver = "latest"
+		}
+		return "", runOut(ctx, e, "npm", "install", "--global", name+"@"+ver)
+	case commandUninstall:
+		return "", runOut(ctx, e, "npm", "uninstall", "--global", name)
+	}
+	return "", fmt.Errorf("npm: %s is not supported", kind)
+}
+
+type pipxBackend string
+
+func (p *pipxBackend) Commands() []command { return registryCommands }
+
+func (p *pipxBackend) Validate() error { return validatePackageName("pipx", string(*p)) }
+
+func (p *pipxBackend) Exec(ctx context.Context, e commandExecutor, kind command, ver string) (string, error) {
+	name := string(*p)
+	switch kind {
+	case commandVer:
+		out, err := run(ctx, e, "pipx", "list", "--json")
+		if err != nil {
+			return "", err
+		}
+		var res struct {
+			Venvs map[string]struct {
+				Metadata struct {
+					MainPackage struct {
+						PackageVersion string `json:"package_version"`
+					} `json:"main_package"`
+				} `json:"metadata"`
+			} `json:"venvs"`
+		}
+		if err := json.Unmarshal([]byte(out), &res); err != nil {
+			return "", fmt.Errorf("pipx: decoding pipx list: %w", err)
+		}
+		venv, ok := res.Venvs[name]
+		if !ok {
+			return "", fmt.Errorf("pipx: %s is not installed", name)
+		}
+		return venv.Metadata.MainPackage.PackageVersion, nil
+	case commandChecklatest:
+		var res struct {
+			Info struct {
+				Version string `json:"version"`
+			} `json:"info"`
+		}
+		if err := fetchJSON(ctx, pypiAPI+"/"+url.PathEscape(name)+"/json", &res); err != nil {
+			return "", fmt.Errorf("pipx: %w", err)
+		}
+		return res.Info.Version, nil
+	case commandInstall, commandUpdate:
+		// pipx upgrade cannot pin a version, so an update reinstalls at ver.
+		switch {
+		case ver != "":
+			args := []string{"pipx", "install", name + "==" + ver}
+			if kind == commandUpdate {
+				args = append(args, "--force")
+			}
+			return "", runOut(ctx, e, args...)
+		case kind == commandUpdate:
+			return "", runOut(ctx, e, "pipx", "upgrade", name)
+		default:
+			return "", runOut(ctx, e, "pipx", "install", name)
+		}
+	case commandUninstall:
+		return "", runOut(ctx, e, "pipx", "uninstall", name)
+	}
+	return "", fmt.Errorf("pipx: %s is not supported", kind)
+}
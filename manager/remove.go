@@ -0,0 +1,194 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// remove deletes name.json, directory name, its pin and its entry in .disabled.json. Sets depending on name are refused unless -f.
+//
+//	remove <name>
+func (m *Manager) remove(_ context.Context, args []string) error {
+	if len(args) != 1 {
+		return configError(fmt.Errorf("remove: wrong args length: want 1, got %d", len(args)))
+	}
+	name := args[0]
+	if err := checkSetExists(m.cfgDir, name); err != nil {
+		return configError(fmt.Errorf("remove: %w", err))
+	}
+	if dependents := dependentsOf(m.cfgDir, name); len(dependents) > 0 {
+		if !m.opts.Force {
+			return configError(fmt.Errorf("remove: %q is required by %s: use -f to remove anyway", name, strings.Join(dependents, ", ")))
+		}
+		m.logger().Warn("removing a required set", "set", name, "required_by", strings.Join(dependents, ","))
+	}
+	pinnedVersions, err := loadPinnedVersions(m.cfgDir)
+	if err != nil {
+		return configError(err)
+	}
+	disabled, err := loadDisabled(m.cfgDir)
+	if err != nil {
+		return configError(err)
+	}
+
+	// Files are first moved aside so that they can be put back if updating .pin.json fails.
+	trash, err := os.MkdirTemp(m.cfgDir, ".remove-"+name+"-*")
+	if err != nil {
+		return err
+	}
+	moved, err := moveSet(m.cfgDir, name, trash, name)
+	if err == nil {
+		if _, ok := pinnedVersions[name]; ok {
+			delete(pinnedVersions, name)
+			err = storePinnedVersions(m.cfgDir, pinnedVersions)
+		}
+	}
+	if i := slices.Index(disabled, name); err == nil && i >= 0 {
+		err = storeDisabled(m.cfgDir, slices.Delete(disabled, i, i+1))
+	}
+	if err != nil {
+		undoMoves(moved)
+		_ = os.RemoveAll(trash)
+		return fmt.Errorf("remove: %w", err)
+	}
+	if err := os.RemoveAll(trash); err != nil {
+		return fmt.Errorf("remove: %w", err)
+	}
+	removeLatestCache(name)
+	fmt.Printf("removed %q\n", name)
+	return nil
+}
+
+// rename renames old.json, directory old, its pin and its entry in .disabled.json. Sets referring to old are only reported.
+//
+//	rename <old> <new>
+func (m *Manager) rename(_ context.Context, args []string) error {
+	if len(args) != 2 {
+		return configError(fmt.Errorf("rename: wrong args length: want 2, got %d", len(args)))
+	}
+	oldName, newName := args[0], args[1]
+	if err := checkSetExists(m.cfgDir, oldName); err != nil {
+		return configError(fmt.Errorf("rename: %w", err))
+	}
+	if err := validateSetName(newName); err != nil {
+		return configError(fmt.Errorf("rename: %w", err))
+	}
+	ok, err := commandSetExists(m.cfgDir, newName)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return configError(fmt.Errorf("rename: %q already exists", newName))
+	}
+	pinnedVersions, err := loadPinnedVersions(m.cfgDir)
+	if err != nil {
+		return configError(err)
+	}
+	disabled, err := loadDisabled(m.cfgDir)
+	if err != nil {
+		return configError(err)
+	}
+
+	moved, err := moveSet(m.cfgDir, oldName, m.cfgDir, newName)
+	if err == nil {
+		if ver, ok := pinnedVersions[oldName]; ok {
+			delete(pinnedVersions, oldName)
+			pinnedVersions[newName] = ver
+			err = storePinnedVersions(m.cfgDir, pinnedVersions)
+		}
+	}
+	if i := slices.Index(disabled, oldName); err == nil && i >= 0 {
+		disabled[i] = newName
+		err = storeDisabled(m.cfgDir, disabled)
+	}
+	if err != nil {
+		undoMoves(moved)
+		return fmt.Errorf("rename: %w", err)
+	}
+	removeLatestCache(oldName)
+	fmt.Printf("renamed %q to %q\n", oldName, newName)
+	if refs := referrersOf(m.cfgDir, oldName); len(refs) > 0 {
+		m.logger().Warn("still referred to in after, deps or requires", "set", oldName, "referrers", strings.Join(refs, ","))
+	}
+	return nil
+}
+
+type move struct{ from, to string }
+
+// moveSet returns renames done so far, even on error.
+func moveSet(srcDir, name, dstDir, newName string) ([]move, error) {
+	var moved []move
+	for _, suffix := range append(slices.Clone(setFileExts), "") {
+		from, to := filepath.Join(srcDir, name+suffix), filepath.Join(dstDir, newName+suffix)
+		err := os.Rename(from, to)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			continue
+		case err != nil:
+			return moved, err
+		}
+		moved = append(moved, move{from, to})
+	}
+	return moved, nil
+}
+
+func undoMoves(moved []move) {
+	for _, mv := range slices.Backward(moved) {
+		_ = os.Rename(mv.to, mv.from)
+	}
+}
+
+func checkSetExists(cfgDir, name string) error {
+	if err := validateSetName(name); err != nil {
+		return err
+	}
+	ok, err := commandSetExists(cfgDir, name)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		sets, _, _ := readCommandSets(cfgDir)
+		return fmt.Errorf("file %[1]q.json or directory %[1]q must exist%[2]s", name, didYouMean(name, setNames(sets)))
+	}
+	return nil
+}
+
+func validateSetName(name string) error {
+	switch {
+	case name == "" || strings.HasPrefix(name, "."):
+		return fmt.Errorf("invalid command set name %q", name)
+	case strings.ContainsAny(name, `/\,`) || filepath.Base(name) != name:
+		return fmt.Errorf("invalid command set name %q: must not contain path separators or commas", name)
+	case name == globalConfigName:
+		return fmt.Errorf("%q is reserved for %s", name, globalConfigFileName)
+	}
+	return nil
+}
+
+func dependentsOf(cfgDir, name string) []string {
+	sets, _, _ := readCommandSets(cfgDir)
+	var names []string
+	for _, s := range sets {
+		if s.Name != name && slices.Contains(s.Set.dependencies(), name) {
+			names = append(names, s.Name)
+		}
+	}
+	return names
+}
+
+func referrersOf(cfgDir, name string) []string {
+	sets, _, _ := readCommandSets(cfgDir)
+	var names []string
+	for _, s := range sets {
+		if slices.Contains(s.Set.After, name) || slices.Contains(s.Set.dependencies(), name) {
+			names = append(names, s.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,691 @@
+package manager
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+type runner struct {
+	cmd    command
+	cfgDir string
+	// args are appended to a user-defined command.
+	args           []string
+	opts           Options
+	sets           []namedCommandSet
+	pinnedVersions map[string]string
+	// requested are versions given as name@version, also in pinnedVersions.
+	requested map[string]string
+	format    outputFormat
+	defaults  executorDefaults
+	// logw is stderr in json mode so that stdout only has the report.
+	logw io.Writer
+	log  *slog.Logger
+	// stderr receives stderr of commands not run in parallel.
+	stderr  io.Writer
+	logFile string
+	report  *runReport
+	notify  *notifyConfig
+	// trace is nil unless tracing is configured.
+	trace *tracer
+
+	mu sync.Mutex
+	// running are sets whose jobs are running, for telling what was interrupted.
+	running         map[string]bool
+	currentVersions map[string]string
+	latestVersions  map[string]string
+	// targetVersions are versions sets should be at after install / update. Empty means any.
+	targetVersions map[string]string
+}
+
+func (m *Manager) newRunner(
+	cmd command,
+	sets []namedCommandSet,
+	pinnedVersions map[string]string,
+	format outputFormat,
+) (*runner, error) {
+	globalCfg, err := loadGlobalConfig(m.cfgDir)
+	if err != nil {
+		return nil, configError(err)
+	}
+	var logw io.Writer = os.Stdout
+	if format.structured() {
+		logw = os.Stderr
+	}
+	managed, err := globalCfg.Managed.dir()
+	if err != nil {
+		return nil, configError(err)
+	}
+	opts := globalCfg.apply(m.opts)
+	if err := opts.validateLog(); err != nil {
+		return nil, configError(err)
+	}
+	logFile := m.logFile(opts)
+	return &runner{
+		cmd:            cmd,
+		cfgDir:         m.cfgDir,
+		opts:           opts,
+		sets:           sets,
+		pinnedVersions: pinnedVersions,
+		format:         format,
+		defaults: executorDefaults{
+			Timeout:        opts.Timeout,
+			Retries:        opts.Retries,
+			Hooks:          globalCfg.Hooks,
+			CacheTTL:       opts.CacheTTL,
+			Refresh:        opts.Refresh,
+			Shell:          globalCfg.Shell,
+			GitHubToken:    globalCfg.githubToken(),
+			BinDir:         globalCfg.BinDir,
+			Managed:        managed,
+			CfgDir:         m.cfgDir,
+			NonInteractive: opts.NonInteractive,
+		},
+		logw:    logw,
+		log:     newLogger(logw, opts, logFile),
+		stderr:  os.Stderr,
+		logFile: logFile,
+		notify:  globalCfg.Notify,
+		trace: globalCfg.Tracing.newTracer("ngpkgmgr "+string(cmd), map[string]string{
+			"pkgmgr.command": string(cmd),
+			"pkgmgr.sets":    strings.Join(setNames(sets), ","),
+		}),
+		report:          newRunReport(cmd, sets, pinnedVersions),
+		running:         map[string]bool{},
+		currentVersions: map[string]string{},
+		latestVersions:  map[string]string{},
+		targetVersions:  map[string]string{},
+	}, nil
+}
+
+func (r *runner) Run(ctx context.Context) error {
+	cmd := r.cmd
+	stop := context.AfterFunc(ctx, func() {
+		r.mu.Lock()
+		running := slices.Sorted(maps.Keys(r.running))
+		r.mu.Unlock()
+		if len(running) > 0 {
+			r.log.Warn("interrupted: stopping commands", "sets", strings.Join(running, ","))
+		}
+	})
+	defer stop()
+
+	var err error
+	switch cmd {
+	case commandInstall:
+		err = r.install(ctx)
+	case commandVer:
+		err = r.ver(ctx)
+	case commandUninstall:
+		err = r.uninstall(ctx)
+	case commandChecklatest:
+		_, err = r.checkVersions(ctx)
+	case commandUpdate:
+		err = r.update(ctx)
+	default:
+		err = r.runUser(ctx)
+	}
+	return r.finish(ctx, err)
+}
+
+func (r *runner) finish(ctx context.Context, err error) error {
+	cmd := r.cmd
+	var verifyErr error
+	if err == nil && r.opts.VerifyAfter && !r.opts.DryRun && (cmd == commandInstall || cmd == commandUpdate) {
+		verifyErr = r.verify(ctx)
+	}
+
+	if !r.opts.DryRun && (cmd == commandInstall || cmd == commandUpdate || cmd == commandUninstall) {
+		if histErr := appendHistory(r.cfgDir, historyEntries(r.report, time.Now())); histErr != nil {
+			r.log.Warn("recording history failed", "err", histErr)
+		}
+	}
+	if !r.opts.DryRun && cmd == commandUpdate && r.notify.shouldNotify(r.report) {
+		// notify even when interrupted; that is when a scheduled job needs it most.
+		if notifyErr := r.notify.Notify(context.WithoutCancel(ctx), r.report); notifyErr != nil {
+			r.log.Warn("notifying failed", "err", notifyErr)
+		}
+	}
+
+	// exported even when interrupted, like notifications.
+	if traceErr := r.trace.export(context.WithoutCancel(ctx), cmp.Or(err, verifyErr, r.report.Failure())); traceErr != nil {
+		r.log.Warn("exporting trace failed", "err", traceErr)
+	}
+
+	if r.format.structured() {
+		if encErr := r.report.Write(os.Stdout, r.format); encErr != nil && err == nil {
+			err = encErr
+		}
+	} else if len(r.sets) > 1 && (cmd == commandInstall || cmd == commandUpdate || cmd == commandUninstall) {
+		_ = r.report.WriteSummary(r.logw, useColor(r.opts.Color, r.logw))
+	}
+
+	if err != nil {
+		return err
+	}
+	if verifyErr != nil {
+		return verifyErr
+	}
+	return r.report.Failure()
+}
+
+func (r *runner) executor(set namedCommandSet) *commandExecutor {
+	e := newCommandExecutor(set, r.defaults, r.stdin(), r.stdout(set.Name, r.logw), r.output(set.Name, r.stderr))
+	e.log = r.log
+	e.observe = r.observe(set.Name)
+	return e
+}
+
+func (r *runner) stdin() io.Reader {
+	if r.opts.NonInteractive {
+		return nil
+	}
+	return os.Stdin
+}
+
+// stdout discards output under -q unless r runs a user-defined command.
+func (r *runner) stdout(name string, w io.Writer) io.Writer {
+	if r.opts.Quiet && slices.Contains(cmds, r.cmd) {
+		return io.Discard
+	}
+	return r.output(name, w)
+}
+
+func (r *runner) logger(w io.Writer) *slog.Logger {
+	return newLogger(w, r.opts, r.logFile)
+}
+
+func (r *runner) measure(name string) func() {
+	start := time.Now()
+	return func() {
+		r.report.Get(name).Duration = ptr(duration(time.Since(start)))
+	}
+}
+
+func (r *runner) observe(name string) func(command, string, time.Time, error) {
+	return func(kind command, ver string, start time.Time, err error) {
+		d := time.Since(start)
+		r.trace.add(name+" "+string(kind), start, map[string]string{
+			"pkgmgr.set":     name,
+			"pkgmgr.command": string(kind),
+			"pkgmgr.version": ver,
+		}, err)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		res := r.report.Get(name)
+		if res.Steps == nil {
+			res.Steps = map[command]duration{}
+		}
+		res.Steps[kind] += duration(d)
+	}
+}
+
+// jobs converts sets into jobs running fn. Jobs run in parallel write stdout and stderr into w and receive no stdin.
+func (r *runner) jobs(fn func(ctx context.Context, executor *commandExecutor, log *slog.Logger) error) []job {
+	js := make([]job, len(r.sets))
+	for i, set := range r.sets {
+		js[i] = job{
+			name: set.Name,
+			deps: set.Set.dependencies(),
+			run: func(ctx context.Context, w io.Writer) error {
+				defer r.measure(set.Name)()
+				r.mu.Lock()
+				r.running[set.Name] = true
+				r.mu.Unlock()
+				defer func() {
+					r.mu.Lock()
+					delete(r.running, set.Name)
+					r.mu.Unlock()
+				}()
+				var executor *commandExecutor
+				if r.opts.Parallel > 1 {
+					executor = newCommandExecutor(set, r.defaults, nil, r.stdout(set.Name, w), r.output(set.Name, w))
+				} else {
+					executor = newCommandExecutor(set, r.defaults, r.stdin(), r.stdout(set.Name, w), r.output(set.Name, r.stderr))
+				}
+				log := r.logger(w)
+				executor.log = log
+				executor.observe = r.observe(set.Name)
+				return fn(ctx, executor, log)
+			},
+		}
+	}
+	return js
+}
+
+func (r *runner) jobErrors(cmd command, errs []error) error {
+	for i, err := range errs {
+		if err != nil && errors.Is(err, errSkipped) {
+			err := fmt.Errorf("%s %q: %w", cmd, r.sets[i].Name, err)
+			r.report.Get(r.sets[i].Name).Fail(err)
+			r.log.Warn("skipped", "set", r.sets[i].Name, "err", err)
+		}
+	}
+	if r.opts.Force {
+		return nil
+	}
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, errSkipped) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *runner) install(ctx context.Context) error {
+	install := func(ctx context.Context, executor *commandExecutor, log *slog.Logger) error {
+		name := executor.commandSet.Name
+		res := r.report.Get(name)
+		if !r.opts.DryRun {
+			log.Info("installing", "set", name)
+		}
+		requested := r.requested[name]
+		out, err := executor.Exec(ctx, commandVer, "", false)
+		installed := err == nil && len(out) > 0
+		switch {
+		case installed && r.opts.Reinstall:
+			res.Current = strings.TrimSpace(out)
+			if !r.opts.DryRun {
+				log.Info("reinstalling over installed version", "set", name, "version", res.Current)
+			}
+		case installed && requested != "" && !atPin(executor.commandSet.Set.Versioning, strings.TrimSpace(out), requested):
+			res.Current = strings.TrimSpace(out)
+			if !r.opts.DryRun {
+				log.Info("installing requested version over installed one", "set", name, "version", res.Current, "requested", requested)
+			}
+		case installed:
+			r.mu.Lock()
+			r.targetVersions[executor.commandSet.Name] = strings.TrimSpace(out)
+			r.mu.Unlock()
+			res.Current, res.Action = strings.TrimSpace(out), actionSkipped
+			if r.opts.DryRun {
+				log.Info("[dry-run] would skip: seems already installed", "set", name, "version", res.Current)
+				return nil
+			}
+			log.Info("skipping: seems already installed", "set", name, "version", res.Current)
+			return nil
+		}
+
+		var ver string
+		if requested == "" || isConstraint(requested) {
+			out, err = executor.Exec(ctx, commandChecklatest, "", false)
+			ver = strings.TrimSpace(out)
+			if err != nil {
+				ver = ""
+				log.Warn("fetching latest version failed, trying with no version specified", "set", name, "err", err)
+			}
+		}
+		res.Latest, res.Target = ver, ver
+		if pin := r.pinnedVersions[name]; pin != "" {
+			res.Target, err = executor.resolvePin(ctx, pin, "", ver)
+			if err != nil {
+				err := fmt.Errorf("install %q: %w", name, err)
+				res.Fail(err)
+				log.Warn("failed", "set", name, "err", err)
+				return err
+			}
+		}
+
+		if r.opts.DryRun {
+			log.Info("[dry-run] would install", "set", name, "version", cmp.Or(res.Target, "(unspecified)"))
+			return nil
+		}
+
+		r.mu.Lock()
+		r.targetVersions[executor.commandSet.Name] = res.Target
+		r.mu.Unlock()
+		_, err = executor.Exec(ctx, commandInstall, res.Target, r.opts.Verbose)
+		if err != nil {
+			err := fmt.Errorf("install %q: %w", executor.commandSet.Name, err)
+			res.Fail(err)
+			log.Warn("failed", "set", name, "err", err)
+			return err
+		}
+		res.Action = actionInstalled
+		log.Info("installed", "set", name, "version", res.Target)
+		return nil
+	}
+	errs := runJobs(ctx, cmp.Or(r.opts.Parallel, 1), r.opts.Force, r.logw, r.jobs(install))
+	return r.jobErrors(commandInstall, errs)
+}
+
+func (r *runner) ver(ctx context.Context) error {
+	ver := func(ctx context.Context, executor *commandExecutor, log *slog.Logger) error {
+		out, err := executor.Exec(ctx, commandVer, "", false)
+		r.mu.Lock()
+		r.currentVersions[executor.commandSet.Name] = strings.TrimSpace(out)
+		r.mu.Unlock()
+		r.report.Get(executor.commandSet.Name).Current = strings.TrimSpace(out)
+		if err != nil || len(out) == 0 {
+			if err == nil {
+				err = fmt.Errorf("empty output")
+			}
+			err := fmt.Errorf("ver %q: %w", executor.commandSet.Name, err)
+			r.report.Get(executor.commandSet.Name).Fail(err)
+			log.Warn("failed", "set", executor.commandSet.Name, "err", err)
+			return err
+		}
+		return nil
+	}
+	errs := runJobs(ctx, cmp.Or(r.opts.Parallel, 1), r.opts.Force, r.logw, r.jobs(ver))
+	if err := r.jobErrors(commandVer, errs); err != nil {
+		return err
+	}
+	if r.format == outputText {
+		out, err := json.MarshalIndent(r.currentVersions, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\n", out)
+	}
+	return nil
+}
+
+func (r *runner) runUser(ctx context.Context) error {
+	for _, set := range r.sets {
+		res := r.report.Get(set.Name)
+		if r.opts.DryRun {
+			r.log.Info("[dry-run] would run", "set", set.Name, "command", r.cmd)
+			continue
+		}
+		executor := r.executor(set)
+		executor.args = r.args
+		done := r.measure(set.Name)
+		_, err := executor.Exec(ctx, r.cmd, "", r.opts.Verbose)
+		done()
+		if err != nil {
+			err := fmt.Errorf("%s %q: %w", r.cmd, set.Name, err)
+			res.Fail(err)
+			if !r.opts.Force {
+				return err
+			}
+			r.log.Warn("failed", "set", set.Name, "err", err)
+			continue
+		}
+		res.Action = actionRan
+	}
+	return nil
+}
+
+func (r *runner) uninstall(ctx context.Context) error {
+	// dependents are uninstalled before their dependencies.
+	for _, set := range slices.Backward(r.sets) {
+		executor := r.executor(set)
+		res := r.report.Get(set.Name)
+		out, err := executor.Exec(ctx, commandVer, "", false)
+		if err != nil || len(strings.TrimSpace(out)) == 0 {
+			res.Action = actionSkipped
+			r.log.Info("skipping: seems not installed", "set", set.Name)
+			continue
+		}
+		res.Current = strings.TrimSpace(out)
+		if r.opts.DryRun {
+			r.log.Info("[dry-run] would uninstall", "set", set.Name, "version", res.Current)
+			continue
+		}
+		r.log.Info("uninstalling", "set", set.Name, "version", res.Current)
+		done := r.measure(set.Name)
+		_, err = executor.Exec(ctx, commandUninstall, res.Current, r.opts.Verbose)
+		done()
+		if err != nil {
+			err := fmt.Errorf("uninstall %q: %w", set.Name, err)
+			res.Fail(err)
+			if !r.opts.Force {
+				return err
+			}
+			r.log.Warn("failed", "set", set.Name, "err", err)
+			continue
+		}
+		res.Action = actionUninstalled
+		r.log.Info("uninstalled", "set", set.Name)
+	}
+	return nil
+}
+
+type targetedExecutor struct {
+	tgt      string
+	executor *commandExecutor
+}
+
+func (r *runner) checkVersions(ctx context.Context) ([]targetedExecutor, error) {
+	if err := r.resolveVersions(ctx); err != nil {
+		return nil, err
+	}
+
+	var updates []targetedExecutor
+	for _, set := range r.sets {
+		name := set.Name
+		res := r.report.Get(name)
+		if res.Action == actionFailed {
+			continue
+		}
+		var prefix string
+		if r.opts.DryRun {
+			prefix = "[dry-run] "
+		}
+		attrs := []any{"set", name, "current", res.Current, "target", res.Target}
+		if res.Pinned {
+			attrs = append(attrs, "pinned", true)
+		}
+		if !needsUpdate(set.Set.Versioning, res.Current, res.Target, r.opts.AllowDowngrade) {
+			// the set stays where it is, which is what -verify-after must expect.
+			r.targetVersions[name] = res.Current
+			if res.Pinned && isDowngrade(set.Set.Versioning, res.Current, res.Target) {
+				// a pin below the installed version is deliberate, yet downgrading needs consent.
+				r.log.Warn(prefix+"installed version is newer than the pin: pass -allow-downgrade to downgrade", attrs...)
+				res.Action = actionSkipped
+				continue
+			}
+			r.log.Info(prefix+"no update", attrs...)
+			res.Action = actionSkipped
+			continue
+		}
+		updates = append(updates, targetedExecutor{tgt: res.Target, executor: r.executor(set)})
+		r.log.Info(prefix+"update available", attrs...)
+	}
+	return updates, nil
+}
+
+// resolveVersions runs ver and checklatest for every set. Under -f, a set failing it fails alone.
+func (r *runner) resolveVersions(ctx context.Context) error {
+	failed := map[string]error{}
+	fail := func(name string, err error) error {
+		if !r.opts.Force {
+			return err
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if failed[name] == nil {
+			failed[name] = err
+		}
+		return nil
+	}
+
+	gr, gCtx := errgroup.WithContext(ctx)
+	gr.SetLimit(cmp.Or(r.opts.Parallel, 5))
+	for _, set := range r.sets {
+		executor := r.executor(set)
+		gr.Go(func() error {
+			out, err := executor.Exec(gCtx, commandVer, "", r.opts.Verbose)
+			if err != nil || len(out) == 0 {
+				if err == nil {
+					err = fmt.Errorf("empty output")
+				}
+				err := fmt.Errorf("ver %q: %w", executor.commandSet.Name, err)
+				return fail(executor.commandSet.Name, err)
+			}
+			r.mu.Lock()
+			r.currentVersions[executor.commandSet.Name] = strings.TrimSpace(out)
+			r.mu.Unlock()
+			return nil
+		})
+		gr.Go(func() error {
+			out, err := executor.Exec(gCtx, commandChecklatest, "", r.opts.Verbose)
+			if err != nil || len(out) == 0 {
+				if err == nil {
+					err = fmt.Errorf("empty output")
+				}
+				err = fmt.Errorf("checklatest %q: %w", executor.commandSet.Name, err)
+				return fail(executor.commandSet.Name, err)
+			}
+			r.mu.Lock()
+			r.latestVersions[executor.commandSet.Name] = strings.TrimSpace(out)
+			r.mu.Unlock()
+			return nil
+		})
+	}
+	if err := gr.Wait(); err != nil {
+		return err
+	}
+
+	for _, set := range r.sets {
+		name := set.Name
+		res := r.report.Get(name)
+		res.Current, res.Latest = r.currentVersions[name], r.latestVersions[name]
+		if err := failed[name]; err != nil {
+			res.Fail(err)
+			r.log.Warn("failed", "set", name, "err", err)
+			continue
+		}
+		tgt := r.latestVersions[name]
+		if pin := r.pinnedVersions[name]; pin != "" {
+			var err error
+			tgt, err = r.executor(set).resolvePin(ctx, pin, r.currentVersions[name], r.latestVersions[name])
+			if err != nil {
+				err := fmt.Errorf("%q: %w", name, err)
+				if !r.opts.Force {
+					return err
+				}
+				res.Fail(err)
+				r.log.Warn("failed", "set", name, "err", err)
+				continue
+			}
+		}
+		r.targetVersions[name] = tgt
+		res.Target = tgt
+	}
+	return nil
+}
+
+func (r *runner) update(ctx context.Context) error {
+	updates, err := r.checkVersions(ctx)
+	if err != nil {
+		return err
+	}
+	updates, err = r.confirm(updates)
+	if err != nil {
+		return err
+	}
+	// failed are sets which failed or were skipped under -f. Their dependents are skipped, as runJobs does.
+	failed := map[string]bool{}
+	for _, set := range r.sets {
+		if r.report.Get(set.Name).Action == actionFailed {
+			failed[set.Name] = true
+		}
+	}
+	for i, t := range updates {
+		if r.opts.DryRun {
+			r.log.Info("[dry-run] would update", "set", t.executor.commandSet.Name, "version", t.tgt)
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			r.skipUpdates(updates[i:], err)
+			return err
+		}
+		var failedDep string
+		for _, dep := range t.executor.commandSet.Set.dependencies() {
+			if failed[dep] {
+				failedDep = dep
+				break
+			}
+		}
+		if failedDep != "" {
+			r.skipUpdates(updates[i:i+1], fmt.Errorf("dependency %q failed", failedDep))
+			failed[t.executor.commandSet.Name] = true
+			continue
+		}
+		if res := r.report.Get(t.executor.commandSet.Name); isDowngrade(t.executor.commandSet.Set.Versioning, res.Current, t.tgt) {
+			r.log.Info("downgrading", "set", t.executor.commandSet.Name, "from", res.Current, "version", t.tgt)
+		} else {
+			r.log.Info("updating", "set", t.executor.commandSet.Name, "version", t.tgt)
+		}
+		done := r.measure(t.executor.commandSet.Name)
+		_, err := t.executor.Exec(ctx, commandUpdate, t.tgt, r.opts.Verbose)
+		done()
+		if err != nil {
+			err := fmt.Errorf("updating %q: %w", t.executor.commandSet.Name, err)
+			r.report.Get(t.executor.commandSet.Name).Fail(err)
+			err = r.rollback(ctx, t.executor, err)
+			if r.opts.Force {
+				r.log.Warn("failed", "set", t.executor.commandSet.Name, "err", err)
+				failed[t.executor.commandSet.Name] = true
+				continue
+			}
+			r.skipUpdates(updates[i+1:], fmt.Errorf("%q failed", t.executor.commandSet.Name))
+			return err
+		}
+		r.report.Get(t.executor.commandSet.Name).Action = actionUpdated
+		r.log.Info("updated", "set", t.executor.commandSet.Name, "version", t.tgt)
+	}
+	return nil
+}
+
+func (r *runner) skipUpdates(updates []targetedExecutor, cause error) {
+	for _, t := range updates {
+		name := t.executor.commandSet.Name
+		err := fmt.Errorf("%s %q: %w: %w", commandUpdate, name, errSkipped, cause)
+		r.report.Get(name).Fail(err)
+		r.log.Warn("skipped", "set", name, "err", err)
+	}
+}
+
+func (r *runner) rollback(ctx context.Context, executor *commandExecutor, err error) error {
+	res := r.report.Get(executor.commandSet.Name)
+	if r.opts.NoRollback || res.Current == "" {
+		return err
+	}
+	r.log.Warn("update failed, rolling back", "set", executor.commandSet.Name, "version", res.Current, "err", err)
+	_, rbErr := executor.Exec(ctx, commandInstall, res.Current, r.opts.Verbose)
+	res.RolledBack = ptr(rbErr == nil)
+	if rbErr != nil {
+		return fmt.Errorf("%w; rollback to %s also failed: %w", err, res.Current, rbErr)
+	}
+	r.log.Info("rolled back", "set", executor.commandSet.Name, "version", res.Current)
+	return err
+}
+
+func (r *runner) verify(ctx context.Context) error {
+	r.log.Info("verifying")
+	var failed int
+	for _, set := range r.sets {
+		got, err := verifyVersion(ctx, r.executor(set), r.targetVersions[set.Name])
+		r.report.Get(set.Name).Verified = ptr(err == nil)
+		if err != nil {
+			failed++
+			r.log.Warn("verification failed", "set", set.Name, "err", err)
+			continue
+		}
+		r.log.Info("verified", "set", set.Name, "version", got)
+	}
+	r.log.Info("verification done", "passed", len(r.sets)-failed, "failed", failed)
+	if failed > 0 {
+		return partialFailure(fmt.Errorf("verification failed for %d set(s)", failed))
+	}
+	return nil
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
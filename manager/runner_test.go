@@ -0,0 +1,25 @@
+package manager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestUpdateVerifyAfterNoDowngrade(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sets use sh")
+	}
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+	set := `{"ver": ["sh", "-c", "echo 1.3.0"], "checklatest": ["sh", "-c", "echo v1.2.0"], "update": ["sh", "-c", "exit 1"]}`
+	if err := os.WriteFile(filepath.Join(dir, "s.json"), []byte(set), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := New(dir, Options{Yes: true, VerifyAfter: true, Quiet: true})
+	if err := m.Run(context.Background(), []string{"update"}); err != nil {
+		t.Fatalf("update of a set newer than latest: %v", err)
+	}
+}
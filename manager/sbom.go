@@ -0,0 +1,200 @@
+package manager
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// packageURL returns PURL if set, or one derived from the backend, without a version. It returns "" if unknown.
+func packageURL(c commandSet) string {
+	if c.PURL != "" {
+		return c.PURL
+	}
+	switch b := c.backend().(type) {
+	case *githubBackend:
+		return "pkg:github/" + strings.ToLower(b.Repo)
+	case *goInstallBackend:
+		pkg, _, _ := strings.Cut(string(*b), "@")
+		return "pkg:golang/" + pkg
+	case *cargoBackend:
+		return "pkg:cargo/" + string(*b)
+	case *npmBackend:
+		// The "@" of a scope is percent-encoded.
+		return "pkg:npm/" + strings.Replace(string(*b), "@", "%40", 1)
+	case *pipxBackend:
+		return "pkg:pypi/" + strings.ToLower(strings.ReplaceAll(string(*b), "_", "-"))
+	}
+	return ""
+}
+
+// packageURLWithVersion prefixes versions of Go modules with "v".
+func packageURLWithVersion(purl, ver string) string {
+	if strings.HasPrefix(purl, "pkg:golang/") && !strings.HasPrefix(ver, "v") {
+		ver = "v" + ver
+	}
+	return purl + "@" + url.PathEscape(ver)
+}
+
+type sbomComponent struct {
+	Name    string
+	Version string
+	// PURL includes the version. It is "" if unknown.
+	PURL    string
+	Backend string
+}
+
+// sbomComponents leaves out sets missing in versions.
+func sbomComponents(sets []namedCommandSet, versions map[string]string) []sbomComponent {
+	var components []sbomComponent
+	for _, set := range sets {
+		ver, ok := versions[set.Name]
+		if !ok {
+			continue
+		}
+		c := sbomComponent{Name: set.Name, Version: ver, Backend: backendName(set.Set.backend())}
+		if purl := packageURL(set.Set); purl != "" {
+			c.PURL = packageURLWithVersion(purl, ver)
+		}
+		components = append(components, c)
+	}
+	slices.SortFunc(components, func(a, b sbomComponent) int { return strings.Compare(a.Name, b.Name) })
+	return components
+}
+
+func newUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func cycloneDX(host string, components []sbomComponent, now time.Time) ([]byte, error) {
+	type property struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	type component struct {
+		Type       string     `json:"type"`
+		BOMRef     string     `json:"bom-ref,omitzero"`
+		Name       string     `json:"name"`
+		Version    string     `json:"version,omitzero"`
+		PURL       string     `json:"purl,omitzero"`
+		Properties []property `json:"properties,omitzero"`
+	}
+	doc := struct {
+		BOMFormat    string `json:"bomFormat"`
+		SpecVersion  string `json:"specVersion"`
+		SerialNumber string `json:"serialNumber"`
+		Version      int    `json:"version"`
+		Metadata     struct {
+			Timestamp string `json:"timestamp"`
+			Tools     struct {
+				Components []component `json:"components"`
+			} `json:"tools"`
+			Component component `json:"component"`
+		} `json:"metadata"`
+		Components []component `json:"components"`
+	}{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + newUUID(),
+		Version:      1,
+		Components:   []component{},
+	}
+	doc.Metadata.Timestamp = now.UTC().Format(time.RFC3339)
+	doc.Metadata.Tools.Components = []component{{Type: "application", Name: "ngpkgmgr", Version: version}}
+	doc.Metadata.Component = component{Type: "device", Name: host}
+	for _, c := range components {
+		out := component{Type: "application", BOMRef: c.Name, Name: c.Name, Version: c.Version, PURL: c.PURL}
+		if c.Backend != "" {
+			out.Properties = []property{{Name: "ngpkgmgr:backend", Value: c.Backend}}
+		}
+		doc.Components = append(doc.Components, out)
+	}
+	return json.MarshalIndent(doc, "", "    ")
+}
+
+var spdxIDInvalid = regexp.MustCompile(`[^A-Za-z0-9.-]`)
+
+func spdx(host string, components []sbomComponent, now time.Time) ([]byte, error) {
+	type externalRef struct {
+		ReferenceCategory string `json:"referenceCategory"`
+		ReferenceType     string `json:"referenceType"`
+		ReferenceLocator  string `json:"referenceLocator"`
+	}
+	type pkg struct {
+		Name             string        `json:"name"`
+		SPDXID           string        `json:"SPDXID"`
+		VersionInfo      string        `json:"versionInfo"`
+		DownloadLocation string        `json:"downloadLocation"`
+		FilesAnalyzed    bool          `json:"filesAnalyzed"`
+		Comment          string        `json:"comment,omitzero"`
+		ExternalRefs     []externalRef `json:"externalRefs,omitzero"`
+	}
+	type relationship struct {
+		SPDXElementID      string `json:"spdxElementId"`
+		RelationshipType   string `json:"relationshipType"`
+		RelatedSPDXElement string `json:"relatedSpdxElement"`
+	}
+	doc := struct {
+		SPDXVersion       string `json:"spdxVersion"`
+		DataLicense       string `json:"dataLicense"`
+		SPDXID            string `json:"SPDXID"`
+		Name              string `json:"name"`
+		DocumentNamespace string `json:"documentNamespace"`
+		CreationInfo      struct {
+			Created  string   `json:"created"`
+			Creators []string `json:"creators"`
+		} `json:"creationInfo"`
+		Packages      []pkg          `json:"packages"`
+		Relationships []relationship `json:"relationships"`
+	}{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              "ngpkgmgr-" + host,
+		DocumentNamespace: "https://spdx.org/spdxdocs/ngpkgmgr-" + url.PathEscape(host) + "-" + newUUID(),
+		Packages:          []pkg{},
+		Relationships:     []relationship{},
+	}
+	doc.CreationInfo.Created = now.UTC().Format(time.RFC3339)
+	doc.CreationInfo.Creators = []string{"Tool: ngpkgmgr-" + version}
+	ids := map[string]bool{}
+	for _, c := range components {
+		id := "SPDXRef-Package-" + spdxIDInvalid.ReplaceAllString(c.Name, "-")
+		for base, i := id, 2; ids[id]; i++ {
+			id = fmt.Sprintf("%s-%d", base, i)
+		}
+		ids[id] = true
+		p := pkg{
+			Name:             c.Name,
+			SPDXID:           id,
+			VersionInfo:      c.Version,
+			DownloadLocation: "NOASSERTION",
+		}
+		if c.Backend != "" {
+			p.Comment = "installed by the " + c.Backend + " backend of ngpkgmgr"
+		}
+		if c.PURL != "" {
+			p.ExternalRefs = []externalRef{{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: c.PURL}}
+		}
+		doc.Packages = append(doc.Packages, p)
+		doc.Relationships = append(doc.Relationships, relationship{SPDXElementID: doc.SPDXID, RelationshipType: "DESCRIBES", RelatedSPDXElement: p.SPDXID})
+	}
+	return json.MarshalIndent(doc, "", "    ")
+}
+
+func hostname() string {
+	if h, err := os.Hostname(); err == nil && h != "" {
+		return h
+	}
+	return "localhost"
+}
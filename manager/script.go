@@ -0,0 +1,95 @@
+package manager
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// scriptArgs runs script by interpreters, keyed by extension, or directly. On Windows, .ps1 runs through PowerShell
+// and .sh or extension-less scripts through the interpreter of the shebang line.
+func scriptArgs(script string, interpreters map[string][]string) ([]string, error) {
+	ext := strings.ToLower(filepath.Ext(script))
+	if interp, ok := interpreters[ext]; ok {
+		return append(append([]string(nil), interp...), script), nil
+	}
+	if ext == ".ps1" {
+		ps := "pwsh"
+		if runtime.GOOS == "windows" {
+			ps = "powershell"
+		}
+		return []string{ps, "-NoProfile", "-ExecutionPolicy", "Bypass", "-File", script}, nil
+	}
+	if runtime.GOOS != "windows" || (ext != "" && ext != ".sh") {
+		return []string{script}, nil
+	}
+
+	interp, err := shebang(script)
+	if err != nil {
+		return nil, err
+	}
+	if len(interp) == 0 || interp[0] == "sh" || interp[0] == "bash" {
+		bash, err := findBash()
+		if err != nil {
+			return nil, fmt.Errorf("running %s: %w", script, err)
+		}
+		return []string{bash, script}, nil
+	}
+	path, err := exec.LookPath(interp[0])
+	if err != nil {
+		return nil, fmt.Errorf("running %s: %w", script, err)
+	}
+	return append(append([]string{path}, interp[1:]...), script), nil
+}
+
+// shebang returns e.g. ["python3"] for "#!/usr/bin/env python3", or nil if there is no such line.
+func shebang(script string) ([]string, error) {
+	f, err := os.Open(script)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	line, _ := bufio.NewReader(f).ReadString('\n')
+	rest, ok := strings.CutPrefix(strings.TrimSpace(line), "#!")
+	if !ok {
+		return nil, nil
+	}
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	fields[0] = filepath.Base(filepath.FromSlash(fields[0]))
+	if fields[0] == "env" {
+		fields = fields[1:]
+		if len(fields) > 0 && fields[0] == "-S" {
+			fields = fields[1:]
+		}
+	}
+	return fields, nil
+}
+
+// findBash skips bash.exe in System32 since it starts WSL, which cannot see Windows paths as they are.
+func findBash() (string, error) {
+	for _, env := range []string{"ProgramFiles", "ProgramW6432", "LOCALAPPDATA"} {
+		dir := os.Getenv(env)
+		if dir == "" {
+			continue
+		}
+		if env == "LOCALAPPDATA" {
+			dir = filepath.Join(dir, "Programs")
+		}
+		p := filepath.Join(dir, "Git", "bin", "bash.exe")
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		}
+	}
+	p, err := exec.LookPath("bash")
+	if err == nil && !strings.EqualFold(filepath.Dir(p), filepath.Join(os.Getenv("SystemRoot"), "System32")) {
+		return p, nil
+	}
+	return "", fmt.Errorf("bash not found: install Git for Windows or set \"interpreters\"")
+}
@@ -0,0 +1,96 @@
+package manager
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// version is the version of this binary, set at build time by
+//
+//	go build -ldflags "-X github.com/ngicks/ngpkgmgr/manager.version=1.2.3"
+var version = "dev"
+
+// selfRelease has a raw binary asset per platform and SHA256SUMS listing them.
+var selfRelease = githubBackend{
+	Repo:  "ngicks/ngpkgmgr",
+	Asset: "ngpkgmgr_${OS}_${ARCH}",
+}
+
+// selfUpdate replaces the running executable with the latest release, verified by SHA256SUMS.
+//
+//	self-update [--check]
+func (m *Manager) selfUpdate(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("self-update", flag.ContinueOnError)
+	check := flags.Bool("check", false, "only prints the current and latest versions")
+	if err := flags.Parse(args); err != nil {
+		return configError(err)
+	}
+	if flags.NArg() != 0 {
+		return configError(fmt.Errorf("self-update: wrong args length: want 0, got %d", flags.NArg()))
+	}
+
+	globalCfg, err := loadGlobalConfig(m.cfgDir)
+	if err != nil {
+		return configError(err)
+	}
+	c := githubClient{token: globalCfg.githubToken(), log: m.logger()}
+	latest, err := selfRelease.latest(ctx, c)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("ngpkgmgr: %s -> %s\n", version, latest)
+	if !needsUpdate(versioningSemver, version, latest, m.opts.AllowDowngrade) {
+		fmt.Println("already up to date")
+		return nil
+	}
+	if *check {
+		return &exitError{code: exitOutdated, err: fmt.Errorf("self-update: %s is available", latest)}
+	}
+	if m.opts.DryRun {
+		fmt.Printf("[dry-run] would update ngpkgmgr to %s\n", latest)
+		return nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return err
+	}
+
+	asset := selfRelease.Asset
+	if runtime.GOOS == "windows" {
+		asset += ".exe"
+	}
+	dict := dictReplacer{"${VER}": latest, "${OS}": runtime.GOOS, "${ARCH}": runtime.GOARCH}
+	data, err := selfRelease.download(ctx, m.cfgDir, newDownloader(os.Stderr), os.Stdout, dict, latest, dict.Expand(asset), &checksumConfig{Sums: "SHA256SUMS"}, nil)
+	if err != nil {
+		return err
+	}
+
+	var old string
+	if runtime.GOOS == "windows" {
+		// A running executable can not be overwritten but can be renamed on Windows.
+		old = exe + ".old"
+		_ = os.Remove(old)
+		if err := os.Rename(exe, old); err != nil {
+			return err
+		}
+	}
+	if err := writeFileAtomic(exe, data, 0o755); err != nil {
+		if old != "" {
+			// puts the running executable back, not to leave the user without ngpkgmgr.
+			if rErr := os.Rename(old, exe); rErr != nil {
+				return fmt.Errorf("%w; restoring %s from %s also failed: %w", err, exe, old, rErr)
+			}
+		}
+		return err
+	}
+	fmt.Printf("updated %s to %s\n", exe, latest)
+	return nil
+}
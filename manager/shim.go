@@ -0,0 +1,104 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// shimMarker is followed by the set name and ",".
+const shimMarker = "ngpkgmgr shim of set "
+
+// shimName is bin itself, or "<bin>.cmd" with ".exe" trimmed on Windows.
+func shimName(bin string) string {
+	if runtime.GOOS == "windows" {
+		return strings.TrimSuffix(bin, ".exe") + ".cmd"
+	}
+	return bin
+}
+
+func shimScript(exe, root, name, bin string) []byte {
+	if runtime.GOOS == "windows" {
+		return fmt.Appendf(nil, "@echo off\r\nrem %s%s, binary %s. Generated by ngpkgmgr; do not edit.\r\n\"%s\" shim-exec \"%s\" \"%s\" \"%s\" %%*\r\nexit /b %%ERRORLEVEL%%\r\n",
+			shimMarker, name, bin, exe, root, name, bin)
+	}
+	return fmt.Appendf(nil, "#!/bin/sh\n# %s%s, binary %s. Generated by ngpkgmgr; do not edit.\nexec %s shim-exec %s %s %s \"$@\"\n",
+		shimMarker, name, bin, shellQuote(exe), shellQuote(root), shellQuote(name), shellQuote(bin))
+}
+
+func shimOwner(p string) (name string, ok bool) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(f, head)
+	_, after, ok := bytes.Cut(head[:n], []byte(shimMarker))
+	if !ok {
+		return "", false
+	}
+	name, _, ok = strings.Cut(string(after), ",")
+	return name, ok && name != ""
+}
+
+// binaryPath returns the link in the bin dir, or the binary of the current version if shims are enabled.
+func (d managedDir) binaryPath(name, bin string) (string, error) {
+	if !d.shims {
+		return filepath.Join(d.binDir(), bin), nil
+	}
+	ver, err := d.linked(name)
+	if err != nil {
+		return "", err
+	}
+	if ver == "" {
+		return "", fmt.Errorf("managed: %q has no current version", name)
+	}
+	dir, err := d.versionDir(name, ver)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, bin), nil
+}
+
+// shimExec runs bin of the version the nearest .tool-versions names, or the current version.
+//
+//	shim-exec <managed dir> <name> <bin> [<arg>...]
+func (m *Manager) shimExec(_ context.Context, args []string) error {
+	if len(args) < 3 {
+		return configError(fmt.Errorf("shim-exec: wrong args length: want 3 or more, got %d", len(args)))
+	}
+	d, name, bin := managedDir{root: args[0], shims: true}, args[1], args[2]
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	ver, file, err := findToolVersion(wd, name)
+	if err != nil {
+		return err
+	}
+	var p string
+	if ver == "" {
+		p, err = d.binaryPath(name, bin)
+	} else {
+		var dir string
+		dir, err = d.versionDir(name, ver)
+		p = filepath.Join(dir, bin)
+	}
+	if err != nil {
+		return configError(fmt.Errorf("%s: %w", bin, err))
+	}
+	if _, err := os.Stat(p); err != nil {
+		if file != "" {
+			return configError(fmt.Errorf("%s: %s of %q required by %s is not installed: run ngpkgmgr install %s@%s", bin, ver, name, file, name, ver))
+		}
+		return configError(fmt.Errorf("%s: %w", bin, err))
+	}
+	return execReplace(p, append([]string{p}, args[3:]...))
+}
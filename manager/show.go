@@ -0,0 +1,233 @@
+package manager
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+type showEntry struct {
+	Name     string `json:"name"`
+	File     string `json:"file,omitzero"`
+	Dir      string `json:"dir,omitzero"`
+	Platform string `json:"platform"`
+	Backend  string `json:"backend,omitzero"`
+	PURL     string `json:"purl,omitzero"`
+	Workdir  string `json:"workdir,omitzero"`
+	Pty      bool   `json:"pty,omitzero"`
+	// Commands are as they would run on this platform.
+	Commands  []shownCommand    `json:"commands"`
+	Env       map[string]string `json:"env,omitzero"`
+	Vars      map[string]string `json:"vars,omitzero"`
+	Deps      []string          `json:"deps,omitzero"`
+	After     []string          `json:"after,omitzero"`
+	Tags      []string          `json:"tags,omitzero"`
+	Pinned    string            `json:"pinned,omitzero"`
+	Disabled  bool              `json:"disabled,omitzero"`
+	OS        []string          `json:"os,omitzero"`
+	Arch      []string          `json:"arch,omitzero"`
+	Supported bool              `json:"supported"`
+	Receipt   *receipt          `json:"receipt,omitzero"`
+}
+
+type shownCommand struct {
+	Name command `json:"name"`
+	// From is "args", "platforms.<platform>", "source", "backend" or "script", or empty if undefined.
+	From string   `json:"from,omitzero"`
+	Args []string `json:"args,omitzero"`
+}
+
+// show prints the set resolved for this platform, never executing commands. Version placeholders need --ver.
+//
+//	show [--ver <version>] <name>
+func (m *Manager) show(_ context.Context, args []string) error {
+	fset := flag.NewFlagSet("show", flag.ContinueOnError)
+	ver := fset.String("ver", "", "expand version placeholders with this version")
+	if err := fset.Parse(args); err != nil {
+		return configError(err)
+	}
+	if fset.NArg() != 1 {
+		return configError(fmt.Errorf("show: wrong args length: want 1, got %d", fset.NArg()))
+	}
+	format := outputFormat(m.opts.Output)
+	if err := format.Validate(); err != nil {
+		return configError(err)
+	}
+
+	set, err := findCommandSet(m.setDirs(), fset.Arg(0))
+	if err != nil {
+		return configError(err)
+	}
+	pinnedVersions, err := loadPinnedVersions(m.cfgDir)
+	if err != nil {
+		return configError(err)
+	}
+	disabled, err := loadDisabled(m.cfgDir)
+	if err != nil {
+		return configError(err)
+	}
+
+	globalCfg, err := loadGlobalConfig(m.cfgDir)
+	if err != nil {
+		return configError(err)
+	}
+	e := newCommandExecutor(set, executorDefaults{Shell: globalCfg.Shell}, nil, nil, nil)
+	dict := e.dict(*ver)
+	if *ver == "" {
+		for _, k := range []string{"VER", "VER_NO_V", "VER_MAJOR", "VER_MINOR", "VER_PATCH"} {
+			delete(dict, "${"+k+"}")
+		}
+	}
+
+	entry := showEntry{
+		Name:      set.Name,
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Deps:      set.Set.dependencies(),
+		After:     set.Set.After,
+		Tags:      set.Set.Tags,
+		Pinned:    pinnedVersions[set.Name],
+		Disabled:  isDisabled(set, disabled),
+		OS:        set.Set.OS,
+		Arch:      set.Set.Arch,
+		Supported: set.Set.supported(),
+	}
+	if p, err := findSetFile(set.Dir, set.Name); err == nil {
+		entry.File = p
+	}
+	if rec, ok, err := loadReceipt(m.cfgDir, set.Name); err == nil && ok {
+		entry.Receipt = &rec
+	}
+	if p := filepath.Join(set.Dir, set.Name); exists(p) {
+		entry.Dir = p
+	}
+	b := set.Set.backend()
+	if b != nil {
+		entry.Backend = backendName(b)
+	}
+	entry.PURL = packageURL(set.Set)
+	if set.Set.Workdir != "" {
+		entry.Workdir = dict.Expand(set.Set.Workdir)
+	}
+	entry.Pty = set.Set.Pty
+	for k, v := range set.Set.Env {
+		if entry.Env == nil {
+			entry.Env = map[string]string{}
+		}
+		entry.Env[k] = dict.Expand(v)
+	}
+	for k, v := range set.Set.Vars {
+		if entry.Vars == nil {
+			entry.Vars = map[string]string{}
+		}
+		entry.Vars[k] = dict.Expand(v)
+	}
+
+	kinds := slices.Clone(cmds)
+	for _, c := range set.Set.userCommands() {
+		kinds = append(kinds, command(c))
+	}
+	for _, kind := range kinds {
+		c := shownCommand{Name: kind}
+		args := set.Set.Select(kind)
+		script, found := findScript(set.Dir, set.Name, kind)
+		switch {
+		case len(args) > 0:
+			c.From = "args"
+			for _, p := range []string{runtime.GOOS + "/" + runtime.GOARCH, runtime.GOOS} {
+				if len(set.Set.Platforms[p].selectBase(kind)) > 0 {
+					c.From = "platforms." + p
+					break
+				}
+			}
+			c.Args = slices.Collect(dict.Map(slices.Values(args)))
+		case kind == commandChecklatest && set.Set.Source != nil:
+			c.From = "source"
+			c.Args = strings.Fields(set.Set.Source.Type + " " + set.Set.Source.Name)
+		case b != nil && slices.Contains(b.Commands(), kind):
+			c.From = "backend"
+		case found:
+			c.From = "script"
+			c.Args, err = scriptArgs(script, e.interpreters())
+			if err != nil {
+				c.Args = []string{script, "(" + err.Error() + ")"}
+			}
+		}
+		entry.Commands = append(entry.Commands, c)
+	}
+
+	if format == outputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "    ")
+		return enc.Encode(entry)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "name:\t%s\n", entry.Name)
+	fmt.Fprintf(w, "file:\t%s\n", entry.File)
+	fmt.Fprintf(w, "dir:\t%s\n", entry.Dir)
+	fmt.Fprintf(w, "platform:\t%s\n", entry.Platform)
+	if entry.Backend != "" {
+		fmt.Fprintf(w, "backend:\t%s\n", entry.Backend)
+	}
+	if entry.PURL != "" {
+		fmt.Fprintf(w, "purl:\t%s\n", entry.PURL)
+	}
+	if entry.Workdir != "" {
+		fmt.Fprintf(w, "workdir:\t%s\n", entry.Workdir)
+	}
+	if entry.Pty {
+		fmt.Fprintf(w, "pty:\ttrue\n")
+	}
+	for _, c := range entry.Commands {
+		from := cmp.Or(c.From, "-")
+		fmt.Fprintf(w, "%s:\t%s\t%s\n", c.Name, from, strings.Join(c.Args, " "))
+	}
+	for _, k := range slices.Sorted(maps.Keys(entry.Vars)) {
+		fmt.Fprintf(w, "vars.%s:\t%s\n", k, entry.Vars[k])
+	}
+	for _, k := range slices.Sorted(maps.Keys(entry.Env)) {
+		fmt.Fprintf(w, "env.%s:\t%s\n", k, entry.Env[k])
+	}
+	if len(entry.Deps) > 0 {
+		fmt.Fprintf(w, "deps:\t%s\n", strings.Join(entry.Deps, ","))
+	}
+	if len(entry.After) > 0 {
+		fmt.Fprintf(w, "after:\t%s\n", strings.Join(entry.After, ","))
+	}
+	if len(entry.Tags) > 0 {
+		fmt.Fprintf(w, "tags:\t%s\n", strings.Join(entry.Tags, ","))
+	}
+	if entry.Pinned != "" {
+		fmt.Fprintf(w, "pinned:\t%s\n", entry.Pinned)
+	}
+	if entry.Disabled {
+		fmt.Fprintln(w, "disabled:\ttrue")
+	}
+	if r := entry.Receipt; r != nil {
+		fmt.Fprintf(w, "installed:\t%s by %s at %s\n", r.Version, r.Backend, r.InstalledAt.Local().Format(time.DateTime))
+		for _, p := range r.Paths {
+			fmt.Fprintf(w, "\t%s\n", p)
+		}
+	}
+	if len(entry.OS) > 0 || len(entry.Arch) > 0 {
+		fmt.Fprintf(w, "os/arch:\t%s/%s (supported: %t)\n", cmp.Or(strings.Join(entry.OS, ","), "*"), cmp.Or(strings.Join(entry.Arch, ","), "*"), entry.Supported)
+	}
+	return w.Flush()
+}
+
+func exists(p string) bool {
+	_, err := os.Stat(p)
+	return !errors.Is(err, fs.ErrNotExist)
+}
@@ -0,0 +1,204 @@
+package manager
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// signatureConfig verifies the artifact of checksum, or release assets of the github backend, with cosign or gpg.
+//
+//	{"signature": {"cosign": {"bundle": "tool_${VER}.tar.gz.sigstore.json", "identity": "https://github.com/o/r/.github/workflows/release.yml@refs/tags/${VER}", "issuer": "https://token.actions.githubusercontent.com"}}}
+//	{"signature": {"gpg": {"key": "https://example.com/KEYS", "fingerprint": "0123 4567 89AB CDEF 0123  4567 89AB CDEF 0123 4567"}}}
+type signatureConfig struct {
+	Cosign *cosignSignature `json:"cosign,omitzero"`
+	GPG    *gpgSignature    `json:"gpg,omitzero"`
+}
+
+// URLs of cosignSignature and gpgSignature have placeholders and may be relative to the artifact URL.
+type cosignSignature struct {
+	// Signature defaults to "<artifact>.sig" unless Bundle is set.
+	Signature      string `json:"signature,omitzero"`
+	Certificate    string `json:"certificate,omitzero"`
+	Bundle         string `json:"bundle,omitzero"`
+	Key            string `json:"key,omitzero"`
+	Identity       string `json:"identity,omitzero"`
+	IdentityRegexp string `json:"identity_regexp,omitzero"`
+	Issuer         string `json:"issuer,omitzero"`
+}
+
+type gpgSignature struct {
+	// Signature defaults to "<artifact>.asc".
+	Signature string `json:"signature,omitzero"`
+	// Key is imported into a temporary keyring. If empty, the keyring of the user is used.
+	Key string `json:"key,omitzero"`
+	// Fingerprint is of the signing key or its primary key. Spaces are ignored.
+	Fingerprint string `json:"fingerprint"`
+}
+
+func (c signatureConfig) Validate() error {
+	if (c.Cosign == nil) == (c.GPG == nil) {
+		return fmt.Errorf("signature: exactly one of cosign or gpg must be specified")
+	}
+	if s := c.Cosign; s != nil {
+		if s.Bundle != "" && (s.Signature != "" || s.Certificate != "") {
+			return fmt.Errorf("signature: cosign: bundle excludes signature and certificate")
+		}
+		if s.Key == "" && ((s.Identity == "") == (s.IdentityRegexp == "") || s.Issuer == "") {
+			return fmt.Errorf("signature: cosign: either key, or issuer and exactly one of identity or identity_regexp must be specified")
+		}
+	}
+	if s := c.GPG; s != nil && s.Fingerprint == "" {
+		return fmt.Errorf("signature: gpg: fingerprint must be specified")
+	}
+	return nil
+}
+
+// Verify downloads signatures, certificates and keys into a temporary dir next to artifact.
+func (c signatureConfig) Verify(ctx context.Context, dict dictReplacer, artifactURL, artifact string) error {
+	dir, err := os.MkdirTemp(filepath.Dir(artifact), "signature-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	if c.Cosign != nil {
+		err = c.Cosign.verify(ctx, dict, dir, artifactURL, artifact)
+	} else {
+		err = c.GPG.verify(ctx, dict, dir, artifactURL, artifact)
+	}
+	if err != nil {
+		return fmt.Errorf("signature: %s: %w", artifactURL, err)
+	}
+	return nil
+}
+
+// fetchRelated downloads ref, relative to artifactURL, into dir. An absolute path or one starting with "~" is a local file.
+func fetchRelated(ctx context.Context, dict dictReplacer, dir, artifactURL, ref string) (string, error) {
+	ref = dict.Expand(ref)
+	base, err := url.Parse(artifactURL)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(ref)
+	if err != nil || (u.Scheme == "" && (filepath.IsAbs(ref) || strings.HasPrefix(ref, "~"))) {
+		return expandHome(ref)
+	}
+	u = base.ResolveReference(u)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return expandHome(ref)
+	}
+	data, err := fetch(ctx, u.String())
+	if err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(dir, "*-"+path.Base(u.Path))
+	if err != nil {
+		return "", err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return f.Name(), err
+}
+
+func (s cosignSignature) verify(ctx context.Context, dict dictReplacer, dir, artifactURL, artifact string) error {
+	args := []string{"verify-blob"}
+	fetchArg := func(flag, ref string) error {
+		if ref == "" {
+			return nil
+		}
+		p, err := fetchRelated(ctx, dict, dir, artifactURL, ref)
+		if err != nil {
+			return err
+		}
+		args = append(args, flag, p)
+		return nil
+	}
+	sig := s.Signature
+	if sig == "" && s.Bundle == "" {
+		sig = path.Base(artifactURL) + ".sig"
+	}
+	for _, f := range []struct{ flag, ref string }{
+		{"--signature", sig},
+		{"--certificate", s.Certificate},
+		{"--bundle", s.Bundle},
+		{"--key", s.Key},
+	} {
+		if err := fetchArg(f.flag, f.ref); err != nil {
+			return err
+		}
+	}
+	for _, f := range []struct{ flag, v string }{
+		{"--certificate-identity", s.Identity},
+		{"--certificate-identity-regexp", s.IdentityRegexp},
+		{"--certificate-oidc-issuer", s.Issuer},
+	} {
+		if v := dict.Expand(f.v); v != "" {
+			args = append(args, f.flag, v)
+		}
+	}
+	out, err := exec.CommandContext(ctx, "cosign", append(args, artifact)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("cosign: %w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+func (s gpgSignature) verify(ctx context.Context, dict dictReplacer, dir, artifactURL, artifact string) error {
+	sig, err := fetchRelated(ctx, dict, dir, artifactURL, cmp.Or(s.Signature, path.Base(artifactURL)+".asc"))
+	if err != nil {
+		return err
+	}
+	env := os.Environ()
+	if s.Key != "" {
+		key, err := fetchRelated(ctx, dict, dir, artifactURL, s.Key)
+		if err != nil {
+			return err
+		}
+		home, err := os.MkdirTemp(dir, "gnupg-")
+		if err != nil {
+			return err
+		}
+		env = append(env, "GNUPGHOME="+home)
+		cmd := exec.CommandContext(ctx, "gpg", "--batch", "--import", key)
+		cmd.Env = env
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("gpg: importing key: %w: %s", err, bytes.TrimSpace(out))
+		}
+	}
+	var status, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "gpg", "--batch", "--status-fd", "1", "--verify", sig, artifact)
+	cmd.Env = env
+	cmd.Stdout, cmd.Stderr = &status, &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("gpg: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	want := normalizeFingerprint(dict.Expand(s.Fingerprint))
+	sc := bufio.NewScanner(&status)
+	for sc.Scan() {
+		// [GNUPG:] VALIDSIG <fingerprint> ... <primary key fingerprint>
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 3 || fields[1] != "VALIDSIG" {
+			continue
+		}
+		if fields[2] == want || fields[len(fields)-1] == want {
+			return nil
+		}
+		return fmt.Errorf("gpg: signed by %s, not %s", fields[len(fields)-1], want)
+	}
+	return fmt.Errorf("gpg: no valid signature")
+}
+
+func normalizeFingerprint(fpr string) string {
+	fpr = strings.ToUpper(strings.ReplaceAll(fpr, " ", ""))
+	return strings.TrimPrefix(fpr, "0X")
+}
@@ -0,0 +1,200 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// sourceConfig resolves the latest version in place of checklatest.
+// It is kept as raw JSON so that each source type can define its own fields.
+type sourceConfig struct {
+	Type string
+	Name string
+	raw  json.RawMessage
+}
+
+func (s *sourceConfig) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Type string `json:"type"`
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.Type == "" {
+		return fmt.Errorf("source: type must be specified")
+	}
+	s.Type, s.Name = v.Type, v.Name
+	s.raw = bytes.Clone(data)
+	return nil
+}
+
+func (s sourceConfig) MarshalJSON() ([]byte, error) {
+	if s.raw != nil {
+		return s.raw, nil
+	}
+	return json.Marshal(map[string]string{"type": s.Type, "name": s.Name})
+}
+
+func (s *sourceConfig) Validate() error {
+	if s == nil {
+		return nil
+	}
+	switch s.Type {
+	case "plugin":
+		if s.Name == "" {
+			return fmt.Errorf("source: plugin name must be specified")
+		}
+	case "http":
+		var cfg httpSource
+		if err := json.Unmarshal(s.raw, &cfg); err != nil {
+			return fmt.Errorf("source: %w", err)
+		}
+		if cfg.URL == "" {
+			return fmt.Errorf("source: url must be specified")
+		}
+		if cfg.Regex != "" || cfg.JSONPath != "" {
+			if err := cfg.extractor.Validate(); err != nil {
+				return fmt.Errorf("source: %w", err)
+			}
+		}
+	default:
+		return fmt.Errorf("source: unknown type %q", s.Type)
+	}
+	return nil
+}
+
+type sourceResolver func(ctx context.Context, e commandExecutor, src sourceConfig) (string, error)
+
+var sourceResolvers = map[string]sourceResolver{
+	"plugin": resolvePlugin,
+	"http":   resolveHTTP,
+}
+
+func (e commandExecutor) resolveSource(ctx context.Context, verbose bool) (string, error) {
+	src := *e.commandSet.Set.Source
+	resolver, ok := sourceResolvers[src.Type]
+	if !ok {
+		return "", fmt.Errorf("unknown source type %q", src.Type)
+	}
+	ver, err := resolver(ctx, e, src)
+	if err != nil {
+		return "", fmt.Errorf("source %q: %w", src.Type, err)
+	}
+	if verbose {
+		fmt.Fprintln(e.stdout, ver)
+	}
+	return ver, nil
+}
+
+const (
+	pluginResolverPrefix   = "pkgmgr-resolver-"
+	pluginResolverProtocol = "1"
+)
+
+// resolvePlugin runs pkgmgr-resolver-<name> in PATH. Protocol version 1:
+//   - stdin receives the source config as JSON, exactly as written in the command set.
+//   - env has PKGMGR_RESOLVER_PROTOCOL=1, PKGMGR_NAME=<command set name>, OS, ARCH and keys of env.
+//   - the first non-empty line of stdout is the resolved version.
+//   - a non-zero exit status means resolution failed; stderr is passed through.
+func resolvePlugin(ctx context.Context, e commandExecutor, src sourceConfig) (string, error) {
+	if src.Name == "" {
+		return "", fmt.Errorf("plugin name must be specified")
+	}
+	bin, err := exec.LookPath(pluginResolverPrefix + src.Name)
+	if err != nil {
+		return "", err
+	}
+
+	input, err := json.Marshal(src)
+	if err != nil {
+		return "", err
+	}
+
+	cmd := exec.CommandContext(ctx, bin)
+	cmd.Stdin = bytes.NewReader(input)
+	buf := new(bytes.Buffer)
+	cmd.Stdout = buf
+	cmd.Stderr = e.stderr
+	cmd.Env = append(
+		e.environ(e.dict("")),
+		"PKGMGR_RESOLVER_PROTOCOL="+pluginResolverProtocol,
+		"PKGMGR_NAME="+e.commandSet.Name,
+	)
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+
+	for line := range strings.Lines(buf.String()) {
+		if line = strings.TrimSpace(line); line != "" {
+			return line, nil
+		}
+	}
+	return "", fmt.Errorf("%s: empty output", bin)
+}
+
+// httpSource fetches a URL and extracts the version from the response body.
+//
+//	{"type": "http", "url": "https://go.dev/VERSION?m=text", "regex": "^go(\\S+)"}
+type httpSource struct {
+	// URL has placeholders other than ${VER}.
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitzero"`
+	extractor
+}
+
+// resolveHTTP takes the first non-empty line of the body without regex and json_path.
+func resolveHTTP(ctx context.Context, e commandExecutor, src sourceConfig) (string, error) {
+	var cfg httpSource
+	if err := json.Unmarshal(src.raw, &cfg); err != nil {
+		return "", err
+	}
+	if cfg.URL == "" {
+		return "", fmt.Errorf("url must be specified")
+	}
+	url := e.dict("").Expand(cfg.URL)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	for k, v := range cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	// the token is sent only to the GitHub API, and not over a user-given Authorization.
+	if err := (githubClient{token: e.githubToken}).authorize(req); err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if wait, limited := githubRateLimit(resp, time.Now()); limited && strings.HasPrefix(url, githubAPI) {
+		return "", githubRateLimitError(req, wait)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("GET %s: %w", url, err)
+	}
+
+	if cfg.Regex == "" && cfg.JSONPath == "" {
+		for line := range strings.Lines(string(body)) {
+			if line = strings.TrimSpace(line); line != "" {
+				return line, nil
+			}
+		}
+		return "", fmt.Errorf("GET %s: empty body", url)
+	}
+	return cfg.Extract(string(body))
+}
@@ -0,0 +1,143 @@
+package manager
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const maxSuggestions = 3
+
+func setNames(sets []namedCommandSet) []string {
+	names := make([]string, len(sets))
+	for i, set := range sets {
+		names[i] = set.Name
+	}
+	return names
+}
+
+// similarNames returns names name is a prefix of, then a subsequence of, then ones within a small edit distance.
+func similarNames(name string, names []string) []string {
+	type candidate struct {
+		name  string
+		class int
+		dist  int
+	}
+	lower := strings.ToLower(name)
+	var found []candidate
+	for _, n := range names {
+		l := strings.ToLower(n)
+		dist := editDistance(lower, l)
+		switch {
+		case n == name:
+		case strings.HasPrefix(l, lower):
+			found = append(found, candidate{n, 0, dist})
+		case isSubsequence(lower, l):
+			found = append(found, candidate{n, 1, dist})
+		case dist <= max(1, len(name)/3):
+			found = append(found, candidate{n, 2, dist})
+		}
+	}
+	slices.SortFunc(found, func(a, b candidate) int {
+		return cmp.Or(cmp.Compare(a.class, b.class), cmp.Compare(a.dist, b.dist), strings.Compare(a.name, b.name))
+	})
+	out := make([]string, len(found))
+	for i, c := range found {
+		out[i] = c.name
+	}
+	return out
+}
+
+func didYouMean(name string, names []string) string {
+	similar := similarNames(name, names)
+	if len(similar) == 0 {
+		return ""
+	}
+	quoted := make([]string, min(len(similar), maxSuggestions))
+	for i := range quoted {
+		quoted[i] = fmt.Sprintf("%q", similar[i])
+	}
+	return ": did you mean " + strings.Join(quoted, " or ") + "?"
+}
+
+func isSubsequence(s, t string) bool {
+	for _, r := range s {
+		i := strings.IndexRune(t, r)
+		if i < 0 {
+			return false
+		}
+		t = t[i+len(string(r)):]
+	}
+	return true
+}
+
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := range ra {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i + 1
+		for j := range rb {
+			cost := 1
+			if ra[i] == rb[j] {
+				cost = 0
+			}
+			cur[j+1] = min(prev[j+1]+1, cur[j]+1, prev[j]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+// fuzzyTarget returns the only name of names that name is a prefix of, or else the only one it is a subsequence of.
+func fuzzyTarget(name string, names []string) (string, bool) {
+	if slices.Contains(names, name) {
+		return "", false
+	}
+	for _, match := range []func(n string) bool{
+		func(n string) bool { return strings.HasPrefix(n, name) },
+		func(n string) bool { return isSubsequence(name, n) },
+	} {
+		matched := slices.DeleteFunc(slices.Clone(names), func(n string) bool { return !match(n) })
+		switch len(matched) {
+		case 0:
+			continue
+		case 1:
+			return matched[0], true
+		}
+		return "", false
+	}
+	return "", false
+}
+
+// expandTargets replaces names naming no set with fuzzyTarget ones if fuzzy_targets of config.json is set.
+func (m *Manager) expandTargets(tgt string) string {
+	if tgt == "" {
+		return tgt
+	}
+	cfg, err := loadGlobalConfig(m.cfgDir)
+	if err != nil || !cfg.FuzzyTargets {
+		// Errors of config.json are reported by whoever needs it.
+		return tgt
+	}
+	names := m.setNames()
+	elems := strings.Split(tgt, ",")
+	for i, e := range elems {
+		name, ver := e, ""
+		if at := strings.LastIndexByte(e, '@'); at >= 0 {
+			name, ver = e[:at], e[at:]
+		}
+		if name == "" || hasMeta(name) {
+			continue
+		}
+		if found, ok := fuzzyTarget(name, names); ok {
+			m.logger().Info("target matched fuzzily", "target", name, "set", found)
+			elems[i] = found + ver
+		}
+	}
+	return strings.Join(elems, ",")
+}
@@ -0,0 +1,164 @@
+package manager
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	syncFileName = ".sync.json"
+)
+
+// syncSource records where a config dir synced from a tarball came from. git records it for git.
+type syncSource struct {
+	URL string `json:"url"`
+}
+
+func isTarballURL(url string) bool {
+	return strings.HasPrefix(url, "https://") && (strings.HasSuffix(url, ".tar.gz") || strings.HasSuffix(url, ".tgz"))
+}
+
+// syncConfig populates an empty cfgDir from url, a git repository or an https tarball.
+// Without url, it updates cfgDir from where it was populated.
+//
+//	sync [<url>]
+func (m *Manager) syncConfig(ctx context.Context, args []string) error {
+	if len(args) > 1 {
+		return configError(fmt.Errorf("sync: wrong args length: want 0 or 1, got %d", len(args)))
+	}
+	var url string
+	if len(args) == 1 {
+		url = args[0]
+	}
+	return syncConfigDir(ctx, m.logger(), m.cfgDir, url)
+}
+
+func syncConfigDir(ctx context.Context, log *slog.Logger, cfgDir, url string) error {
+	if url != "" {
+		entries, err := os.ReadDir(cfgDir)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if len(entries) > 0 {
+			return configError(fmt.Errorf("sync: %s is not empty; run sync without url to update it", cfgDir))
+		}
+		if isTarballURL(url) {
+			return syncTarball(ctx, log, cfgDir, url)
+		}
+		return runGit(ctx, "clone", url, cfgDir)
+	}
+
+	if s, err := os.Stat(filepath.Join(cfgDir, ".git")); err == nil && s.IsDir() {
+		return runGit(ctx, "-C", cfgDir, "pull", "--ff-only")
+	}
+	data, err := os.ReadFile(filepath.Join(cfgDir, syncFileName))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return configError(fmt.Errorf("sync: %s is neither a git repository nor synced from a tarball", cfgDir))
+		}
+		return err
+	}
+	var src syncSource
+	if err := json.Unmarshal(data, &src); err != nil {
+		return configError(fmt.Errorf("%s: %w", syncFileName, err))
+	}
+	return syncTarball(ctx, log, cfgDir, src.URL)
+}
+
+func runGit(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("sync: git %s: %w", args[0], err)
+	}
+	return nil
+}
+
+// syncTarball strips a single top level directory, as in GitHub archives, and keeps files absent from the tarball.
+func syncTarball(ctx context.Context, log *slog.Logger, cfgDir, url string) error {
+	log.Info("downloading", "url", url)
+	data, err := fetch(ctx, url)
+	if err != nil {
+		return fmt.Errorf("sync: %w", err)
+	}
+
+	type file struct {
+		name string
+		mode fs.FileMode
+		data []byte
+	}
+	var (
+		files []file
+		top   = map[string]bool{}
+	)
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("sync: %w", err)
+	}
+	tr := tar.NewReader(gr)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("sync: %w", err)
+		}
+		name := path.Clean(strings.TrimPrefix(h.Name, "./"))
+		if !fs.ValidPath(name) {
+			return fmt.Errorf("sync: invalid path %q in tarball", h.Name)
+		}
+		top[strings.Split(name, "/")[0]] = true
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return fmt.Errorf("sync: %w", err)
+		}
+		files = append(files, file{name: name, mode: h.FileInfo().Mode().Perm(), data: content})
+	}
+
+	var prefix string
+	if len(top) == 1 {
+		for dir := range top {
+			prefix = dir + "/"
+		}
+		if len(files) == 1 && files[0].name+"/" == prefix {
+			prefix = ""
+		}
+	}
+	for _, f := range files {
+		name := filepath.Join(cfgDir, filepath.FromSlash(strings.TrimPrefix(f.name, prefix)))
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			return err
+		}
+		if err := writeFileAtomic(name, f.data, f.mode); err != nil {
+			return err
+		}
+	}
+
+	out, err := json.MarshalIndent(syncSource{URL: url}, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(cfgDir, syncFileName), append(out, '\n'), 0o644); err != nil {
+		return err
+	}
+	log.Info("synced", "files", len(files), "dir", cfgDir)
+	return nil
+}
@@ -0,0 +1,230 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Backends passing through to system package managers. Each is the package name as the manager knows it.
+//
+//	{"brew": "jq"}
+//	{"apt": "jq"}
+//	{"scoop": "jq"}
+//	{"winget": "jqlang.jq"}
+
+var systemCommands = []command{commandVer, commandChecklatest, commandInstall, commandUpdate, commandUninstall}
+
+func keyValue(out, key string) (string, bool) {
+	for line := range strings.Lines(out) {
+		k, v, ok := strings.Cut(line, ":")
+		if ok && strings.TrimSpace(k) == key {
+			return strings.TrimSpace(v), true
+		}
+	}
+	return "", false
+}
+
+// brewBackend ignores ver since Homebrew only installs the latest version.
+type brewBackend string
+
+func (b *brewBackend) Commands() []command { return systemCommands }
+
+func (b *brewBackend) Validate() error { return validatePackageName("brew", string(*b)) }
+
+func (b *brewBackend) Exec(ctx context.Context, e commandExecutor, kind command, ver string) (string, error) {
+	name := string(*b)
+	switch kind {
+	case commandVer:
+		// "jq 1.7.1 1.6", newest first.
+		out, err := run(ctx, e, "brew", "list", "--versions", name)
+		fields := strings.Fields(out)
+		if err != nil || len(fields) < 2 {
+			return "", fmt.Errorf("brew: %s is not installed", name)
+		}
+		return fields[1], nil
+	case commandChecklatest:
+		out, err := run(ctx, e, "brew", "info", "--json=v2", name)
+		if err != nil {
+			return "", err
+		}
+		var res struct {
+			Formulae []struct {
+				Versions struct {
+					Stable string `json:"stable"`
+				} `json:"versions"`
+			} `json:"formulae"`
+			Casks []struct {
+				Version string `json:"version"`
+			} `json:"casks"`
+		}
+		if err := json.Unmarshal([]byte(out), &res); err != nil {
+			return "", fmt.Errorf("brew: decoding brew info: %w", err)
+		}
+		switch {
+		case len(res.Formulae) > 0:
+			return res.Formulae[0].Versions.Stable, nil
+		case len(res.Casks) > 0:
+			return res.Casks[0].Version, nil
+		}
+		return "", fmt.Errorf("brew: no formula or cask %s", name)
+	case commandInstall:
+		return "", runOut(ctx, e, "brew", "install", name)
+	case commandUpdate:
+		return "", runOut(ctx, e, "brew", "upgrade", name)
+	case commandUninstall:
+		return "", runOut(ctx, e, "brew", "uninstall", name)
+	}
+	return "", fmt.Errorf("brew: %s is not supported", kind)
+}
+
+// aptBackend runs apt-get under sudo unless already root.
+type aptBackend string
+
+func (a *aptBackend) Commands() []command { return systemCommands }
+
+func (a *aptBackend) Validate() error { return validatePackageName("apt", string(*a)) }
+
+func (a *aptBackend) aptGet(args ...string) []string {
+	args = append([]string{"apt-get", "--yes"}, args...)
+	if os.Geteuid() != 0 {
+		args = append([]string{"sudo"}, args...)
+	}
+	return args
+}
+
+func (a *aptBackend) Exec(ctx context.Context, e commandExecutor, kind command, ver string) (string, error) {
+	name := string(*a)
+	pkg := name
+	if ver != "" {
+		pkg += "=" + ver
+	}
+	switch kind {
+	case commandVer:
+		out, err := run(ctx, e, "dpkg-query", "--show", "--showformat=${Version}", name)
+		if err != nil || out == "" {
+			return "", fmt.Errorf("apt: %s is not installed", name)
+		}
+		return out, nil
+	case commandChecklatest:
+		out, err := run(ctx, e, "apt-cache", "policy", name)
+		if err != nil {
+			return "", err
+		}
+		v, ok := keyValue(out, "Candidate")
+		if !ok || v == "(none)" {
+			return "", fmt.Errorf("apt: no candidate for %s", name)
+		}
+		return v, nil
+	case commandInstall:
+		return "", runOut(ctx, e, a.aptGet("install", pkg)...)
+	case commandUpdate:
+		return "", runOut(ctx, e, a.aptGet("install", "--only-upgrade", pkg)...)
+	case commandUninstall:
+		return "", runOut(ctx, e, a.aptGet("remove", name)...)
+	}
+	return "", fmt.Errorf("apt: %s is not supported", kind)
+}
+
+type scoopBackend string
+
+func (s *scoopBackend) Commands() []command { return systemCommands }
+
+func (s *scoopBackend) Validate() error { return validatePackageName("scoop", string(*s)) }
+
+func (s *scoopBackend) Exec(ctx context.Context, e commandExecutor, kind command, ver string) (string, error) {
+	name := string(*s)
+	switch kind {
+	case commandVer:
+		// A table of "Name Version Source Updated Info".
+		out, err := run(ctx, e, "scoop", "list", name)
+		if err != nil {
+			return "", err
+		}
+		for line := range strings.Lines(out) {
+			if fields := strings.Fields(line); len(fields) >= 2 && strings.EqualFold(fields[0], name) {
+				return fields[1], nil
+			}
+		}
+		return "", fmt.Errorf("scoop: %s is not installed", name)
+	case commandChecklatest:
+		out, err := run(ctx, e, "scoop", "info", name)
+		if err != nil {
+			return "", err
+		}
+		v, ok := keyValue(out, "Version")
+		if !ok {
+			return "", fmt.Errorf("scoop: no version in scoop info %s", name)
+		}
+		return v, nil
+	case commandInstall:
+		if ver != "" {
+			return "", runOut(ctx, e, "scoop", "install", name+"@"+ver)
+		}
+		return "", runOut(ctx, e, "scoop", "install", name)
+	case commandUpdate:
+		// scoop update always moves to the latest version of the bucket.
+		return "", runOut(ctx, e, "scoop", "update", name)
+	case commandUninstall:
+		return "", runOut(ctx, e, "scoop", "uninstall", name)
+	}
+	return "", fmt.Errorf("scoop: %s is not supported", kind)
+}
+
+// wingetBackend names the package id, matched exactly.
+type wingetBackend string
+
+func (w *wingetBackend) Commands() []command { return systemCommands }
+
+func (w *wingetBackend) Validate() error { return validatePackageName("winget", string(*w)) }
+
+func (w *wingetBackend) winget(cmd string, extra ...string) []string {
+	args := []string{"winget", cmd, "--id", string(*w), "--exact", "--accept-source-agreements"}
+	return append(args, extra...)
+}
+
+func (w *wingetBackend) Exec(ctx context.Context, e commandExecutor, kind command, ver string) (string, error) {
+	name := string(*w)
+	var extra []string
+	if ver != "" {
+		extra = []string{"--version", ver}
+	}
+	switch kind {
+	case commandVer:
+		// A table of "Name Id Version Available Source"; the version follows the id.
+		out, err := run(ctx, e, w.winget("list")...)
+		if err != nil {
+			return "", fmt.Errorf("winget: %s is not installed", name)
+		}
+		for line := range strings.Lines(out) {
+			fields := strings.Fields(line)
+			for i, f := range fields {
+				if strings.EqualFold(f, name) && i+1 < len(fields) {
+					return fields[i+1], nil
+				}
+			}
+		}
+		return "", fmt.Errorf("winget: %s is not installed", name)
+	case commandChecklatest:
+		out, err := run(ctx, e, w.winget("show")...)
+		if err != nil {
+			return "", err
+		}
+		v, ok := keyValue(out, "Version")
+		if !ok {
+			return "", fmt.Errorf("winget: no version in winget show %s", name)
+		}
+		return v, nil
+	case commandInstall, commandUpdate:
+		cmd := "install"
+		if kind == commandUpdate {
+			cmd = "upgrade"
+		}
+		return "", runOut(ctx, e, w.winget(cmd, append(extra, "--silent", "--accept-package-agreements")...)...)
+	case commandUninstall:
+		return "", runOut(ctx, e, w.winget("uninstall", "--silent")...)
+	}
+	return "", fmt.Errorf("winget: %s is not supported", kind)
+}
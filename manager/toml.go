@@ -0,0 +1,556 @@
+package manager
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// parseTOML parses the subset of TOML v1.0 command sets need:
+// bare, quoted and dotted keys, [tables], [[arrays of tables]], basic, literal and multi-line strings,
+// integers, floats, booleans, arrays and inline tables. Anything else, e.g. dates and times, inf and nan,
+// is rejected with an error.
+func parseTOML(data []byte) (map[string]any, error) {
+	p := &tomlParser{s: string(data)}
+	root := map[string]any{}
+	if err := p.parse(root); err != nil {
+		return nil, fmt.Errorf("line %d: %w", strings.Count(p.s[:p.pos], "\n")+1, err)
+	}
+	return root, nil
+}
+
+type tomlParser struct {
+	s   string
+	pos int
+}
+
+func (p *tomlParser) eof() bool { return p.pos >= len(p.s) }
+
+func (p *tomlParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *tomlParser) skipSpaces() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+func (p *tomlParser) skipComment() {
+	if p.peek() == '#' {
+		for !p.eof() && p.peek() != '\n' {
+			p.pos++
+		}
+	}
+}
+
+func (p *tomlParser) skipBlank() {
+	for {
+		p.skipSpaces()
+		p.skipComment()
+		if p.eof() || (p.peek() != '\n' && p.peek() != '\r') {
+			return
+		}
+		p.pos++
+	}
+}
+
+func (p *tomlParser) endOfLine() error {
+	p.skipSpaces()
+	p.skipComment()
+	if p.eof() {
+		return nil
+	}
+	if strings.HasPrefix(p.s[p.pos:], "\r\n") {
+		p.pos += 2
+		return nil
+	}
+	if p.peek() != '\n' {
+		return fmt.Errorf("unexpected %q after value", p.peek())
+	}
+	p.pos++
+	return nil
+}
+
+func (p *tomlParser) parse(root map[string]any) error {
+	cur := root
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return nil
+		}
+		if p.peek() != '[' {
+			key, err := p.key()
+			if err != nil {
+				return err
+			}
+			p.skipSpaces()
+			if p.peek() != '=' {
+				return fmt.Errorf("expected = after key %q", strings.Join(key, "."))
+			}
+			p.pos++
+			p.skipSpaces()
+			v, err := p.value()
+			if err != nil {
+				return err
+			}
+			if err := setTOMLKey(cur, key, v); err != nil {
+				return err
+			}
+			if err := p.endOfLine(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		p.pos++
+		array := p.peek() == '['
+		if array {
+			p.pos++
+		}
+		p.skipSpaces()
+		key, err := p.key()
+		if err != nil {
+			return err
+		}
+		closing := "]"
+		if array {
+			closing = "]]"
+		}
+		if !strings.HasPrefix(p.s[p.pos:], closing) {
+			return fmt.Errorf("expected %s after table name", closing)
+		}
+		p.pos += len(closing)
+
+		parent, err := tomlTable(root, key[:len(key)-1])
+		if err != nil {
+			return err
+		}
+		last := key[len(key)-1]
+		if array {
+			arr, _ := parent[last].([]any)
+			if _, exists := parent[last]; exists && arr == nil {
+				return fmt.Errorf("%q is not an array of tables", strings.Join(key, "."))
+			}
+			cur = map[string]any{}
+			parent[last] = append(arr, cur)
+		} else {
+			if cur, err = tomlTable(parent, []string{last}); err != nil {
+				return err
+			}
+		}
+		if err := p.endOfLine(); err != nil {
+			return err
+		}
+	}
+}
+
+// tomlTable returns the table at path under t, creating missing ones. The last element of an array of tables stands for the array.
+func tomlTable(t map[string]any, path []string) (map[string]any, error) {
+	for _, k := range path {
+		switch v := t[k].(type) {
+		case nil:
+			next := map[string]any{}
+			t[k] = next
+			t = next
+		case map[string]any:
+			t = v
+		case []any:
+			if len(v) == 0 {
+				return nil, fmt.Errorf("%q is not a table", k)
+			}
+			last, ok := v[len(v)-1].(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%q is not a table", k)
+			}
+			t = last
+		default:
+			return nil, fmt.Errorf("%q is not a table", k)
+		}
+	}
+	return t, nil
+}
+
+func setTOMLKey(t map[string]any, key []string, v any) error {
+	t, err := tomlTable(t, key[:len(key)-1])
+	if err != nil {
+		return err
+	}
+	last := key[len(key)-1]
+	if _, ok := t[last]; ok {
+		return fmt.Errorf("duplicate key %q", strings.Join(key, "."))
+	}
+	t[last] = v
+	return nil
+}
+
+var tomlBareKeyRe = regexp.MustCompile(`^[A-Za-z0-9_-]+`)
+
+func (p *tomlParser) key() ([]string, error) {
+	var key []string
+	for {
+		p.skipSpaces()
+		switch p.peek() {
+		case '"':
+			s, err := p.basicString()
+			if err != nil {
+				return nil, err
+			}
+			key = append(key, s)
+		case '\'':
+			s, err := p.literalString()
+			if err != nil {
+				return nil, err
+			}
+			key = append(key, s)
+		default:
+			bare := tomlBareKeyRe.FindString(p.s[p.pos:])
+			if bare == "" {
+				return nil, fmt.Errorf("invalid key at %q", p.rest())
+			}
+			p.pos += len(bare)
+			key = append(key, bare)
+		}
+		p.skipSpaces()
+		if p.peek() != '.' {
+			return key, nil
+		}
+		p.pos++
+	}
+}
+
+func (p *tomlParser) rest() string {
+	line, _, _ := strings.Cut(p.s[p.pos:], "\n")
+	return line
+}
+
+func (p *tomlParser) value() (any, error) {
+	switch c := p.peek(); {
+	case strings.HasPrefix(p.s[p.pos:], `"""`):
+		return p.multilineString(`"""`, true)
+	case strings.HasPrefix(p.s[p.pos:], `'''`):
+		return p.multilineString(`'''`, false)
+	case c == '"':
+		return p.basicString()
+	case c == '\'':
+		return p.literalString()
+	case c == '[':
+		return p.array()
+	case c == '{':
+		return p.inlineTable()
+	}
+
+	end := strings.IndexAny(p.s[p.pos:], " \t\r\n,]}#")
+	if end < 0 {
+		end = len(p.s) - p.pos
+	}
+	tok := p.s[p.pos : p.pos+end]
+	switch tok {
+	case "true", "false":
+		p.pos += end
+		return tok == "true", nil
+	case "":
+		return nil, fmt.Errorf("missing value")
+	}
+	num := strings.ReplaceAll(tok, "_", "")
+	switch {
+	case tomlIntRe.MatchString(tok):
+		if i, err := strconv.ParseInt(num, 0, 64); err == nil {
+			p.pos += end
+			return i, nil
+		}
+	case tomlFloatRe.MatchString(tok):
+		if f, err := strconv.ParseFloat(num, 64); err == nil {
+			p.pos += end
+			return f, nil
+		}
+	case tomlDateTimeRe.MatchString(tok):
+		return nil, fmt.Errorf("unsupported value %q: dates and times are not supported", tok)
+	case strings.TrimLeft(tok, "+-") == "inf" || strings.TrimLeft(tok, "+-") == "nan":
+		return nil, fmt.Errorf("unsupported value %q: inf and nan are not supported", tok)
+	}
+	return nil, fmt.Errorf("unsupported value %q", tok)
+}
+
+var (
+	tomlIntRe      = regexp.MustCompile(`^([-+]?(0|[1-9](_?[0-9])*)|0x[0-9A-Fa-f](_?[0-9A-Fa-f])*|0o[0-7](_?[0-7])*|0b[01](_?[01])*)$`)
+	tomlFloatRe    = regexp.MustCompile(`^[-+]?(0|[1-9](_?[0-9])*)(\.[0-9](_?[0-9])*)?([eE][-+]?[0-9](_?[0-9])*)?$`)
+	tomlDateTimeRe = regexp.MustCompile(`^([0-9]{4}-[0-9]{2}-[0-9]{2}|[0-9]{2}:[0-9]{2})`)
+)
+
+func (p *tomlParser) basicString() (string, error) {
+	p.pos++ // "
+	var b strings.Builder
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", fmt.Errorf("unterminated string")
+		}
+		c := p.peek()
+		switch c {
+		case '"':
+			p.pos++
+			return b.String(), nil
+		case '\\':
+			if err := p.escape(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+func (p *tomlParser) escape(b *strings.Builder) error {
+	p.pos++ // \
+	if p.eof() {
+		return fmt.Errorf("unterminated escape")
+	}
+	c := p.peek()
+	p.pos++
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case '"', '\\':
+		b.WriteByte(c)
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		if p.pos+n > len(p.s) {
+			return fmt.Errorf("invalid unicode escape")
+		}
+		r, err := strconv.ParseUint(p.s[p.pos:p.pos+n], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(r)) {
+			return fmt.Errorf("invalid unicode escape %q", p.s[p.pos:p.pos+n])
+		}
+		b.WriteRune(rune(r))
+		p.pos += n
+	default:
+		return fmt.Errorf("invalid escape \\%c", c)
+	}
+	return nil
+}
+
+func (p *tomlParser) literalString() (string, error) {
+	p.pos++ // '
+	end := strings.IndexAny(p.s[p.pos:], "'\n")
+	if end < 0 || p.s[p.pos+end] != '\'' {
+		return "", fmt.Errorf("unterminated string")
+	}
+	s := p.s[p.pos : p.pos+end]
+	p.pos += end + 1
+	return s, nil
+}
+
+// multilineString trims a newline right after the opening delimiter.
+func (p *tomlParser) multilineString(delim string, basic bool) (string, error) {
+	p.pos += len(delim)
+	if strings.HasPrefix(p.s[p.pos:], "\r\n") {
+		p.pos += 2
+	} else if p.peek() == '\n' {
+		p.pos++
+	}
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", fmt.Errorf("unterminated string")
+		}
+		if strings.HasPrefix(p.s[p.pos:], delim) {
+			p.pos += len(delim)
+			return b.String(), nil
+		}
+		if basic && p.peek() == '\\' {
+			// A line ending backslash trims the newline and following whitespace.
+			if rest := strings.TrimLeft(p.s[p.pos+1:], " \t"); strings.HasPrefix(rest, "\n") || strings.HasPrefix(rest, "\r\n") {
+				p.pos = len(p.s) - len(strings.TrimLeft(rest, " \t\r\n"))
+				continue
+			}
+			if err := p.escape(&b); err != nil {
+				return "", err
+			}
+			continue
+		}
+		b.WriteByte(p.peek())
+		p.pos++
+	}
+}
+
+func (p *tomlParser) array() ([]any, error) {
+	p.pos++ // [
+	arr := []any{}
+	for {
+		p.skipBlank()
+		if p.peek() == ']' {
+			p.pos++
+			return arr, nil
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, v)
+		p.skipBlank()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+		default:
+			return nil, fmt.Errorf("expected , or ] in array")
+		}
+	}
+}
+
+func (p *tomlParser) inlineTable() (map[string]any, error) {
+	p.pos++ // {
+	t := map[string]any{}
+	p.skipSpaces()
+	if p.peek() == '}' {
+		p.pos++
+		return t, nil
+	}
+	for {
+		p.skipSpaces()
+		if p.peek() == '\n' || p.peek() == '\r' {
+			return nil, fmt.Errorf("newlines are not allowed in inline tables")
+		}
+		key, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != '=' {
+			return nil, fmt.Errorf("expected = after key %q", strings.Join(key, "."))
+		}
+		p.pos++
+		p.skipSpaces()
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		if err := setTOMLKey(t, key, v); err != nil {
+			return nil, err
+		}
+		p.skipSpaces()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return t, nil
+		case '\n', '\r':
+			return nil, fmt.Errorf("newlines are not allowed in inline tables")
+		default:
+			return nil, fmt.Errorf("expected , or } in inline table")
+		}
+	}
+}
+
+// writeTOML writes root as TOML. Objects inside arrays become inline tables and nulls are dropped.
+func writeTOML(w io.Writer, root *orderedObject) error {
+	return writeTOMLTable(w, nil, root)
+}
+
+func writeTOMLTable(w io.Writer, path []string, t *orderedObject) error {
+	var tables, arrays []int
+	for i, k := range t.keys {
+		switch v := t.values[i].(type) {
+		case nil:
+		case *orderedObject:
+			tables = append(tables, i)
+		case []any:
+			if len(v) > 0 && allObjects(v) {
+				arrays = append(arrays, i)
+				continue
+			}
+			fmt.Fprintf(w, "%s = %s\n", tomlKey(k), tomlInline(v))
+		default:
+			fmt.Fprintf(w, "%s = %s\n", tomlKey(k), tomlInline(v))
+		}
+	}
+	for _, i := range tables {
+		p := append(append([]string(nil), path...), tomlKey(t.keys[i]))
+		sub := t.values[i].(*orderedObject)
+		// A table only having tables needs no header of its own.
+		if !slices.ContainsFunc(sub.values, func(v any) bool { _, ok := v.(*orderedObject); return !ok }) && len(sub.keys) > 0 {
+			if err := writeTOMLTable(w, p, sub); err != nil {
+				return err
+			}
+			continue
+		}
+		fmt.Fprintf(w, "\n[%s]\n", strings.Join(p, "."))
+		if err := writeTOMLTable(w, p, sub); err != nil {
+			return err
+		}
+	}
+	for _, i := range arrays {
+		p := append(append([]string(nil), path...), tomlKey(t.keys[i]))
+		for _, e := range t.values[i].([]any) {
+			fmt.Fprintf(w, "\n[[%s]]\n", strings.Join(p, "."))
+			if err := writeTOMLTable(w, p, e.(*orderedObject)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func allObjects(arr []any) bool {
+	for _, e := range arr {
+		if _, ok := e.(*orderedObject); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func tomlKey(k string) string {
+	if tomlBareKeyRe.FindString(k) == k && k != "" {
+		return k
+	}
+	return quoteJSON(k)
+}
+
+func tomlInline(v any) string {
+	switch v := v.(type) {
+	case *orderedObject:
+		var parts []string
+		for i, k := range v.keys {
+			if v.values[i] != nil {
+				parts = append(parts, tomlKey(k)+" = "+tomlInline(v.values[i]))
+			}
+		}
+		if len(parts) == 0 {
+			return "{}"
+		}
+		return "{ " + strings.Join(parts, ", ") + " }"
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, e := range v {
+			if e != nil {
+				parts = append(parts, tomlInline(e))
+			}
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case string:
+		// JSON string escapes are valid in TOML basic strings.
+		return quoteJSON(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
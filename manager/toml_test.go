@@ -0,0 +1,91 @@
+package manager
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	for _, tc := range []struct {
+		name, in, want string
+	}{
+		{"empty", "", `{}`},
+		{"comments", "# comment\n\na = 1 # trailing\n", `{"a": 1}`},
+		{"strings", `a = "x\ty\u00e9\"" ` + "\nb = 'C:\\path'\n", `{"a": "x\tyé\"", "b": "C:\\path"}`},
+		{"multiline basic", "a = \"\"\"\nline1\nline2\\\n   continued\"\"\"\n", `{"a": "line1\nline2continued"}`},
+		{"multiline literal", "a = '''\nraw \\n\n'''\n", `{"a": "raw \\n\n"}`},
+		{"numbers", "a = 1_000\nb = -3\nc = 0x1f\nd = 1.5e2\ne = +0.5\n", `{"a": 1000, "b": -3, "c": 31, "d": 150, "e": 0.5}`},
+		{"booleans", "a = true\nb = false\n", `{"a": true, "b": false}`},
+		{"arrays", "a = [\n  \"x\", # comment\n  1,\n  [true],\n]\n", `{"a": ["x", 1, [true]]}`},
+		{"inline table", `a = { b = "c", d.e = 1 }`, `{"a": {"b": "c", "d": {"e": 1}}}`},
+		{"dotted and quoted keys", "a.b = 1\n\"c.d\" = 2\n'e f'.g = 3\n", `{"a": {"b": 1}, "c.d": 2, "e f": {"g": 3}}`},
+		{"tables", "[env]\nA = \"1\"\n[x.y]\nz = 2\n", `{"env": {"A": "1"}, "x": {"y": {"z": 2}}}`},
+		{"array of tables", "[[h]]\nn = 1\n[h.sub]\nk = 1\n[[h]]\nn = 2\n", `{"h": [{"n": 1, "sub": {"k": 1}}, {"n": 2}]}`},
+		{"crlf", "a = 1\r\nb = \"x\"\r\n", `{"a": 1, "b": "x"}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseTOML([]byte(tc.in))
+			if err != nil {
+				t.Fatalf("parseTOML: %v", err)
+			}
+			assertJSON(t, got, tc.want)
+		})
+	}
+}
+
+func TestParseTOMLError(t *testing.T) {
+	for _, tc := range []struct {
+		name, in, want string
+	}{
+		{"no equals", "a 1\n", "line 1: expected ="},
+		{"missing value", "a =\n", "line 1: missing value"},
+		{"duplicate key", "a = 1\na = 2\n", `line 2: duplicate key "a"`},
+		{"unterminated string", `a = "x`, "line 1"},
+		{"unterminated array", "a = [1, 2\n", "line"},
+		{"bad escape", `a = "\q"`, "line 1"},
+		{"leading zero", "a = 012\n", `unsupported value "012"`},
+		{"date", "a = 1979-05-27\n", "dates and times are not supported"},
+		{"time", "a = 07:32:00\n", "dates and times are not supported"},
+		{"inf", "a = -inf\n", "inf and nan are not supported"},
+		{"nan", "a = nan\n", "inf and nan are not supported"},
+		{"bad underscore", "a = 1__0\n", `unsupported value "1__0"`},
+		{"signed hex", "a = +0x1f\n", `unsupported value "+0x1f"`},
+		{"multiline inline table", "a = { b = 1,\n c = 2 }\n", "newlines are not allowed in inline tables"},
+		{"trailing content", "a = 1 2\n", "line 1"},
+		{"unclosed table", "[a\n", "line 1: expected ]"},
+		{"table over value", "a = 1\n[a]\n", `line 2: "a" is not a table`},
+		{"array of tables over value", "a = 1\n[[a]]\n", `"a" is not an array of tables`},
+		{"invalid key", "= 1\n", "line 1: invalid key"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseTOML([]byte(tc.in))
+			if err == nil {
+				t.Fatalf("parseTOML succeeded, want an error containing %q", tc.want)
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("error %q does not contain %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestTOMLRoundTrip(t *testing.T) {
+	for _, doc := range roundTripDocs {
+		var v any
+		if err := json.Unmarshal([]byte(doc), &v); err != nil {
+			t.Fatal(err)
+		}
+		data, err := marshalAs(".toml", v)
+		if err != nil {
+			t.Errorf("marshalAs(%s): %v", doc, err)
+			continue
+		}
+		got, err := parseTOML(data)
+		if err != nil {
+			t.Errorf("parseTOML of\n%s\nfrom %s: %v", data, doc, err)
+			continue
+		}
+		assertJSON(t, got, doc)
+	}
+}
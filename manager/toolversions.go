@@ -0,0 +1,84 @@
+package manager
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const toolVersionsFileName = ".tool-versions"
+
+// parseToolVersions returns only the first version of each tool.
+func parseToolVersions(data []byte) map[string]string {
+	vers := map[string]string{}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		if _, ok := vers[fields[0]]; !ok {
+			vers[fields[0]] = fields[1]
+		}
+	}
+	return vers
+}
+
+// updateToolVersions replaces versions of listed tools and appends the others sorted by name, keeping other lines.
+func updateToolVersions(data []byte, versions map[string]string) []byte {
+	var b bytes.Buffer
+	done := map[string]bool{}
+	for line := range strings.Lines(string(data)) {
+		content, comment, hasComment := strings.Cut(strings.TrimRight(line, "\r\n"), "#")
+		fields := strings.Fields(content)
+		ver, ok := "", false
+		if len(fields) > 0 {
+			ver, ok = versions[fields[0]]
+		}
+		if !ok || done[fields[0]] {
+			b.WriteString(strings.TrimRight(line, "\r\n") + "\n")
+			continue
+		}
+		done[fields[0]] = true
+		b.WriteString(fields[0] + " " + ver)
+		if hasComment {
+			b.WriteString(" #" + comment)
+		}
+		b.WriteByte('\n')
+	}
+	for _, tool := range slices.Sorted(maps.Keys(versions)) {
+		if !done[tool] {
+			fmt.Fprintf(&b, "%s %s\n", tool, versions[tool])
+		}
+	}
+	return b.Bytes()
+}
+
+// findToolVersion returns the version of name in the nearest .tool-versions listing it, and its path. ver is "" if none.
+func findToolVersion(dir, name string) (ver, file string, err error) {
+	for {
+		p := filepath.Join(dir, toolVersionsFileName)
+		data, err := os.ReadFile(p)
+		switch {
+		case err == nil:
+			if v, ok := parseToolVersions(data)[name]; ok {
+				return v, p, nil
+			}
+		case !errors.Is(err, fs.ErrNotExist):
+			return "", "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", nil
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,236 @@
+package manager
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tracingConfig exports traces of runs over OTLP/HTTP with JSON encoding. OTEL_EXPORTER_OTLP_* and OTEL_SERVICE_NAME
+// are used for what it leaves unset.
+//
+//	{"tracing": {"endpoint": "http://collector:4318", "headers": {"authorization": "Bearer ..."}}}
+type tracingConfig struct {
+	// Endpoint gets "/v1/traces" appended.
+	Endpoint    string            `json:"endpoint,omitzero"`
+	Headers     map[string]string `json:"headers,omitzero"`
+	ServiceName string            `json:"service_name,omitzero"`
+}
+
+func (c *tracingConfig) Validate() error {
+	if c == nil || c.Endpoint == "" {
+		return nil
+	}
+	if !strings.HasPrefix(c.Endpoint, "https://") && !strings.HasPrefix(c.Endpoint, "http://") {
+		return fmt.Errorf("tracing: endpoint must be http(s), got %q", c.Endpoint)
+	}
+	return nil
+}
+
+func (c *tracingConfig) url() string {
+	if c != nil && c.Endpoint != "" {
+		return strings.TrimSuffix(c.Endpoint, "/") + "/v1/traces"
+	}
+	if u := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"); u != "" {
+		return u
+	}
+	if u := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); u != "" {
+		return strings.TrimSuffix(u, "/") + "/v1/traces"
+	}
+	return ""
+}
+
+func (c *tracingConfig) headers() map[string]string {
+	h := map[string]string{}
+	for kv := range strings.SplitSeq(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), ",") {
+		k, v, ok := strings.Cut(kv, "=")
+		if ok && strings.TrimSpace(k) != "" {
+			h[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	if c != nil {
+		for k, v := range c.Headers {
+			h[k] = v
+		}
+	}
+	return h
+}
+
+// newTracer returns nil if tracing is not configured.
+func (c *tracingConfig) newTracer(name string, attrs map[string]string) *tracer {
+	url := c.url()
+	if url == "" {
+		return nil
+	}
+	var service string
+	if c != nil {
+		service = c.ServiceName
+	}
+	t := &tracer{
+		url:     url,
+		headers: c.headers(),
+		service: cmp.Or(service, os.Getenv("OTEL_SERVICE_NAME"), "ngpkgmgr"),
+	}
+	_, _ = rand.Read(t.traceID[:])
+	t.root = span{id: newSpanID(), name: name, start: time.Now(), attrs: attrs}
+	return t
+}
+
+// tracer records the root span of a run and a child span per command of each set. A nil tracer does nothing.
+type tracer struct {
+	url     string
+	headers map[string]string
+	service string
+	traceID [16]byte
+	root    span
+
+	mu    sync.Mutex
+	spans []span
+}
+
+type span struct {
+	id         [8]byte
+	name       string
+	start, end time.Time
+	attrs      map[string]string
+	err        string
+}
+
+func newSpanID() [8]byte {
+	var id [8]byte
+	_, _ = rand.Read(id[:])
+	return id
+}
+
+func (t *tracer) add(name string, start time.Time, attrs map[string]string, err error) {
+	if t == nil {
+		return
+	}
+	s := span{id: newSpanID(), name: name, start: start, end: time.Now(), attrs: attrs}
+	if err != nil {
+		s.err = err.Error()
+	}
+	t.mu.Lock()
+	t.spans = append(t.spans, s)
+	t.mu.Unlock()
+}
+
+var traceTimeout = 10 * time.Second
+
+func (t *tracer) export(ctx context.Context, err error) error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.root.end = time.Now()
+	if err != nil {
+		t.root.err = err.Error()
+	}
+
+	traceID := hex.EncodeToString(t.traceID[:])
+	spans := []otlpSpan{t.root.otlp(traceID, "")}
+	for _, s := range t.spans {
+		spans = append(spans, s.otlp(traceID, hex.EncodeToString(t.root.id[:])))
+	}
+	payload := map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{
+				"attributes": otlpAttributes(map[string]string{"service.name": t.service, "host.name": hostname()}),
+			},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]string{"name": "ngpkgmgr", "version": version},
+				"spans": spans,
+			}},
+		}},
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, traceTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ngpkgmgr/"+version)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("POST %s: %s", t.url, resp.Status)
+	}
+	return nil
+}
+
+// otlpSpan is a span in the JSON encoding of OTLP, where ids are hex and times are decimal strings.
+type otlpSpan struct {
+	TraceID      string          `json:"traceId"`
+	SpanID       string          `json:"spanId"`
+	ParentSpanID string          `json:"parentSpanId,omitzero"`
+	Name         string          `json:"name"`
+	Kind         int             `json:"kind"`
+	Start        string          `json:"startTimeUnixNano"`
+	End          string          `json:"endTimeUnixNano"`
+	Attributes   []otlpAttribute `json:"attributes,omitzero"`
+	Status       otlpStatus      `json:"status"`
+}
+
+type otlpAttribute struct {
+	Key   string            `json:"key"`
+	Value map[string]string `json:"value"`
+}
+
+type otlpStatus struct {
+	// Code is 1 for ok and 2 for error.
+	Code    int    `json:"code"`
+	Message string `json:"message,omitzero"`
+}
+
+func (s span) otlp(traceID, parent string) otlpSpan {
+	status := otlpStatus{Code: 1}
+	if s.err != "" {
+		status = otlpStatus{Code: 2, Message: s.err}
+	}
+	return otlpSpan{
+		TraceID:      traceID,
+		SpanID:       hex.EncodeToString(s.id[:]),
+		ParentSpanID: parent,
+		Name:         s.name,
+		Kind:         1, // internal
+		Start:        strconv.FormatInt(s.start.UnixNano(), 10),
+		End:          strconv.FormatInt(s.end.UnixNano(), 10),
+		Attributes:   otlpAttributes(s.attrs),
+		Status:       status,
+	}
+}
+
+func otlpAttributes(attrs map[string]string) []otlpAttribute {
+	var out []otlpAttribute
+	for _, k := range slices.Sorted(maps.Keys(attrs)) {
+		if attrs[k] != "" {
+			out = append(out, otlpAttribute{Key: k, Value: map[string]string{"stringValue": attrs[k]}})
+		}
+	}
+	return out
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+
+package manager
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+)
+
+// tui fails; the tui needs raw terminal mode, which is supported only on Linux and macOS.
+func (m *Manager) tui(_ context.Context, _ []string) error {
+	return configError(fmt.Errorf("tui is not supported on %s", runtime.GOOS))
+}
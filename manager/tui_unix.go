@@ -0,0 +1,471 @@
+//go:build linux || darwin
+
+package manager
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+	"unsafe"
+)
+
+const tuiMaxLogLines = 1000
+
+const tuiHelp = "↑/↓ move  space select  a select outdated  enter install/update  r recheck  q quit"
+
+// tui shows a table of sets with their versions, installing or updating selected ones with the log of the set
+// under the cursor. Commands run as under -non-interactive and -yes, since the terminal belongs to the table.
+//
+//	tui [<tgt>]
+func (m *Manager) tui(ctx context.Context, args []string) error {
+	fset := flag.NewFlagSet("tui", flag.ContinueOnError)
+	if err := fset.Parse(args); err != nil {
+		return configError(err)
+	}
+	if fset.NArg() > 1 {
+		return configError(fmt.Errorf("tui: wrong args length: want 0 or 1, got %d", fset.NArg()))
+	}
+	if m.opts.NonInteractive || !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		return configError(errors.New("tui: stdin and stdout must be terminals"))
+	}
+	pinned, err := loadPinnedVersions(m.cfgDir)
+	if err != nil {
+		return configError(err)
+	}
+	sets, err := m.resolveTargets(fset.Arg(0))
+	if err != nil {
+		return configError(err)
+	}
+	if len(sets) == 0 {
+		return configError(errors.New("tui: no command set"))
+	}
+	// fails early on a broken config.json, which every runner loads.
+	if _, err := m.newRunner(commandUpdate, nil, pinned, outputText); err != nil {
+		return err
+	}
+
+	t := &tuiModel{m: m, pinned: pinned, color: useColor(m.opts.Color, os.Stdout)}
+	for _, set := range sets {
+		t.rows = append(t.rows, &tuiRow{set: set, log: &tuiLog{}})
+	}
+
+	restore, err := makeRaw(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("tui: %w", err)
+	}
+	defer restore()
+	// alternate screen, hidden cursor.
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer fmt.Print("\x1b[?25h\x1b[?1049l")
+
+	input := make(chan []byte, 16)
+	defer forwardStdin(os.Stdin, tuiInput(input))()
+
+	actx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	t.mu.Lock()
+	t.recheck(actx, &wg)
+	t.mu.Unlock()
+
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		t.draw()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		case in := <-input:
+			for _, key := range tuiKeys(in) {
+				if key == "quit" {
+					cancel()
+					return nil
+				}
+				t.handle(actx, &wg, key)
+			}
+		}
+	}
+}
+
+// tuiInput drops input while full so that the stdin forwarder never blocks.
+type tuiInput chan []byte
+
+func (c tuiInput) Write(p []byte) (int, error) {
+	select {
+	case c <- bytes.Clone(p):
+	default:
+	}
+	return len(p), nil
+}
+
+func tuiKeys(in []byte) []string {
+	var keys []string
+	for len(in) > 0 {
+		switch {
+		case bytes.HasPrefix(in, []byte("\x1b[A")), bytes.HasPrefix(in, []byte("\x1bOA")):
+			keys, in = append(keys, "up"), in[3:]
+			continue
+		case bytes.HasPrefix(in, []byte("\x1b[B")), bytes.HasPrefix(in, []byte("\x1bOB")):
+			keys, in = append(keys, "down"), in[3:]
+			continue
+		}
+		switch c := in[0]; c {
+		case 'k':
+			keys = append(keys, "up")
+		case 'j':
+			keys = append(keys, "down")
+		case ' ':
+			keys = append(keys, "space")
+		case '\r', '\n':
+			keys = append(keys, "enter")
+		case 'q', 3, 4: // ^C, ^D
+			keys = append(keys, "quit")
+		default:
+			keys = append(keys, string(c))
+		}
+		in = in[1:]
+	}
+	return keys
+}
+
+// tuiRow fields but set and log are guarded by tuiModel.mu.
+type tuiRow struct {
+	set namedCommandSet
+	log *tuiLog
+	// entry is nil while checking.
+	entry    *checkEntry
+	selected bool
+	action   string
+}
+
+func (r *tuiRow) status() (string, string) {
+	switch {
+	case r.action == "failed":
+		return r.action, colorRed
+	case r.action != "":
+		return r.action + "...", colorYellow
+	case r.entry == nil:
+		return "checking...", ""
+	}
+	switch r.entry.Problem {
+	case "":
+		return "up to date", colorGreen
+	case "checklatest failed":
+		return r.entry.Problem, colorRed
+	}
+	return r.entry.Problem, colorYellow
+}
+
+type tuiModel struct {
+	m      *Manager
+	pinned map[string]string
+	color  bool
+
+	mu      sync.Mutex
+	rows    []*tuiRow
+	cursor  int
+	offset  int
+	busy    bool
+	message string
+}
+
+func (t *tuiModel) runner(row *tuiRow, cmd command) (*runner, error) {
+	r, err := t.m.newRunner(cmd, []namedCommandSet{row.set}, t.pinned, outputText)
+	if err != nil {
+		return nil, err
+	}
+	r.opts.Yes, r.opts.NonInteractive, r.opts.Verbose, r.opts.Parallel = true, true, true, 1
+	r.defaults.NonInteractive = true
+	r.logw, r.stderr = row.log, row.log
+	r.log = r.logger(row.log)
+	return r, nil
+}
+
+func (t *tuiModel) check(ctx context.Context, rows []*tuiRow) {
+	sem := make(chan struct{}, cmp.Or(t.m.opts.Parallel, 5))
+	var wg sync.WaitGroup
+	for _, row := range rows {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			entry := &checkEntry{Name: row.set.Name, Problem: "checklatest failed"}
+			r, err := t.runner(row, commandChecklatest)
+			if err == nil {
+				var entries []*checkEntry
+				entries, err = r.checkAll(ctx)
+				if err == nil {
+					entry = entries[0]
+				}
+			}
+			if err != nil {
+				entry.Error = err.Error()
+			}
+			if entry.Error != "" {
+				fmt.Fprintf(row.log, "checking failed: %s\n", entry.Error)
+			}
+			t.mu.Lock()
+			row.entry = entry
+			t.mu.Unlock()
+		}()
+	}
+	wg.Wait()
+}
+
+func (t *tuiModel) handle(ctx context.Context, wg *sync.WaitGroup, key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.message = ""
+	switch key {
+	case "up":
+		t.cursor = max(t.cursor-1, 0)
+	case "down":
+		t.cursor = min(t.cursor+1, len(t.rows)-1)
+	case "space":
+		t.rows[t.cursor].selected = !t.rows[t.cursor].selected
+	case "a":
+		for _, row := range t.rows {
+			row.selected = row.entry != nil && (row.entry.Problem == "outdated" || row.entry.Problem == "not installed")
+		}
+	case "enter":
+		if t.busy {
+			t.message = "wait for running commands to finish"
+			return
+		}
+		var rows []*tuiRow
+		for _, row := range t.rows {
+			if !row.selected {
+				continue
+			}
+			if row.entry == nil {
+				t.message = "wait for checks to finish"
+				return
+			}
+			rows = append(rows, row)
+		}
+		if len(rows) == 0 {
+			rows = append(rows, t.rows[t.cursor])
+			if rows[0].entry == nil {
+				t.message = "wait for checks to finish"
+				return
+			}
+		}
+		for _, row := range rows {
+			row.selected, row.action = false, "queued"
+		}
+		t.busy = true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			t.run(ctx, rows)
+		}()
+	case "r":
+		if t.busy {
+			t.message = "wait for running commands to finish"
+			return
+		}
+		t.recheck(ctx, wg)
+	}
+}
+
+// recheck needs t.mu held.
+func (t *tuiModel) recheck(ctx context.Context, wg *sync.WaitGroup) {
+	for _, row := range t.rows {
+		row.entry, row.action = nil, ""
+	}
+	t.busy = true
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		t.check(ctx, t.rows)
+		t.mu.Lock()
+		t.busy = false
+		t.mu.Unlock()
+	}()
+}
+
+func (t *tuiModel) run(ctx context.Context, rows []*tuiRow) {
+	var failed int
+	for _, row := range rows {
+		t.mu.Lock()
+		cmd, action := commandUpdate, "updating"
+		if row.entry.Current == "" {
+			cmd, action = commandInstall, "installing"
+		}
+		if ctx.Err() != nil {
+			row.action = ""
+			t.mu.Unlock()
+			continue
+		}
+		row.action = action
+		t.mu.Unlock()
+
+		r, err := t.runner(row, cmd)
+		if err == nil {
+			err = r.Run(ctx)
+		}
+		if err != nil {
+			failed++
+			fmt.Fprintf(row.log, "%s: %v\n", cmd, err)
+		}
+		t.mu.Lock()
+		row.entry, row.action = nil, ""
+		if err != nil {
+			row.action = "failed"
+		}
+		t.mu.Unlock()
+	}
+	t.check(ctx, rows)
+	t.mu.Lock()
+	for _, row := range rows {
+		if row.action == "failed" {
+			// keeps failures visible over the result of the check.
+			continue
+		}
+		row.action = ""
+	}
+	t.busy = false
+	t.message = fmt.Sprintf("done: %d succeeded, %d failed", len(rows)-failed, failed)
+	t.mu.Unlock()
+}
+
+func (t *tuiModel) draw() {
+	height, width := 24, 80
+	var ws [4]uint16 // rows, columns, x and y pixels
+	if ioctl(os.Stdout, ioctlGetWinsize, unsafe.Pointer(&ws)) == nil && ws[0] > 0 && ws[1] > 0 {
+		height, width = int(ws[0]), int(ws[1])
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tableHeight := min(len(t.rows), max((height-4)/2, 1))
+	if t.cursor < t.offset {
+		t.offset = t.cursor
+	} else if t.cursor >= t.offset+tableHeight {
+		t.offset = t.cursor - tableHeight + 1
+	}
+
+	var table bytes.Buffer
+	tw := tabwriter.NewWriter(&table, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "      NAME\tCURRENT\tTARGET\t")
+	for _, row := range t.rows[t.offset : t.offset+tableHeight] {
+		cursor, check := " ", " "
+		if row == t.rows[t.cursor] {
+			cursor = ">"
+		}
+		if row.selected {
+			check = "x"
+		}
+		var current, target, pinned string
+		if row.entry != nil {
+			current, target = cmp.Or(row.entry.Current, "-"), cmp.Or(row.entry.Target, "-")
+			if row.entry.Pinned {
+				pinned = " (pinned)"
+			}
+		}
+		fmt.Fprintf(tw, "%s [%s] %s\t%s\t%s%s\t\n", cursor, check, row.set.Name, current, target, pinned)
+	}
+	_ = tw.Flush()
+
+	var lines []string
+	lines = append(lines, truncateLine("ngpkgmgr tui  "+tuiHelp, width))
+	tableLines := strings.Split(strings.TrimSuffix(table.String(), "\n"), "\n")
+	for i, line := range tableLines {
+		if i == 0 {
+			lines = append(lines, truncateLine(line+"STATUS", width))
+			continue
+		}
+		status, color := t.rows[t.offset+i-1].status()
+		if t.color && utf8.RuneCountInString(line+status) <= width {
+			lines = append(lines, line+paint(color, status))
+		} else {
+			lines = append(lines, truncateLine(line+status, width))
+		}
+	}
+
+	row := t.rows[t.cursor]
+	title := "── " + row.set.Name + " "
+	lines = append(lines, title+strings.Repeat("─", max(width-utf8.RuneCountInString(title), 0)))
+	logHeight := max(height-len(lines)-1, 0)
+	for _, line := range row.log.tail(logHeight) {
+		lines = append(lines, truncateLine(line, width))
+	}
+	for len(lines) < height-1 {
+		lines = append(lines, "")
+	}
+	lines = append(lines, truncateLine(t.message, width))
+
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	for i, line := range lines {
+		b.WriteString(line)
+		b.WriteString("\x1b[K") // clears the rest of the previous frame.
+		if i < len(lines)-1 {
+			b.WriteString("\r\n")
+		}
+	}
+	_, _ = os.Stdout.WriteString(b.String())
+}
+
+func truncateLine(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	return string([]rune(s)[:max(width, 0)])
+}
+
+var escapeSeqRe = regexp.MustCompile(`\x1b(?:\[[0-9;?]*[ -/]*[@-~]|\][^\x07\x1b]*(?:\x07|\x1b\\)|.)`)
+
+// tuiLog keeps the last tuiMaxLogLines lines. A carriage return starts the line over, as progress bars expect.
+type tuiLog struct {
+	mu      sync.Mutex
+	lines   []string
+	partial string
+}
+
+func (l *tuiLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s := l.partial + strings.ReplaceAll(escapeSeqRe.ReplaceAllString(string(p), ""), "\t", "    ")
+	lines := strings.Split(s, "\n")
+	l.partial = lines[len(lines)-1]
+	for _, line := range lines[:len(lines)-1] {
+		l.lines = append(l.lines, overwriteLine(line))
+	}
+	if over := len(l.lines) - tuiMaxLogLines; over > 0 {
+		l.lines = append(l.lines[:0], l.lines[over:]...)
+	}
+	return len(p), nil
+}
+
+func (l *tuiLog) tail(n int) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lines := l.lines
+	if partial := overwriteLine(l.partial); partial != "" {
+		lines = append(lines[:len(lines):len(lines)], partial)
+	}
+	return lines[max(len(lines)-n, 0):]
+}
+
+// overwriteLine returns what line shows on a terminal: text after its last carriage return, except a trailing one.
+func overwriteLine(line string) string {
+	line = strings.TrimSuffix(line, "\r")
+	if i := strings.LastIndexByte(line, '\r'); i >= 0 {
+		return line[i+1:]
+	}
+	return line
+}
@@ -0,0 +1,170 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var placeholderRe = regexp.MustCompile(`\$\{[^}]*\}`)
+
+func (m *Manager) validate(_ context.Context, args []string) error {
+	if len(args) != 0 {
+		return configError(fmt.Errorf("validate: wrong args length: want 0, got %d", len(args)))
+	}
+	return checkConfig("validate", m.cfgDir, m.setDirs(), nil)
+}
+
+// checkConfig reports problems of cfgDir and, if extra is non-nil, by extra for each set read from dirs.
+func checkConfig(name, cfgDir string, dirs []string, extra func(set namedCommandSet) []string) error {
+
+	var problems []string
+	report := func(format string, a ...any) {
+		problems = append(problems, fmt.Sprintf(format, a...))
+	}
+
+	sets, errs, err := readLayeredCommandSets(dirs)
+	if err != nil {
+		return err
+	}
+	for _, err := range errs {
+		report("%v", err)
+	}
+	if _, err := topologicalSort(sets); err != nil {
+		report("%v", err)
+	}
+	for _, set := range sets {
+		for _, p := range validateCommandSet(set) {
+			report("%q: %s", set.Name, p)
+		}
+		if extra != nil {
+			for _, p := range extra(set) {
+				report("%q: %s", set.Name, p)
+			}
+		}
+	}
+
+	if cfg, err := loadGlobalConfig(cfgDir); err != nil {
+		report("%v", err)
+	} else {
+		for _, p := range validateProfiles(cfg.Profiles, sets) {
+			report("%s", p)
+		}
+	}
+
+	// .pin.json is decoded by hand since loadPinnedVersions stops at the first problem.
+	pinnedVersions := map[string]string{}
+	data, err := readAsJSON(filepath.Join(cfgDir, pinnedVersionsFileName))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		if err := json.Unmarshal(data, &pinnedVersions); err != nil {
+			report("%s: %v", pinnedVersionsFileName, err)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(pinnedVersions)) {
+		ver := pinnedVersions[name]
+		if err := validatePin(name, ver); err != nil {
+			report("%s: %v", pinnedVersionsFileName, err)
+		}
+		if !slices.ContainsFunc(sets, func(s namedCommandSet) bool { return s.Name == name }) {
+			report("%s: %q is not a known command set", pinnedVersionsFileName, name)
+		}
+	}
+
+	disabled, err := loadDisabled(cfgDir)
+	if err != nil {
+		report("%v", err)
+	}
+	for _, name := range disabled {
+		if !slices.ContainsFunc(sets, func(s namedCommandSet) bool { return s.Name == name }) {
+			report("%s: %q is not a known command set", disabledFileName, name)
+		}
+	}
+
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	if len(problems) > 0 {
+		return configError(fmt.Errorf("%s: %d problem(s) found", name, len(problems)))
+	}
+	fmt.Println("ok")
+	return nil
+}
+
+func validateCommandSet(set namedCommandSet) []string {
+	var problems []string
+
+	defined := definedCommands(set)
+	if len(defined) == 0 {
+		problems = append(problems, "neither commands are defined nor runnable scripts found")
+	} else {
+		for _, c := range cmds {
+			if !slices.Contains(defined, c) && !slices.Contains(optionalCmds, c) {
+				problems = append(problems, fmt.Sprintf("%s: neither defined nor found as a script", c))
+			}
+		}
+	}
+
+	known := set.Set.placeholders()
+	check := func(where, s string) {
+		if strings.Count(s, "${") != len(placeholderRe.FindAllString(s, -1)) {
+			problems = append(problems, fmt.Sprintf("%s: malformed placeholder in %q", where, s))
+		}
+		for _, p := range placeholderRe.FindAllString(s, -1) {
+			if !slices.Contains(known, p) {
+				problems = append(problems, fmt.Sprintf("%s: unknown placeholder %s", where, p))
+			}
+		}
+	}
+	for _, c := range cmds {
+		for _, arg := range set.Set.selectBase(c) {
+			check(string(c), arg)
+		}
+		for _, p := range slices.Sorted(maps.Keys(set.Set.Platforms)) {
+			for _, arg := range set.Set.Platforms[p].selectBase(c) {
+				check(fmt.Sprintf("platforms.%s.%s", p, c), arg)
+			}
+		}
+	}
+	for _, k := range set.Set.userCommands() {
+		for _, arg := range set.Set.Commands[k] {
+			check("commands."+k, arg)
+		}
+		for _, p := range slices.Sorted(maps.Keys(set.Set.Platforms)) {
+			for _, arg := range set.Set.Platforms[p].Commands[k] {
+				check(fmt.Sprintf("platforms.%s.commands.%s", p, k), arg)
+			}
+		}
+	}
+	for _, k := range slices.Sorted(maps.Keys(set.Set.Vars)) {
+		check("vars."+k, set.Set.Vars[k])
+	}
+	check("workdir", set.Set.Workdir)
+	for _, k := range slices.Sorted(maps.Keys(set.Set.Env)) {
+		check("env."+k, set.Set.Env[k])
+	}
+	for _, ext := range slices.Sorted(maps.Keys(set.Set.Interpreters)) {
+		if !strings.HasPrefix(ext, ".") || len(set.Set.Interpreters[ext]) == 0 {
+			problems = append(problems, fmt.Sprintf("interpreters.%s: must map an extension like \".sh\" to a non-empty command", ext))
+		}
+	}
+	for _, timing := range []hookTiming{hookPre, hookPost} {
+		for _, c := range []command{commandInstall, commandUpdate} {
+			for _, arg := range set.Set.Hooks.Select(timing, c) {
+				check(fmt.Sprintf("hooks.%s_%s", timing, c), arg)
+			}
+		}
+	}
+
+	return problems
+}
@@ -0,0 +1,195 @@
+package manager
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// semver treats missing minor and patch as 0 so that versions like "1.24" can be compared.
+type semver struct {
+	major, minor, patch uint64
+	pre                 []string
+}
+
+// parseSemver ignores a leading "v" and build metadata.
+func parseSemver(s string) (semver, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	s, _, _ = strings.Cut(s, "+")
+	s, pre, hasPre := strings.Cut(s, "-")
+
+	comps := strings.Split(s, ".")
+	if len(comps) > 3 {
+		return semver{}, false
+	}
+	var nums [3]uint64
+	for i, c := range comps {
+		if c == "" || (len(c) > 1 && c[0] == '0') {
+			return semver{}, false
+		}
+		n, err := strconv.ParseUint(c, 10, 64)
+		if err != nil {
+			return semver{}, false
+		}
+		nums[i] = n
+	}
+
+	v := semver{major: nums[0], minor: nums[1], patch: nums[2]}
+	if hasPre {
+		if pre == "" {
+			return semver{}, false
+		}
+		v.pre = strings.Split(pre, ".")
+		for _, id := range v.pre {
+			if id == "" {
+				return semver{}, false
+			}
+		}
+	}
+	return v, true
+}
+
+func (v semver) Compare(u semver) int {
+	if c := cmp.Compare(v.major, u.major); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.minor, u.minor); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.patch, u.patch); c != 0 {
+		return c
+	}
+	// a version without pre-release has higher precedence.
+	switch {
+	case len(v.pre) == 0 && len(u.pre) == 0:
+		return 0
+	case len(v.pre) == 0:
+		return +1
+	case len(u.pre) == 0:
+		return -1
+	}
+	for i := range min(len(v.pre), len(u.pre)) {
+		if c := comparePrerelease(v.pre[i], u.pre[i]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(v.pre), len(u.pre))
+}
+
+func comparePrerelease(a, b string) int {
+	an, aErr := strconv.ParseUint(a, 10, 64)
+	bn, bErr := strconv.ParseUint(b, 10, 64)
+	switch {
+	case aErr == nil && bErr == nil:
+		return cmp.Compare(an, bn)
+	case aErr == nil:
+		// numeric identifiers have lower precedence.
+		return -1
+	case bErr == nil:
+		return +1
+	default:
+		return cmp.Compare(a, b)
+	}
+}
+
+type versioning string
+
+const (
+	// versioningSemver is the default.
+	versioningSemver versioning = "semver"
+	// versioningCalver compares calendar versions, e.g. "2024.01.15", numerically component by component.
+	versioningCalver versioning = "calver"
+	versioningExact  versioning = "exact"
+)
+
+func (v versioning) Validate() error {
+	switch v {
+	case "", versioningSemver, versioningCalver, versioningExact:
+		return nil
+	}
+	return fmt.Errorf("versioning: unknown %q", v)
+}
+
+// compare reports ok false if either does not parse under v, when they are only comparable as exact strings.
+func (v versioning) compare(a, b string) (c int, ok bool) {
+	switch v {
+	case "", versioningSemver:
+		va, ok1 := parseSemver(a)
+		vb, ok2 := parseSemver(b)
+		if !ok1 || !ok2 {
+			return 0, false
+		}
+		return va.Compare(vb), true
+	case versioningCalver:
+		va, ok1 := parseCalver(a)
+		vb, ok2 := parseCalver(b)
+		if !ok1 || !ok2 {
+			return 0, false
+		}
+		return compareCalver(va, vb), true
+	}
+	return 0, false
+}
+
+// parseCalver ignores a leading "v" and zero padding, e.g. "2024.01" and "2024.1" are the same.
+func parseCalver(s string) ([]uint64, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	comps := strings.FieldsFunc(s, func(r rune) bool { return r == '.' || r == '-' || r == '_' })
+	if len(comps) == 0 {
+		return nil, false
+	}
+	nums := make([]uint64, len(comps))
+	for i, c := range comps {
+		n, err := strconv.ParseUint(c, 10, 64)
+		if err != nil {
+			return nil, false
+		}
+		nums[i] = n
+	}
+	return nums, true
+}
+
+func compareCalver(a, b []uint64) int {
+	for i := range max(len(a), len(b)) {
+		var x, y uint64
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if c := cmp.Compare(x, y); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// needsUpdate reports whether target is newer than current, or just different under allowDowngrade or if either does not parse.
+func needsUpdate(v versioning, current, target string, allowDowngrade bool) bool {
+	if current == target {
+		return false
+	}
+	c, ok := v.compare(target, current)
+	if !ok {
+		return true
+	}
+	if allowDowngrade {
+		return c != 0
+	}
+	return c > 0
+}
+
+func isDowngrade(v versioning, current, target string) bool {
+	c, ok := v.compare(target, current)
+	return ok && c < 0
+}
+
+func sameVersion(v versioning, a, b string) bool {
+	if a == b {
+		return true
+	}
+	c, ok := v.compare(a, b)
+	return ok && c == 0
+}
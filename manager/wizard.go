@@ -0,0 +1,269 @@
+package manager
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// "args" of wizardBackends defines commands as shell lines.
+var wizardBackends = []string{"github", "goinstall", "cargo", "npm", "pipx", "brew", "apt", "scoop", "winget", "args"}
+
+// newSet asks for the name, the backend and what it needs, writes <name>.json, then optionally tests ver and checklatest.
+//
+//	new
+func (m *Manager) newSet(ctx context.Context, args []string) error {
+	if len(args) != 0 {
+		return configError(fmt.Errorf("new: wrong args length: want 0, got %d: use -new <name> to create a set without questions", len(args)))
+	}
+	if !isTerminal(os.Stdin) {
+		return configError(fmt.Errorf("new: stdin is not a terminal: use -new <name> instead"))
+	}
+	if m.opts.NonInteractive {
+		return configError(fmt.Errorf("new: asks questions but -non-interactive is set: use -new <name> instead"))
+	}
+	p := &prompter{sc: bufio.NewScanner(os.Stdin), w: os.Stdout}
+
+	name, err := p.ask("name", "", func(s string) error {
+		if err := validateSetName(s); err != nil {
+			return err
+		}
+		if ok, err := commandSetExists(m.cfgDir, s); err != nil || ok {
+			return fmt.Errorf("%q already exists", s)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	kind, err := p.choose("backend", wizardBackends)
+	if err != nil {
+		return err
+	}
+	set, err := p.askSet(name, kind)
+	if err != nil {
+		return err
+	}
+	if err := set.Validate(); err != nil {
+		return configError(fmt.Errorf("new: %w", err))
+	}
+
+	data, err := json.MarshalIndent(set, "", "    ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(m.cfgDir, name+".json")
+	fmt.Fprintf(p.w, "\n%s:\n%s\n", path, data)
+	if ok, err := p.confirm("write it?", true); err != nil || !ok {
+		if err == nil {
+			err = errAborted
+		}
+		return err
+	}
+	if err := os.MkdirAll(m.cfgDir, 0o755); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(p.w, "wrote %s\n", path)
+
+	if ok, err := p.confirm("run ver and checklatest now?", true); err != nil || !ok {
+		return err
+	}
+	return m.CheckLatest(ctx, name)
+}
+
+func (p *prompter) askSet(name, kind string) (commandSet, error) {
+	var set commandSet
+	switch kind {
+	case "github":
+		repo, err := p.ask("repository (owner/name)", "", func(s string) error {
+			if owner, repo, ok := strings.Cut(s, "/"); !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+				return fmt.Errorf("must be \"owner/name\"")
+			}
+			return nil
+		})
+		if err != nil {
+			return set, err
+		}
+		asset, err := p.ask("release asset", name+"_${VER}_${OS}_${ARCH}.tar.gz", nil)
+		if err != nil {
+			return set, err
+		}
+		ver, err := p.ask("command printing the installed version", name+" --version", nil)
+		if err != nil {
+			return set, err
+		}
+		set.GitHub = &githubBackend{Repo: repo, Asset: asset}
+		set.Ver = strings.Fields(ver)
+		set.Extract = map[command]extractor{commandVer: {Regex: `\d+\.\d+\.\d+`}}
+	case "goinstall":
+		pkg, err := p.ask("package path", "", nil)
+		if err != nil {
+			return set, err
+		}
+		if !strings.Contains(pkg, "@") {
+			pkg += "@${VER}"
+		}
+		g := goInstallBackend(pkg)
+		set.GoInstall = &g
+	case "cargo", "npm", "pipx", "brew", "apt", "scoop", "winget":
+		pkg, err := p.ask("package name", name, nil)
+		if err != nil {
+			return set, err
+		}
+		switch kind {
+		case "cargo":
+			b := cargoBackend(pkg)
+			set.Cargo = &b
+		case "npm":
+			b := npmBackend(pkg)
+			set.Npm = &b
+		case "pipx":
+			b := pipxBackend(pkg)
+			set.Pipx = &b
+		case "brew":
+			b := brewBackend(pkg)
+			set.Brew = &b
+		case "apt":
+			b := aptBackend(pkg)
+			set.Apt = &b
+		case "scoop":
+			b := scoopBackend(pkg)
+			set.Scoop = &b
+		case "winget":
+			b := wingetBackend(pkg)
+			set.Winget = &b
+		}
+	case "args":
+		shell := []string{"sh", "-c"}
+		if runtime.GOOS == "windows" {
+			shell = []string{"powershell", "-NoProfile", "-Command"}
+		}
+		fmt.Fprintf(p.w, "commands are run by %q, with ${VER}, ${OS}, ${ARCH} and more expanded\n", strings.Join(shell, " "))
+		var install string
+		for _, q := range []struct {
+			c   command
+			def *string
+		}{
+			{commandVer, nil},
+			{commandChecklatest, nil},
+			{commandInstall, nil},
+			{commandUpdate, &install},
+			{commandUninstall, nil},
+		} {
+			def := ""
+			if q.def != nil {
+				def = *q.def
+			}
+			line, err := p.ask(string(q.c), def, nil)
+			if err != nil {
+				return set, err
+			}
+			if q.c == commandInstall {
+				install = line
+			}
+			if line == "" {
+				continue
+			}
+			args := append(append([]string(nil), shell...), line)
+			switch q.c {
+			case commandVer:
+				set.Ver = args
+			case commandChecklatest:
+				set.CheckLatest = args
+			case commandInstall:
+				set.Install = args
+			case commandUpdate:
+				set.Update = args
+			case commandUninstall:
+				set.Uninstall = args
+			}
+		}
+	}
+	return set, nil
+}
+
+type prompter struct {
+	sc *bufio.Scanner
+	w  io.Writer
+}
+
+// ask returns def for an empty answer. check may be nil.
+func (p *prompter) ask(question, def string, check func(string) error) (string, error) {
+	for {
+		if def != "" {
+			fmt.Fprintf(p.w, "%s [%s]: ", question, def)
+		} else {
+			fmt.Fprintf(p.w, "%s: ", question)
+		}
+		if !p.sc.Scan() {
+			if err := p.sc.Err(); err != nil {
+				return "", err
+			}
+			return "", errAborted
+		}
+		answer := strings.TrimSpace(p.sc.Text())
+		if answer == "" {
+			answer = def
+		}
+		if check != nil {
+			if err := check(answer); err != nil {
+				fmt.Fprintf(p.w, "%v\n", err)
+				continue
+			}
+		}
+		return answer, nil
+	}
+}
+
+func (p *prompter) choose(question string, options []string) (string, error) {
+	for i, o := range options {
+		fmt.Fprintf(p.w, "  %d) %s\n", i+1, o)
+	}
+	var picked string
+	_, err := p.ask(question, "", func(s string) error {
+		if i, err := strconv.Atoi(s); err == nil && i >= 1 && i <= len(options) {
+			picked = options[i-1]
+			return nil
+		}
+		for _, o := range options {
+			if o == s {
+				picked = o
+				return nil
+			}
+		}
+		return fmt.Errorf("must be a number from 1 to %d or one of the names", len(options))
+	})
+	return picked, err
+}
+
+func (p *prompter) confirm(question string, def bool) (bool, error) {
+	hint := "Y/n"
+	if !def {
+		hint = "y/N"
+	}
+	var yes bool
+	_, err := p.ask(question+" ["+hint+"]", "", func(s string) error {
+		switch strings.ToLower(s) {
+		case "":
+			yes = def
+		case "y", "yes":
+			yes = true
+		case "n", "no":
+			yes = false
+		default:
+			return fmt.Errorf("answer y or n")
+		}
+		return nil
+	})
+	return yes, err
+}
@@ -0,0 +1,608 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// parseYAML parses the subset of YAML 1.2 command sets need:
+// block mappings and sequences, flow sequences and mappings, plain, single- and double-quoted scalars,
+// literal (|) and folded (>) block scalars and comments.
+// Anything else, e.g. anchors, aliases, tags, merge and complex keys, directives, multiple documents,
+// multi-line plain and quoted scalars, and numbers other than decimal ones, is rejected with an error.
+func parseYAML(data []byte) (any, error) {
+	p := &yamlParser{}
+	// the newline ending the last line starts no line of its own.
+	text := strings.TrimSuffix(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for i, raw := range strings.Split(text, "\n") {
+		if strings.HasPrefix(raw, "%") {
+			return nil, fmt.Errorf("line %d: directives are not supported", i+1)
+		}
+		if strings.HasPrefix(raw, "---") || strings.HasPrefix(raw, "...") {
+			if strings.TrimSpace(raw[3:]) != "" && !strings.HasPrefix(strings.TrimSpace(raw[3:]), "#") {
+				return nil, fmt.Errorf("line %d: content after document marker is not supported", i+1)
+			}
+			if strings.HasPrefix(raw, "---") && p.next(0) < len(p.lines) {
+				return nil, fmt.Errorf("line %d: multiple documents are not supported", i+1)
+			}
+			p.lines = append(p.lines, yamlLine{no: i + 1})
+			continue
+		}
+		content := strings.TrimLeft(raw, " ")
+		if strings.HasPrefix(content, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", i+1)
+		}
+		text := stripYAMLComment(content)
+		if text == "?" || strings.HasPrefix(text, "? ") {
+			return nil, fmt.Errorf("line %d: complex keys are not supported", i+1)
+		}
+		p.lines = append(p.lines, yamlLine{no: i + 1, raw: raw, indent: len(raw) - len(content), text: text})
+	}
+	i := p.next(0)
+	if i == len(p.lines) {
+		return map[string]any{}, nil
+	}
+	v, i, err := p.node(i, p.lines[i].indent)
+	if err != nil {
+		return nil, err
+	}
+	if i = p.next(i); i < len(p.lines) {
+		return nil, fmt.Errorf("line %d: unexpected content", p.lines[i].no)
+	}
+	return v, nil
+}
+
+type yamlLine struct {
+	no     int
+	raw    string
+	indent int
+	// text is the line without indentation and comments.
+	text string
+}
+
+type yamlParser struct {
+	lines []yamlLine
+}
+
+func (p *yamlParser) next(i int) int {
+	for i < len(p.lines) && strings.TrimSpace(p.lines[i].text) == "" {
+		i++
+	}
+	return i
+}
+
+func (p *yamlParser) node(i, indent int) (any, int, error) {
+	l := p.lines[i]
+	switch {
+	case l.text == "-" || strings.HasPrefix(l.text, "- "):
+		return p.sequence(i, indent)
+	case yamlKey(l.text) >= 0:
+		return p.mapping(i, indent)
+	}
+	return p.inline(i, l.text)
+}
+
+func (p *yamlParser) sequence(i, indent int) (any, int, error) {
+	seq := []any{}
+	for i = p.next(i); i < len(p.lines) && p.lines[i].indent == indent; i = p.next(i) {
+		l := p.lines[i]
+		if l.text != "-" && !strings.HasPrefix(l.text, "- ") {
+			break
+		}
+		rest := strings.TrimLeft(strings.TrimPrefix(l.text, "-"), " ")
+		var (
+			v   any
+			err error
+		)
+		if rest == "" {
+			j := p.next(i + 1)
+			if j < len(p.lines) && p.lines[j].indent > indent {
+				v, i, err = p.node(j, p.lines[j].indent)
+			} else {
+				i++
+			}
+		} else if rest[0] == '|' || rest[0] == '>' {
+			v, i, err = p.blockScalar(i, indent, rest)
+		} else {
+			// "- key: value" starts a mapping indented at the column of key.
+			col := indent + len(l.text) - len(rest)
+			p.lines[i] = yamlLine{no: l.no, raw: l.raw, indent: col, text: rest}
+			v, i, err = p.node(i, col)
+			if err == nil && yamlKey(rest) < 0 {
+				err = p.continued(i, indent)
+			}
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		seq = append(seq, v)
+	}
+	if i < len(p.lines) && p.lines[i].indent > indent {
+		return nil, 0, fmt.Errorf("line %d: bad indentation", p.lines[i].no)
+	}
+	return seq, i, nil
+}
+
+func (p *yamlParser) mapping(i, indent int) (any, int, error) {
+	m := map[string]any{}
+	for i = p.next(i); i < len(p.lines) && p.lines[i].indent == indent; i = p.next(i) {
+		l := p.lines[i]
+		colon := yamlKey(l.text)
+		if colon < 0 {
+			return nil, 0, fmt.Errorf("line %d: expected \"key: value\"", l.no)
+		}
+		key, err := yamlScalarString(strings.TrimSpace(l.text[:colon]))
+		if err != nil {
+			return nil, 0, fmt.Errorf("line %d: %w", l.no, err)
+		}
+		if _, ok := m[key]; ok {
+			return nil, 0, fmt.Errorf("line %d: duplicate key %q", l.no, key)
+		}
+		if strings.TrimSpace(l.text[:colon]) == "<<" {
+			return nil, 0, fmt.Errorf("line %d: merge keys are not supported", l.no)
+		}
+		rest := strings.TrimSpace(l.text[colon+1:])
+
+		var v any
+		switch {
+		case rest == "":
+			j := p.next(i + 1)
+			// A sequence may be indented as deep as its key.
+			if j < len(p.lines) && (p.lines[j].indent > indent ||
+				p.lines[j].indent == indent && (p.lines[j].text == "-" || strings.HasPrefix(p.lines[j].text, "- "))) {
+				v, i, err = p.node(j, p.lines[j].indent)
+			} else {
+				i++
+			}
+		case rest[0] == '|' || rest[0] == '>':
+			v, i, err = p.blockScalar(i, indent, rest)
+		default:
+			l.text = rest
+			p.lines[i] = l
+			v, i, err = p.inline(i, rest)
+			if err == nil {
+				err = p.continued(i, indent)
+			}
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		m[key] = v
+	}
+	if i < len(p.lines) && p.lines[i].indent > indent {
+		return nil, 0, fmt.Errorf("line %d: bad indentation", p.lines[i].no)
+	}
+	return m, i, nil
+}
+
+// continued errors if line i is indented deeper than indent, continuing a multi-line scalar.
+func (p *yamlParser) continued(i, indent int) error {
+	if i = p.next(i); i < len(p.lines) && p.lines[i].indent > indent {
+		return fmt.Errorf("line %d: multi-line plain scalars are not supported; use a block scalar (| or >)", p.lines[i].no)
+	}
+	return nil
+}
+
+// yamlKey returns the index of the colon separating a key at the start of s, or -1.
+func yamlKey(s string) int {
+	if s == "" || s[0] == '[' || s[0] == '{' {
+		return -1
+	}
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case i == 0 && (c == '"' || c == '\''):
+			quote = c
+		case c == ':' && (i+1 == len(s) || s[i+1] == ' '):
+			return i
+		}
+	}
+	return -1
+}
+
+// blockScalar parses a literal or folded block scalar under the line i whose header is header, e.g. "|-".
+func (p *yamlParser) blockScalar(i, indent int, header string) (any, int, error) {
+	folded := header[0] == '>'
+	chomp := strings.TrimSpace(header[1:])
+	if chomp != "" && chomp != "-" && chomp != "+" {
+		return nil, 0, fmt.Errorf("line %d: unsupported block scalar header %q", p.lines[i].no, header)
+	}
+	var lines []string
+	blockIndent := -1
+	i++
+	for ; i < len(p.lines); i++ {
+		raw := p.lines[i].raw
+		if strings.TrimSpace(raw) == "" {
+			lines = append(lines, "")
+			continue
+		}
+		ind := len(raw) - len(strings.TrimLeft(raw, " "))
+		if ind <= indent {
+			break
+		}
+		if blockIndent < 0 {
+			blockIndent = ind
+		}
+		if ind < blockIndent {
+			return nil, 0, fmt.Errorf("line %d: bad indentation of block scalar", p.lines[i].no)
+		}
+		lines = append(lines, raw[blockIndent:])
+	}
+	// trailing blank lines belong to the following node, except for chomping.
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+	i -= trailing
+
+	var s string
+	if folded {
+		// a line break between lines becomes a space, unless empty lines follow it, which become line breaks instead,
+		// or either line is more indented, where breaks are kept.
+		var b strings.Builder
+		empties, started, prevSpaced := 0, false, false
+		for _, l := range lines {
+			if l == "" {
+				empties++
+				continue
+			}
+			spaced := strings.HasPrefix(l, " ")
+			switch {
+			case !started:
+				b.WriteString(strings.Repeat("\n", empties))
+			case spaced || prevSpaced:
+				b.WriteString(strings.Repeat("\n", empties+1))
+			case empties > 0:
+				b.WriteString(strings.Repeat("\n", empties))
+			default:
+				b.WriteByte(' ')
+			}
+			b.WriteString(l)
+			empties, started, prevSpaced = 0, true, spaced
+		}
+		s = b.String()
+	} else {
+		s = strings.Join(lines, "\n")
+	}
+	switch {
+	case len(lines) == 0:
+	case chomp == "-":
+	case chomp == "+":
+		s += strings.Repeat("\n", trailing+1)
+	default:
+		s += "\n"
+	}
+	return s, i, nil
+}
+
+// inline parses s, a scalar or a flow collection at line i, which may continue onto following lines
+// while brackets are open.
+func (p *yamlParser) inline(i int, s string) (any, int, error) {
+	no := p.lines[i].no
+	i++
+	if s[0] == '[' || s[0] == '{' {
+		for !yamlBalanced(s) {
+			if i == len(p.lines) {
+				return nil, 0, fmt.Errorf("line %d: unterminated flow collection", no)
+			}
+			s += " " + strings.TrimSpace(p.lines[i].text)
+			i++
+		}
+	}
+	f := &yamlFlow{s: s}
+	v, err := f.value(false)
+	if err == nil {
+		f.skipSpaces()
+		if f.pos < len(f.s) {
+			err = fmt.Errorf("unexpected %q", f.s[f.pos:])
+		}
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("line %d: %w", no, err)
+	}
+	return v, i, nil
+}
+
+func yamlBalanced(s string) bool {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		}
+	}
+	return depth <= 0
+}
+
+// stripYAMLComment removes a comment, which starts with # at the beginning or after a space, outside quotes.
+func stripYAMLComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && (i == 0 || strings.ContainsRune(" [{,:", rune(s[i-1]))):
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return strings.TrimRight(s[:i], " \t")
+		}
+	}
+	return strings.TrimRight(s, " \t")
+}
+
+type yamlFlow struct {
+	s   string
+	pos int
+}
+
+func (f *yamlFlow) skipSpaces() {
+	for f.pos < len(f.s) && f.s[f.pos] == ' ' {
+		f.pos++
+	}
+}
+
+// value parses a value. In a flow collection, plain scalars end at ",", "]", "}" and ": ".
+func (f *yamlFlow) value(inFlow bool) (any, error) {
+	f.skipSpaces()
+	if f.pos == len(f.s) {
+		return nil, nil
+	}
+	switch f.s[f.pos] {
+	case '[':
+		f.pos++
+		seq := []any{}
+		for {
+			f.skipSpaces()
+			if f.pos < len(f.s) && f.s[f.pos] == ']' {
+				f.pos++
+				return seq, nil
+			}
+			v, err := f.value(true)
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, v)
+			if err := f.separator(']'); err != nil {
+				return nil, err
+			}
+		}
+	case '{':
+		f.pos++
+		m := map[string]any{}
+		for {
+			f.skipSpaces()
+			if f.pos < len(f.s) && f.s[f.pos] == '}' {
+				f.pos++
+				return m, nil
+			}
+			k, err := f.value(true)
+			if err != nil {
+				return nil, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				key = fmt.Sprint(k)
+			}
+			f.skipSpaces()
+			var v any
+			if f.pos < len(f.s) && f.s[f.pos] == ':' {
+				f.pos++
+				if v, err = f.value(true); err != nil {
+					return nil, err
+				}
+			}
+			m[key] = v
+			if err := f.separator('}'); err != nil {
+				return nil, err
+			}
+		}
+	case '"', '\'':
+		end := f.quotedEnd()
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated string; multi-line quoted scalars are not supported")
+		}
+		s, err := yamlScalarString(f.s[f.pos:end])
+		f.pos = end
+		return s, err
+	case '&', '*', '!':
+		return nil, fmt.Errorf("anchors, aliases and tags are not supported")
+	case '@', '`', '%':
+		return nil, fmt.Errorf("plain scalars can not start with %q; quote it", f.s[f.pos])
+	}
+
+	start := f.pos
+	for f.pos < len(f.s) {
+		c := f.s[f.pos]
+		if inFlow && (c == ',' || c == ']' || c == '}' || c == ':' && (f.pos+1 == len(f.s) || strings.ContainsRune(" ,]}", rune(f.s[f.pos+1])))) {
+			break
+		}
+		f.pos++
+	}
+	plain := strings.TrimSpace(f.s[start:f.pos])
+	switch {
+	case !inFlow && (strings.Contains(plain, ": ") || strings.HasSuffix(plain, ":")):
+		return nil, fmt.Errorf("unexpected \":\" in plain scalar %q; quote it", plain)
+	case yamlOtherNumberRe.MatchString(plain):
+		return nil, fmt.Errorf("unsupported number %q; quote it for a string", plain)
+	case inFlow && plain == "<<":
+		return nil, fmt.Errorf("merge keys are not supported")
+	}
+	return yamlPlain(plain), nil
+}
+
+func (f *yamlFlow) separator(closing byte) error {
+	f.skipSpaces()
+	switch {
+	case f.pos == len(f.s):
+		return fmt.Errorf("unterminated flow collection")
+	case f.s[f.pos] == ',':
+		f.pos++
+	case f.s[f.pos] != closing:
+		return fmt.Errorf("expected , or %c", closing)
+	}
+	return nil
+}
+
+func (f *yamlFlow) quotedEnd() int {
+	quote := f.s[f.pos]
+	for i := f.pos + 1; i < len(f.s); i++ {
+		switch {
+		case quote == '"' && f.s[i] == '\\':
+			i++
+		case quote == '\'' && f.s[i] == '\'' && i+1 < len(f.s) && f.s[i+1] == '\'':
+			i++
+		case f.s[i] == quote:
+			return i + 1
+		}
+	}
+	return -1
+}
+
+func yamlScalarString(s string) (string, error) {
+	switch {
+	case len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'':
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	case len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"':
+		// YAML escapes are a superset of JSON ones; common extras are translated first.
+		var v string
+		js := strings.NewReplacer(`\\`, `\\`, `\/`, `/`, `\0`, `\u0000`, `\a`, `\u0007`, `\e`, `\u001b`, `\ `, ` `, "\t", `\t`).Replace(s)
+		if err := json.Unmarshal([]byte(js), &v); err != nil {
+			return "", fmt.Errorf("invalid double-quoted scalar %s", s)
+		}
+		return v, nil
+	case s != "" && (s[0] == '"' || s[0] == '\''):
+		return "", fmt.Errorf("unterminated string %s", s)
+	}
+	return s, nil
+}
+
+var (
+	yamlIntRe         = regexp.MustCompile(`^[-+]?(0|[1-9][0-9]*)$`)
+	yamlFloatRe       = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+	yamlOtherNumberRe = regexp.MustCompile(`^(0x[0-9A-Fa-f]+|0o[0-7]+|[-+]?\.(inf|Inf|INF)|\.(nan|NaN|NAN))$`)
+)
+
+// yamlPlain resolves a plain scalar by the YAML 1.2 core schema.
+func yamlPlain(s string) any {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+	if yamlIntRe.MatchString(s) {
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i
+		}
+	}
+	if yamlFloatRe.MatchString(s) {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
+	return s
+}
+
+var yamlSafePlainRe = regexp.MustCompile(`^[A-Za-z0-9_./][A-Za-z0-9_./-]*$`)
+
+// writeYAML writes obj as a block mapping, with arrays of scalars in flow style, e.g. ver: [go, version].
+func writeYAML(w io.Writer, obj *orderedObject, indent int) error {
+	pad := strings.Repeat(" ", indent)
+	if len(obj.keys) == 0 {
+		_, err := fmt.Fprintf(w, "%s{}\n", pad)
+		return err
+	}
+	for i, k := range obj.keys {
+		key := yamlScalar(k)
+		switch v := obj.values[i].(type) {
+		case *orderedObject:
+			if len(v.keys) == 0 {
+				fmt.Fprintf(w, "%s%s: {}\n", pad, key)
+				continue
+			}
+			fmt.Fprintf(w, "%s%s:\n", pad, key)
+			if err := writeYAML(w, v, indent+2); err != nil {
+				return err
+			}
+		case []any:
+			if len(v) == 0 || !allObjects(v) {
+				fmt.Fprintf(w, "%s%s: %s\n", pad, key, yamlFlowValue(v))
+				continue
+			}
+			fmt.Fprintf(w, "%s%s:\n", pad, key)
+			for _, e := range v {
+				var b strings.Builder
+				if err := writeYAML(&b, e.(*orderedObject), indent+4); err != nil {
+					return err
+				}
+				// "- " replaces the indentation of the first key.
+				fmt.Fprintf(w, "%s  - %s", pad, b.String()[indent+4:])
+			}
+		default:
+			fmt.Fprintf(w, "%s%s: %s\n", pad, key, yamlFlowValue(v))
+		}
+	}
+	return nil
+}
+
+func yamlFlowValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return yamlScalar(v)
+	case []any:
+		parts := make([]string, len(v))
+		for i, e := range v {
+			parts[i] = yamlFlowValue(e)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case *orderedObject:
+		parts := make([]string, len(v.keys))
+		for i, k := range v.keys {
+			parts[i] = yamlScalar(k) + ": " + yamlFlowValue(v.values[i])
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// yamlScalar returns s plain if it cannot be mistaken for anything else, double-quoted otherwise.
+func yamlScalar(s string) string {
+	if yamlSafePlainRe.MatchString(s) && !yamlOtherNumberRe.MatchString(s) {
+		if _, ok := yamlPlain(s).(string); ok {
+			return s
+		}
+	}
+	return quoteJSON(s)
+}
@@ -0,0 +1,99 @@
+package manager
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	for _, tc := range []struct {
+		name, in, want string
+	}{
+		{"empty", "", `{}`},
+		{"comments and markers", "---\n# comment\na: 1 # trailing\nb: 'x # y'\n...\n", `{"a": 1, "b": "x # y"}`},
+		{"scalars", "a: 1\nb: -2.5\nc: true\nd: ~\ne: null\nf: plain text\ng: 1.0.0\nh: yes\n",
+			`{"a": 1, "b": -2.5, "c": true, "d": null, "e": null, "f": "plain text", "g": "1.0.0", "h": "yes"}`},
+		{"quoted", `a: "x\ty\"\u00e9"` + "\nb: 'it''s'\n", `{"a": "x\ty\"é", "b": "it's"}`},
+		{"flow", "a: [go, version, \"x, y\"]\nb: {c: d, e: [1, 2]}\n", `{"a": ["go", "version", "x, y"], "b": {"c": "d", "e": [1, 2]}}`},
+		{"multiline flow", "a: [\n  go,\n  version,\n]\n", `{"a": ["go", "version"]}`},
+		{"block sequence", "a:\n  - x\n  - y\nb:\n- z\n", `{"a": ["x", "y"], "b": ["z"]}`},
+		{"sequence of mappings", "h:\n  - name: a\n    run: [echo]\n  - name: b\n", `{"h": [{"name": "a", "run": ["echo"]}, {"name": "b"}]}`},
+		{"nested mappings", "env:\n  A: \"1\"\n  nested:\n    B: x\n", `{"env": {"A": "1", "nested": {"B": "x"}}}`},
+		{"literal", "a: |\n  line1\n    indented\n\n  line3\nb: 1\n", `{"a": "line1\n  indented\n\nline3\n", "b": 1}`},
+		{"literal strip", "a: |-\n  x\n  y\n\n", `{"a": "x\ny"}`},
+		{"literal keep", "a: |+\n  x\n\n", `{"a": "x\n\n"}`},
+		{"folded", "a: >\n  one\n  two\n\n  three\n", `{"a": "one two\nthree\n"}`},
+		{"folded more indented", "a: >-\n  one\n    code\n  two\n", `{"a": "one\n  code\ntwo"}`},
+		{"literal at end", "a: |\n  x", `{"a": "x\n"}`},
+		{"top level sequence", "- a\n- b\n", `["a", "b"]`},
+		{"crlf", "a: 1\r\nb: x\r\n", `{"a": 1, "b": "x"}`},
+		{"empty value", "a:\nb: 1\n", `{"a": null, "b": 1}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseYAML([]byte(tc.in))
+			if err != nil {
+				t.Fatalf("parseYAML: %v", err)
+			}
+			assertJSON(t, got, tc.want)
+		})
+	}
+}
+
+func TestParseYAMLError(t *testing.T) {
+	for _, tc := range []struct {
+		name, in, want string
+	}{
+		{"tab indentation", "a:\n\tb: 1\n", "line 2: tabs are not allowed"},
+		{"multiple documents", "a: 1\n---\nb: 2\n", "line 2: multiple documents"},
+		{"duplicate key", "a: 1\na: 2\n", `line 2: duplicate key "a"`},
+		{"bad indentation", "a:\n    b: 1\n  c: 2\n", "line 3: bad indentation"},
+		{"unterminated flow", "a: [x, y\n", "line 1: unterminated flow collection"},
+		{"unterminated string", "a: \"x\n", "line 1"},
+		{"anchor", "a: &x 1\n", "anchors, aliases and tags are not supported"},
+		{"alias", "a: *x\n", "anchors, aliases and tags are not supported"},
+		{"not a mapping", "a: 1\njust text\n", "line 2"},
+		{"flow separator", "a: [x y: z]\n", "line 1"},
+		{"block scalar header", "a: |2\n  x\n", "unsupported block scalar header"},
+		{"tag", "a: !!str 1\n", "anchors, aliases and tags are not supported"},
+		{"merge key", "<<: {a: 1}\n", "line 1: merge keys are not supported"},
+		{"complex key", "? a\n: b\n", "line 1: complex keys are not supported"},
+		{"directive", "%YAML 1.2\n---\na: 1\n", "line 1: directives are not supported"},
+		{"multi-line plain scalar", "a: foo\n  bar\n", "line 2: multi-line plain scalars are not supported"},
+		{"multi-line quoted scalar", "a: \"foo\n  bar\"\n", "multi-line quoted scalars are not supported"},
+		{"colon in plain scalar", "a: b: c\n", `unexpected ":" in plain scalar "b: c"`},
+		{"reserved indicator", "a: @x\n", "plain scalars can not start with '@'"},
+		{"hex number", "a: 0x1f\n", `unsupported number "0x1f"`},
+		{"infinity", "a: .inf\n", `unsupported number ".inf"`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseYAML([]byte(tc.in))
+			if err == nil {
+				t.Fatalf("parseYAML succeeded, want an error containing %q", tc.want)
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("error %q does not contain %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestYAMLRoundTrip(t *testing.T) {
+	for _, doc := range roundTripDocs {
+		var v any
+		if err := json.Unmarshal([]byte(doc), &v); err != nil {
+			t.Fatal(err)
+		}
+		data, err := marshalAs(".yaml", v)
+		if err != nil {
+			t.Errorf("marshalAs(%s): %v", doc, err)
+			continue
+		}
+		got, err := parseYAML(data)
+		if err != nil {
+			t.Errorf("parseYAML of\n%s\nfrom %s: %v", data, doc, err)
+			continue
+		}
+		assertJSON(t, got, doc)
+	}
+}